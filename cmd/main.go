@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	logger "github.com/sirupsen/logrus"
 
 	"service-auth/internal/app/delivery/http"
+	"service-auth/internal/app/mailer"
 	"service-auth/internal/app/repository"
 	"service-auth/internal/app/service"
 	"service-auth/internal/app/utils"
@@ -22,6 +24,12 @@ import (
 
 // @host      localhost:8080
 // @BasePath  /api/v1
+
+// @securityDefinitions.basic  BasicAuth
+
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
 func main() {
 	// Загружаем конфигурацию
 	cfg, err := configs.LoadConfig("./internal/configs")
@@ -47,15 +55,115 @@ func main() {
 	db.ApplyMigrations(cfg.Database.Dsn, cfg.Database.MigratePath)
 
 	// загрузка auth параметров
-	jwtManager, err := utils.NewJWTManager(cfg.Auth.PrivateKey, cfg.Auth.PublicKey)
+	var jwtManager *utils.JWTManager
+	if cfg.Auth.KeysDir != "" {
+		jwtManager, err = utils.NewJWTManagerFromDir(cfg.Auth.SigningAlgorithm, cfg.Auth.KeysDir, cfg.KeyRetention())
+	} else {
+		jwtManager, err = utils.NewJWTManager(cfg.Auth.SigningAlgorithm, cfg.Auth.PrivateKey, cfg.Auth.PublicKey)
+	}
 	if err != nil {
 		logger.Fatalf("Error creating JWT manager: %v", err)
 		return
 	}
-	repo := repository.NewRepository(dbConn, redisConn)
-	services := service.NewService(repo, jwtManager, cfg)
+	jwtManager.WithIssuer(cfg.Auth.Issuer, cfg.Auth.Audience).WithLeeway(cfg.Auth.ClockSkew)
+
+	// Фоновые задачи живут до остановки сервера
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go jwtManager.RunKeyMaintenance(ctx, cfg.Auth.KeysCheckInterval)
+	hasher, err := utils.NewPasswordHasher(cfg.Password.Algorithm, utils.Argon2Params{
+		Memory:      cfg.Password.Argon2Memory,
+		Iterations:  cfg.Password.Argon2Iterations,
+		Parallelism: cfg.Password.Argon2Parallelism,
+	}, cfg.Password.BcryptCost)
+	if err != nil {
+		logger.Fatalf("Error creating password hasher: %v", err)
+		return
+	}
+	pepperSecrets, err := cfg.Password.PepperSecrets()
+	if err != nil {
+		logger.Fatalf("Error loading password peppers: %v", err)
+		return
+	}
+	peppers, err := utils.NewPeppers(cfg.Password.PepperVersion, pepperSecrets)
+	if err != nil {
+		logger.Fatalf("Error loading password peppers: %v", err)
+		return
+	}
+
+	var breached *utils.BreachedPasswords
+	if cfg.Password.BreachedListFile != "" {
+		breached, err = utils.LoadBreachedPasswords(cfg.Password.BreachedListFile)
+		if err != nil {
+			logger.Fatalf("Error loading breached passwords: %v", err)
+			return
+		}
+	}
+	policy := utils.NewPasswordPolicy(utils.PasswordPolicyParams{
+		MinLength:        cfg.Password.MinLength,
+		MaxLength:        cfg.Password.MaxLength,
+		RequireLowercase: cfg.Password.RequireLowercase,
+		RequireUppercase: cfg.Password.RequireUppercase,
+		RequireDigit:     cfg.Password.RequireDigit,
+		RequireSymbol:    cfg.Password.RequireSymbol,
+	}, breached)
+
+	var cipher *utils.SecretCipher
+	if cfg.MFA.EncryptionKey != "" {
+		cipher, err = utils.NewSecretCipher(cfg.MFA.EncryptionKey)
+		if err != nil {
+			logger.Fatalf("Error creating MFA cipher: %v", err)
+			return
+		}
+	}
+
+	// Письма отправляются фоновой очередью, запросы не ждут доставки
+	mailBackend, err := newMailBackend(cfg.Mail)
+	if err != nil {
+		logger.Fatalf("Error creating mailer: %v", err)
+		return
+	}
+	mailTemplates, err := mailer.NewTemplates(cfg.Mail.Locale)
+	if err != nil {
+		logger.Fatalf("Error loading mail templates: %v", err)
+		return
+	}
+	mailQueue := mailer.NewQueue(mailBackend, cfg.Mail.QueueSize, cfg.Mail.Retries, cfg.Mail.RetryDelay)
+	go mailQueue.Run(ctx, cfg.Mail.Workers)
+
+	repo := repository.NewRepository(dbConn, redisConn, cfg.Redis.KeyPrefix, []byte(cfg.Auth.TokenHashSecret))
+	services := service.NewService(repo, jwtManager, utils.NewPasswordManager(hasher, peppers), policy, cipher, mailQueue, mailTemplates, cfg)
 	handlers := http.NewHandler(services, cfg)
 
+	// Учетные записи с истекшим сроком отмены удаления очищаются в фоне
+	go services.RunAccountPurge(ctx, cfg.Account.PurgeInterval)
+	// Выгрузки данных собираются фоновыми обработчиками, остановка вместе с приложением
+	go services.RunExports(ctx, cfg.Export.Workers)
+
 	// Настройка и запуск сервера
 	server.SetupAndRunServer(&cfg.Server, handlers.InitRoutes())
 }
+
+// newMailBackend создает способ доставки писем по mail.backend
+func newMailBackend(cfg configs.MailConfig) (mailer.Mailer, error) {
+	switch cfg.Backend {
+	case "log":
+		logger.Warn("Mail backend log is for development only: mails are not delivered")
+		return mailer.NewLogMailer(), nil
+	case "file":
+		return mailer.NewFileMailer(cfg.Dir, cfg.From)
+	case "smtp":
+		return mailer.NewSMTPMailer(mailer.SMTPParams{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+			Security: cfg.SMTPSecurity,
+			Timeout:  cfg.Timeout,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported mail backend %q", cfg.Backend)
+	}
+}
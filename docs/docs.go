@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
+        "/admin/keys/rotate": {
             "post": {
-                "description": "Logs in a user and returns access and refresh tokens",
+                "description": "Promotes a key from the keyring (or a freshly generated one) to the active signing key. The previous key stays available for verification until its tokens expire.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,35 +25,101 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "UserInput login",
+                "summary": "Rotate signing key",
                 "parameters": [
                     {
-                        "description": "Login credentials",
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Key to promote",
                         "name": "input",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.SignInInput"
+                            "$ref": "#/definitions/models.RotateKeysInput"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Access and refresh tokens",
+                        "description": "Active key id",
                         "schema": {
-                            "$ref": "#/definitions/models.Tokens"
+                            "$ref": "#/definitions/models.KeyIdResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid input format",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Signing key not found",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Key rotation is disabled",
                         "schema": {
                             "$ref": "#/definitions/middleware.ValidationErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/export": {
+            "post": {
+                "description": "Starts building a personal data export of any user. No email is sent; the download link is returned by the status endpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Request user data export (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Export started",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataExport"
+                        }
                     },
                     "403": {
-                        "description": "invalid username or password",
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Too many exports in progress",
                         "schema": {
                             "$ref": "#/definitions/middleware.ValidationErrorResponse"
                         }
@@ -61,9 +127,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/refresh": {
+        "/admin/users/{id}/export/{export_id}": {
+            "get": {
+                "description": "Returns the status of a user's data export; a ready export includes an expiring download link",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "User data export status (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Export ID",
+                        "name": "export_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export status",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataExport"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found or expired",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/email-change/confirm": {
             "post": {
-                "description": "Refreshes the access token using a valid refresh token",
+                "description": "Makes the pending email the account email using the token sent to the new address. The token is single-use",
                 "consumes": [
                     "application/json"
                 ],
@@ -73,23 +194,78 @@ const docTemplate = `{
                 "tags": [
                     "auth"
                 ],
-                "summary": "Refresh access token",
+                "summary": "Confirm email change",
                 "parameters": [
                     {
-                        "description": "Refresh token",
+                        "description": "Confirmation token",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.InputRefresh"
+                            "$ref": "#/definitions/models.VerifyEmailInput"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "New access and refresh tokens",
+                        "description": "Email changed",
                         "schema": {
-                            "$ref": "#/definitions/models.Tokens"
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or expired token, or email already used",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Account is scheduled for deletion",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/introspect": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Reports whether a token is active and returns its claims (RFC 7662). Requires client credentials (HTTP Basic).",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Token introspection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token to introspect",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access_token or refresh_token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token state",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntrospectionResponse"
                         }
                     },
                     "400": {
@@ -99,7 +275,7 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Unauthorized or invalid refresh token",
+                        "description": "Invalid client credentials",
                         "schema": {
                             "$ref": "#/definitions/middleware.ValidationErrorResponse"
                         }
@@ -107,9 +283,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/register": {
+        "/auth/login": {
             "post": {
-                "description": "Creates a new user account",
+                "description": "Logs in a user and returns access and refresh tokens. If MFA is enabled, returns mfa_required and mfa_token for /auth/mfa/verify instead.\nIf the user's role requires MFA that is not enabled yet, returns mfa_enrollment_required and mfa_token for /auth/mfa/enroll",
                 "consumes": [
                     "application/json"
                 ],
@@ -119,27 +295,82 @@ const docTemplate = `{
                 "tags": [
                     "auth"
                 ],
-                "summary": "Register a new user",
+                "summary": "UserInput login",
                 "parameters": [
                     {
-                        "description": "UserInput details",
+                        "description": "Login credentials",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UserInput"
+                            "$ref": "#/definitions/models.SignInInput"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "UserInput ID",
+                    "200": {
+                        "description": "Access and refresh tokens or MFA challenge",
                         "schema": {
-                            "$ref": "#/definitions/models.UserIdResponse"
+                            "$ref": "#/definitions/models.Tokens"
                         }
                     },
                     "400": {
-                        "description": "Invalid input format or validation errors",
+                        "description": "Invalid input format",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid username or password, or email is not verified",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Account is temporarily locked (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many login attempts (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Role requires MFA, but MFA is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes every refresh token of the authenticated user and rejects access tokens issued before the call",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log out everywhere",
+                "responses": {
+                    "200": {
+                        "description": "All sessions revoked",
+                        "schema": {
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/middleware.ValidationErrorResponse"
                         }
@@ -153,9 +384,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/revoke-token": {
-            "delete": {
-                "description": "Revokes the specified refresh token",
+        "/auth/mfa/enroll": {
+            "post": {
+                "description": "Generates a TOTP secret for a user whose role requires MFA, using the mfa_token returned by /auth/login with mfa_enrollment_required",
                 "consumes": [
                     "application/json"
                 ],
@@ -163,23 +394,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "mfa"
                 ],
-                "summary": "Revoke refresh token",
+                "summary": "Start required TOTP enrollment",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Refresh token",
-                        "name": "refresh_token",
-                        "in": "query",
-                        "required": true
+                        "description": "MFA enrollment token",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFAEnrollInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Token revoked successfully",
+                        "description": "TOTP secret and otpauth URI",
                         "schema": {
-                            "$ref": "#/definitions/http.SuccessResponse"
+                            "$ref": "#/definitions/models.TOTPEnrollment"
                         }
                     },
                     "400": {
@@ -188,55 +421,1720 @@ const docTemplate = `{
                             "$ref": "#/definitions/middleware.ValidationErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "Invalid mfa token",
                         "schema": {
                             "$ref": "#/definitions/middleware.ValidationErrorResponse"
                         }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "http.SuccessResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string"
-                }
-            }
-        },
-        "middleware.ValidationErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "object",
-                    "properties": {
-                        "code": {
-                            "type": "integer"
-                        },
-                        "fields": {
-                            "description": "Поля с ошибками",
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        },
-                        "message": {
-                            "type": "string"
+                    },
+                    "409": {
+                        "description": "MFA already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "MFA is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
                         }
                     }
                 }
             }
         },
-        "models.InputRefresh": {
-            "type": "object",
+        "/auth/mfa/enroll/confirm": {
+            "post": {
+                "description": "Enables TOTP with the first code and returns single-use recovery codes (shown only once). No tokens are issued: the user signs in again and completes /auth/mfa/verify",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Confirm required TOTP enrollment",
+                "parameters": [
+                    {
+                        "description": "MFA enrollment token and TOTP code",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFAVerifyInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "MFA enabled, recovery codes",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or enrollment not started",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid mfa token or code",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "MFA already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many invalid codes (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/mfa/verify": {
+            "post": {
+                "description": "Exchanges the mfa_token from /auth/login and a TOTP code (or a single-use recovery code) for access and refresh tokens",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Complete MFA login",
+                "parameters": [
+                    {
+                        "description": "MFA token and code",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFAVerifyInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Access and refresh tokens",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tokens"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input format",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid mfa token or code",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many invalid codes (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Sends a single-use password reset link if the address belongs to an account. Always returns 202 so the response does not reveal registered addresses",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Request password reset",
+                "parameters": [
+                    {
+                        "description": "Email address",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ForgotPasswordInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Request accepted",
+                        "schema": {
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input format",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Sets a new password using the token from the reset email. The token is single-use; all sessions of the user are revoked",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ResetPasswordInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password changed",
+                        "schema": {
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or expired token, or password does not meet policy",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Refreshes the access token using a valid refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.InputRefresh"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New access and refresh tokens",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tokens"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input format",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role requires MFA that is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Creates a new user account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "UserInput details",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "UserInput ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserIdResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input format or validation errors",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/restore-account": {
+            "post": {
+                "description": "Cancels a pending account deletion with the username and password while the grace period lasts. Revoked tokens are not restored, the user has to log in again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Cancel account deletion",
+                "parameters": [
+                    {
+                        "description": "Username and password",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SignInInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Account restored",
+                        "schema": {
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Password is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found or grace period expired",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many attempts (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/revoke-token": {
+            "delete": {
+                "description": "Revokes the specified refresh token and, if passed (body, Authorization header or cookie), the access token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Revoke refresh token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Refresh token",
+                        "name": "refresh_token",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Access token",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevokeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input format",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns active sessions (devices) of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "List active sessions",
+                "responses": {
+                    "200": {
+                        "description": "Active sessions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Session"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Terminates a session of the authenticated user by revoking its refresh and access tokens",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Terminate session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session terminated",
+                        "schema": {
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email": {
+            "post": {
+                "description": "Confirms the user's email with the token from the verification email. The token is single-use and becomes invalid if the email changes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Confirm email address",
+                "parameters": [
+                    {
+                        "description": "Verification token",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.VerifyEmailInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email verified",
+                        "schema": {
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or expired token",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email/resend": {
+            "post": {
+                "description": "Sends a new verification link if the address belongs to an unverified account. Always returns 202 so the response does not reveal registered addresses",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Resend verification email",
+                "parameters": [
+                    {
+                        "description": "Email address",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ResendVerificationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Request accepted",
+                        "schema": {
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input format",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/webauthn/login/begin": {
+            "post": {
+                "description": "Returns PublicKeyCredentialRequestOptions for navigator.credentials.get. Without username a discoverable passkey is expected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webauthn"
+                ],
+                "summary": "Begin passkey login",
+                "parameters": [
+                    {
+                        "description": "Optional username",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebAuthnLoginBeginInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Request options",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebAuthnRequestOptions"
+                        }
+                    },
+                    "501": {
+                        "description": "WebAuthn is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/webauthn/login/finish": {
+            "post": {
+                "description": "Verifies the authenticator assertion and returns access and refresh tokens, or mfa_enrollment_required if the user's role requires MFA that is not enabled yet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webauthn"
+                ],
+                "summary": "Finish passkey login",
+                "parameters": [
+                    {
+                        "description": "PublicKeyCredential JSON",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebAuthnLoginInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Access and refresh tokens",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tokens"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid response or challenge",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Verification failed",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Email is not verified",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Account is temporarily locked (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/exports/download": {
+            "get": {
+                "description": "Downloads the export archive by the signed link. The link expires with the export and is invalidated by a password change or account deletion",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Download personal data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Download token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export archive",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataExportArchive"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or expired link",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found or expired",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the profile of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Current user profile",
+                "responses": {
+                    "200": {
+                        "description": "User profile",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserProfile"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Schedules the account for deletion after checking the password. All tokens are revoked and login is blocked at once; the deletion can be cancelled until purge_at, after which the account data is erased",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete current user account",
+                "parameters": [
+                    {
+                        "description": "Current password",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteAccountInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Account scheduled for deletion",
+                        "schema": {
+                            "$ref": "#/definitions/models.AccountDeletion"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Password is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many attempts (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates mutable profile fields. Changing the email requires current_password. A new email is stored as pending: a confirmation link is sent to the new address and a notice to the current one; the email changes only after confirmation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update current user profile",
+                "parameters": [
+                    {
+                        "description": "Profile fields to change",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateProfileInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated profile",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input, username or email already used",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or invalid current password",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Email change requested too often, or too many password attempts (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/export": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts building a JSON archive with all data the service holds about the user: profile, sessions, MFA metadata and audit events. The archive is built asynchronously; when ready, a download link is emailed and returned by the status endpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Request personal data export",
+                "responses": {
+                    "202": {
+                        "description": "Export started",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataExport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Export requested too often (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Too many exports in progress",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/export/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the export status; a ready export includes an expiring download link",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Personal data export status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export status",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataExport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found or expired",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/mfa/recovery-codes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns how many unused recovery codes remain",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Remaining recovery codes",
+                "responses": {
+                    "200": {
+                        "description": "Remaining recovery codes",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecoveryCodesStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issues a new batch of single-use recovery codes (shown only once); the previous batch stops working",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Regenerate recovery codes",
+                "responses": {
+                    "200": {
+                        "description": "New recovery codes",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "MFA is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/mfa/totp": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generates a new TOTP secret and otpauth:// URI. MFA is enabled only after confirmation with a first code",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Start TOTP enrollment",
+                "responses": {
+                    "200": {
+                        "description": "TOTP secret and otpauth URI",
+                        "schema": {
+                            "$ref": "#/definitions/models.TOTPEnrollment"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "MFA already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "MFA is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/mfa/totp/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enables TOTP after verifying the first code from the authenticator app and returns single-use recovery codes (shown only once)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "mfa"
+                ],
+                "summary": "Confirm TOTP enrollment",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFACodeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "MFA enabled, recovery codes",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or enrollment not started",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid code",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "MFA already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many invalid codes (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes the password of the authenticated user after checking the current one. The caller's session stays active; all other sessions and tokens issued before the change are revoked",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "description": "Current and new password",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChangePasswordInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password changed",
+                        "schema": {
+                            "$ref": "#/definitions/http.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or new password does not meet policy",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Current password is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many attempts (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/webauthn/register/begin": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns PublicKeyCredentialCreationOptions for navigator.credentials.create (binary fields are base64url)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webauthn"
+                ],
+                "summary": "Begin passkey registration",
+                "responses": {
+                    "200": {
+                        "description": "Creation options",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebAuthnCreationOptions"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "WebAuthn is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/webauthn/register/finish": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Verifies the authenticator response (attestation \"none\") and stores the passkey",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webauthn"
+                ],
+                "summary": "Finish passkey registration",
+                "parameters": [
+                    {
+                        "description": "PublicKeyCredential JSON",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebAuthnRegistrationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Registered passkey",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebAuthnCredential"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid response or challenge",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or verification failed",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Passkey already registered",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "http.SuccessResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "middleware.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "object",
+                    "properties": {
+                        "code": {
+                            "type": "integer"
+                        },
+                        "fields": {
+                            "description": "Поля с ошибками",
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "message": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "models.AccountDeletion": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "type": "string"
+                },
+                "purge_at": {
+                    "description": "до этого времени удаление можно отменить",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ChangePasswordInput": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DataExport": {
+            "type": "object",
+            "properties": {
+                "download_url": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "после этого выгрузка удаляется",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ready_at": {
+                    "type": "string"
+                },
+                "requested_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DataExportArchive": {
+            "type": "object",
+            "properties": {
+                "audit_events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEvent"
+                    }
+                },
+                "consents": {
+                    "description": "сервис согласий не собирает, раздел всегда пуст",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExportedConsent"
+                    }
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "mfa": {
+                    "$ref": "#/definitions/models.ExportedMFA"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Session"
+                    }
+                },
+                "user": {
+                    "$ref": "#/definitions/models.ExportedUser"
+                }
+            }
+        },
+        "models.DeleteAccountInput": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportedConsent": {
+            "type": "object",
+            "properties": {
+                "granted_at": {
+                    "type": "string"
+                },
+                "purpose": {
+                    "description": "цель обработки",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "версия текста, с которым пользователь согласился",
+                    "type": "string"
+                },
+                "withdrawn_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportedMFA": {
+            "type": "object",
+            "properties": {
+                "recovery_codes_remaining": {
+                    "type": "integer"
+                },
+                "totp_enabled": {
+                    "type": "boolean"
+                },
+                "totp_enabled_at": {
+                    "type": "string"
+                },
+                "webauthn_credentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WebAuthnCredential"
+                    }
+                }
+            }
+        },
+        "models.ExportedUser": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_verified": {
+                    "type": "boolean"
+                },
+                "failed_login_attempts": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "locked_until": {
+                    "type": "string"
+                },
+                "password_changed_at": {
+                    "type": "string"
+                },
+                "pending_email": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ForgotPasswordInput": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.InputRefresh": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.IntrospectionResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "aud": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "exp": {
+                    "type": "integer"
+                },
+                "iat": {
+                    "type": "integer"
+                },
+                "iss": {
+                    "type": "string"
+                },
+                "jti": {
+                    "type": "string"
+                },
+                "nbf": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "sub": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.KeyIdResponse": {
+            "type": "object",
+            "properties": {
+                "kid": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MFACodeInput": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MFAEnrollInput": {
+            "type": "object",
+            "required": [
+                "mfa_token"
+            ],
+            "properties": {
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MFAVerifyInput": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RecoveryCodes": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.RecoveryCodesStatus": {
+            "type": "object",
+            "properties": {
+                "remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ResendVerificationInput": {
+            "type": "object",
             "required": [
-                "refresh_token"
+                "email"
             ],
             "properties": {
-                "refresh_token": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResetPasswordInput": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RevokeInput": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RotateKeysInput": {
+            "type": "object",
+            "properties": {
+                "kid": {
+                    "description": "kid уже загруженного в связку ключа, пусто - сгенерировать новый",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Session": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_refreshed_at": {
+                    "type": "string"
+                },
+                "user_agent": {
                     "type": "string"
                 }
             }
@@ -256,17 +2154,55 @@ const docTemplate = `{
                 }
             }
         },
+        "models.TOTPEnrollment": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "description": "base32, для ручного ввода",
+                    "type": "string"
+                },
+                "uri": {
+                    "description": "otpauth:// для QR-кода",
+                    "type": "string"
+                }
+            }
+        },
         "models.Tokens": {
             "type": "object",
             "properties": {
                 "access_token": {
                     "type": "string"
                 },
+                "mfa_enrollment_required": {
+                    "type": "boolean"
+                },
+                "mfa_required": {
+                    "type": "boolean"
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
                 "refresh_token": {
                     "type": "string"
                 }
             }
         },
+        "models.UpdateProfileInput": {
+            "type": "object",
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "minLength": 5
+                }
+            }
+        },
         "models.UserIdResponse": {
             "type": "object",
             "properties": {
@@ -287,9 +2223,8 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "password": {
-                    "type": "string",
-                    "maxLength": 16,
-                    "minLength": 8
+                    "description": "длина и состав проверяются политикой паролей",
+                    "type": "string"
                 },
                 "username": {
                     "type": "string",
@@ -297,6 +2232,298 @@ const docTemplate = `{
                     "minLength": 5
                 }
             }
+        },
+        "models.UserProfile": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_verified": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mfa_enabled": {
+                    "type": "boolean"
+                },
+                "password_changed_at": {
+                    "type": "string"
+                },
+                "pending_email": {
+                    "description": "новый email, ожидающий подтверждения",
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.VerifyEmailInput": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnAssertionResponse": {
+            "type": "object",
+            "required": [
+                "authenticatorData",
+                "clientDataJSON",
+                "signature"
+            ],
+            "properties": {
+                "authenticatorData": {
+                    "type": "string"
+                },
+                "clientDataJSON": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "userHandle": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnAttestationResponse": {
+            "type": "object",
+            "required": [
+                "attestationObject",
+                "clientDataJSON"
+            ],
+            "properties": {
+                "attestationObject": {
+                    "type": "string"
+                },
+                "clientDataJSON": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnAuthenticatorSelection": {
+            "type": "object",
+            "properties": {
+                "residentKey": {
+                    "type": "string"
+                },
+                "userVerification": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnCreationOptions": {
+            "type": "object",
+            "properties": {
+                "attestation": {
+                    "type": "string"
+                },
+                "authenticatorSelection": {
+                    "$ref": "#/definitions/models.WebAuthnAuthenticatorSelection"
+                },
+                "challenge": {
+                    "description": "base64url",
+                    "type": "string"
+                },
+                "excludeCredentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WebAuthnCredentialDescriptor"
+                    }
+                },
+                "pubKeyCredParams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WebAuthnCredentialParameter"
+                    }
+                },
+                "rp": {
+                    "$ref": "#/definitions/models.WebAuthnRelyingParty"
+                },
+                "timeout": {
+                    "description": "мс",
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.WebAuthnUser"
+                }
+            }
+        },
+        "models.WebAuthnCredential": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnCredentialDescriptor": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "base64url",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnCredentialParameter": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnLoginBeginInput": {
+            "type": "object",
+            "properties": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnLoginInput": {
+            "type": "object",
+            "required": [
+                "id",
+                "rawId",
+                "response",
+                "type"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "rawId": {
+                    "type": "string"
+                },
+                "response": {
+                    "$ref": "#/definitions/models.WebAuthnAssertionResponse"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnRegistrationInput": {
+            "type": "object",
+            "required": [
+                "id",
+                "rawId",
+                "response",
+                "type"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "имя ключа для пользователя",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "rawId": {
+                    "type": "string"
+                },
+                "response": {
+                    "$ref": "#/definitions/models.WebAuthnAttestationResponse"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnRelyingParty": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnRequestOptions": {
+            "type": "object",
+            "properties": {
+                "allowCredentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WebAuthnCredentialDescriptor"
+                    }
+                },
+                "challenge": {
+                    "description": "base64url",
+                    "type": "string"
+                },
+                "rpId": {
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "мс",
+                    "type": "integer"
+                },
+                "userVerification": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebAuthnUser": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "base64url",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BasicAuth": {
+            "type": "basic"
+        },
+        "BearerAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
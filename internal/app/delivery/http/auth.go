@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 
+	"service-auth/internal/app/delivery/middleware"
 	"service-auth/internal/app/errs"
 	"service-auth/internal/app/models"
 	"service-auth/internal/app/service"
@@ -65,14 +66,18 @@ func (h *Auth) Register(ctx *gin.Context) {
 
 // Login godoc
 // @Summary UserInput login
-// @Description Logs in a user and returns access and refresh tokens
+// @Description Logs in a user and returns access and refresh tokens. If MFA is enabled, returns mfa_required and mfa_token for /auth/mfa/verify instead.
+// @Description If the user's role requires MFA that is not enabled yet, returns mfa_enrollment_required and mfa_token for /auth/mfa/enroll
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body models.SignInInput true "Login credentials"
-// @Success 200 {object} models.Tokens "Access and refresh tokens"
+// @Success 200 {object} models.Tokens "Access and refresh tokens or MFA challenge"
 // @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input format"
-// @Failure 403 {object} middleware.ValidationErrorResponse "invalid username or password"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Invalid username or password, or email is not verified"
+// @Failure 423 {object} middleware.ValidationErrorResponse "Account is temporarily locked (see Retry-After)"
+// @Failure 429 {object} middleware.ValidationErrorResponse "Too many login attempts (see Retry-After)"
+// @Failure 501 {object} middleware.ValidationErrorResponse "Role requires MFA, but MFA is not configured"
 // @Router /auth/login [post]
 func (h *Auth) Login(ctx *gin.Context) {
 	var input models.SignInInput
@@ -83,14 +88,50 @@ func (h *Auth) Login(ctx *gin.Context) {
 		return
 	}
 
-	tokens, err := h.services.GenerateTokens(ctx, input.Username, input.Password)
+	tokens, err := h.services.GenerateTokens(ctx, input.Username, input.Password, clientInfo(ctx))
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	ctx.SetCookie("access_token", tokens.AccessToken, int(h.cfg.Auth.AccessTokenTTL), "/", "", true, true)
-	ctx.SetCookie("refresh_token", tokens.RefreshToken, int(h.cfg.Auth.RefreshTokenTTL), "/", "", true, true)
+	// второй фактор: куки выставляются только после /auth/mfa/verify
+	if tokens.MFARequired || tokens.MFAEnrollmentRequired {
+		ctx.JSON(http.StatusOK, tokens)
+		return
+	}
+
+	setTokenCookies(ctx, h.cfg, tokens)
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// VerifyMFA godoc
+// @Summary Complete MFA login
+// @Description Exchanges the mfa_token from /auth/login and a TOTP code (or a single-use recovery code) for access and refresh tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.MFAVerifyInput true "MFA token and code"
+// @Success 200 {object} models.Tokens "Access and refresh tokens"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input format"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Invalid mfa token or code"
+// @Failure 429 {object} middleware.ValidationErrorResponse "Too many invalid codes (see Retry-After)"
+// @Router /auth/mfa/verify [post]
+func (h *Auth) VerifyMFA(ctx *gin.Context) {
+	var input models.MFAVerifyInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	tokens, err := h.services.VerifyMFA(ctx, input.MFAToken, input.Code, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	setTokenCookies(ctx, h.cfg, tokens)
 
 	ctx.JSON(http.StatusOK, tokens)
 }
@@ -105,6 +146,7 @@ func (h *Auth) Login(ctx *gin.Context) {
 // @Success 200 {object} models.Tokens "New access and refresh tokens"
 // @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input format"
 // @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized or invalid refresh token"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Role requires MFA that is not enabled"
 // @Router /auth/refresh [post]
 func (h *Auth) Refresh(ctx *gin.Context) {
 	var tokens models.Tokens
@@ -116,25 +158,25 @@ func (h *Auth) Refresh(ctx *gin.Context) {
 		return
 	}
 	// Обновление токенов
-	tokens, err := h.services.RefreshTokens(ctx, input.RefreshToken)
+	tokens, err := h.services.RefreshTokens(ctx, input.RefreshToken, clientInfo(ctx))
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	ctx.SetCookie("access_token", tokens.AccessToken, int(h.cfg.Auth.AccessTokenTTL), "/", "", true, true)
-	ctx.SetCookie("refresh_token", tokens.RefreshToken, int(h.cfg.Auth.RefreshTokenTTL), "/", "", true, true)
+	setTokenCookies(ctx, h.cfg, tokens)
 
 	ctx.JSON(http.StatusOK, tokens)
 }
 
 // Revoke godoc
 // @Summary Revoke refresh token
-// @Description Revokes the specified refresh token
+// @Description Revokes the specified refresh token and, if passed (body, Authorization header or cookie), the access token
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param refresh_token query string true "Refresh token"
+// @Param input body models.RevokeInput false "Access token"
 // @Success 200 {object} SuccessResponse "Token revoked successfully"
 // @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input format"
 // @Failure 500 {object} middleware.ValidationErrorResponse "Internal server error"
@@ -146,13 +188,144 @@ func (h *Auth) Revoke(ctx *gin.Context) {
 		return
 	}
 
+	var input models.RevokeInput
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
 	err := h.services.RevokeToken(ctx, token)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
+	// Access токен отзывается через denylist, чтобы выход вступал в силу сразу.
+	// Из query он не принимается: URL оседает в логах прокси и истории браузера
+	accessToken := input.AccessToken
+	if accessToken == "" {
+		accessToken = middleware.AccessToken(ctx)
+	}
+	if accessToken != "" {
+		if err := h.services.RevokeAccessToken(ctx, accessToken); err != nil && !errors.Is(err, errs.ErrTokenExpired) {
+			ctx.Error(err)
+			return
+		}
+	}
+
 	response := SuccessResponse{Message: "Token revoked successfully"}
 
 	ctx.JSON(http.StatusOK, response)
 }
+
+// Introspect godoc
+// @Summary Token introspection
+// @Description Reports whether a token is active and returns its claims (RFC 7662). Requires client credentials (HTTP Basic).
+// @Tags auth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} models.IntrospectionResponse "Token state"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input format"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Invalid client credentials"
+// @Router /auth/introspect [post]
+func (h *Auth) Introspect(ctx *gin.Context) {
+	var input models.IntrospectionInput
+
+	if err := ctx.ShouldBind(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response, err := h.services.Introspect(ctx, input.Token)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Ответ интроспекции не кэшируется (RFC 7662, раздел 4)
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, response)
+}
+
+// LogoutAll godoc
+// @Summary Log out everywhere
+// @Description Revokes every refresh token of the authenticated user and rejects access tokens issued before the call
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "All sessions revoked"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 500 {object} middleware.ValidationErrorResponse "Internal server error"
+// @Router /auth/logout-all [post]
+func (h *Auth) LogoutAll(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.services.RevokeAllForUser(ctx, userID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.SetCookie("access_token", "", -1, "/", "", true, true)
+	ctx.SetCookie("refresh_token", "", -1, "/", "", true, true)
+
+	ctx.JSON(http.StatusOK, SuccessResponse{Message: "All sessions revoked successfully"})
+}
+
+// Sessions godoc
+// @Summary List active sessions
+// @Description Returns active sessions (devices) of the authenticated user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Session "Active sessions"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 500 {object} middleware.ValidationErrorResponse "Internal server error"
+// @Router /auth/sessions [get]
+func (h *Auth) Sessions(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+	claims, _ := middleware.Principal(ctx)
+
+	sessions, err := h.services.GetSessions(ctx, userID, claims.SessionID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Terminate session
+// @Description Terminates a session of the authenticated user by revoking its refresh and access tokens
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} SuccessResponse "Session terminated"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 404 {object} middleware.ValidationErrorResponse "Session not found"
+// @Router /auth/sessions/{id} [delete]
+func (h *Auth) RevokeSession(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.services.RevokeSession(ctx, userID, ctx.Param("id")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SuccessResponse{Message: "Session terminated successfully"})
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"service-auth/internal/app/models"
+)
+
+// VerifyEmail godoc
+// @Summary Confirm email address
+// @Description Confirms the user's email with the token from the verification email. The token is single-use and becomes invalid if the email changes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.VerifyEmailInput true "Verification token"
+// @Success 200 {object} SuccessResponse "Email verified"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid or expired token"
+// @Router /auth/verify-email [post]
+func (h *Auth) VerifyEmail(ctx *gin.Context) {
+	var input models.VerifyEmailInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.services.VerifyEmail(ctx, input.Token); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SuccessResponse{Message: "Email verified successfully"})
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Sends a new verification link if the address belongs to an unverified account. Always returns 202 so the response does not reveal registered addresses
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.ResendVerificationInput true "Email address"
+// @Success 202 {object} SuccessResponse "Request accepted"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input format"
+// @Router /auth/verify-email/resend [post]
+func (h *Auth) ResendVerification(ctx *gin.Context) {
+	var input models.ResendVerificationInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.services.ResendVerification(ctx, input.Email); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, SuccessResponse{Message: "If the address needs verification, an email has been sent"})
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/service"
+)
+
+type Exports struct {
+	services service.Service
+}
+
+func NewExports(services service.Service) *Exports {
+	return &Exports{services: services}
+}
+
+// RequestExport godoc
+// @Summary Request personal data export
+// @Description Starts building a JSON archive with all data the service holds about the user: profile, sessions, MFA metadata and audit events. The archive is built asynchronously; when ready, a download link is emailed and returned by the status endpoint
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} models.DataExport "Export started"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 429 {object} middleware.ValidationErrorResponse "Export requested too often (see Retry-After)"
+// @Failure 503 {object} middleware.ValidationErrorResponse "Too many exports in progress"
+// @Router /users/me/export [post]
+func (h *Exports) RequestExport(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	export, err := h.services.RequestExport(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, export)
+}
+
+// GetExport godoc
+// @Summary Personal data export status
+// @Description Returns the export status; a ready export includes an expiring download link
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Export ID"
+// @Success 200 {object} models.DataExport "Export status"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 404 {object} middleware.ValidationErrorResponse "Export not found or expired"
+// @Router /users/me/export/{id} [get]
+func (h *Exports) GetExport(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	export, err := h.services.GetExport(ctx, userID, ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, export)
+}
+
+// AdminRequestExport godoc
+// @Summary Request user data export (admin)
+// @Description Starts building a personal data export of any user. No email is sent; the download link is returned by the status endpoint
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param id path string true "User ID"
+// @Success 202 {object} models.DataExport "Export started"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Forbidden"
+// @Failure 404 {object} middleware.ValidationErrorResponse "User not found"
+// @Failure 503 {object} middleware.ValidationErrorResponse "Too many exports in progress"
+// @Router /admin/users/{id}/export [post]
+func (h *Exports) AdminRequestExport(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(errs.ErrUserNotFound)
+		return
+	}
+
+	export, err := h.services.RequestExportByAdmin(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, export)
+}
+
+// AdminGetExport godoc
+// @Summary User data export status (admin)
+// @Description Returns the status of a user's data export; a ready export includes an expiring download link
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param id path string true "User ID"
+// @Param export_id path string true "Export ID"
+// @Success 200 {object} models.DataExport "Export status"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Forbidden"
+// @Failure 404 {object} middleware.ValidationErrorResponse "Export not found or expired"
+// @Router /admin/users/{id}/export/{export_id} [get]
+func (h *Exports) AdminGetExport(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(errs.ErrExportNotFound)
+		return
+	}
+
+	export, err := h.services.GetExport(ctx, userID, ctx.Param("export_id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, export)
+}
+
+// DownloadExport godoc
+// @Summary Download personal data export
+// @Description Downloads the export archive by the signed link. The link expires with the export and is invalidated by a password change or account deletion
+// @Tags exports
+// @Produce json
+// @Param token query string true "Download token"
+// @Success 200 {object} models.DataExportArchive "Export archive"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid or expired link"
+// @Failure 404 {object} middleware.ValidationErrorResponse "Export not found or expired"
+// @Router /exports/download [get]
+func (h *Exports) DownloadExport(ctx *gin.Context) {
+	archive, err := h.services.DownloadExport(ctx, ctx.Query("token"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filename := "data-export-" + time.Now().UTC().Format("2006-01-02") + ".json"
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "application/json", archive)
+}
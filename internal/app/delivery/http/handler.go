@@ -2,6 +2,7 @@ package http
 
 import (
 	"github.com/gin-gonic/gin"
+	logger "github.com/sirupsen/logrus"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -18,24 +19,92 @@ type AuthHandler interface {
 	Login(ctx *gin.Context)
 	Refresh(ctx *gin.Context)
 	Revoke(ctx *gin.Context)
+	Introspect(ctx *gin.Context)
+	LogoutAll(ctx *gin.Context)
+	Sessions(ctx *gin.Context)
+	RevokeSession(ctx *gin.Context)
+	VerifyMFA(ctx *gin.Context)
+	VerifyEmail(ctx *gin.Context)
+	ResendVerification(ctx *gin.Context)
+	ForgotPassword(ctx *gin.Context)
+	ResetPassword(ctx *gin.Context)
+}
+
+type KeysHandler interface {
+	JWKS(ctx *gin.Context)
+	RotateKeys(ctx *gin.Context)
+}
+
+type UserHandler interface {
+	Me(ctx *gin.Context)
+	ChangePassword(ctx *gin.Context)
+	UpdateProfile(ctx *gin.Context)
+	ConfirmEmailChange(ctx *gin.Context)
+	DeleteAccount(ctx *gin.Context)
+	RestoreAccount(ctx *gin.Context)
+}
+
+type MFAHandler interface {
+	EnrollTOTP(ctx *gin.Context)
+	ConfirmTOTP(ctx *gin.Context)
+	EnrollRequiredTOTP(ctx *gin.Context)
+	ConfirmRequiredTOTP(ctx *gin.Context)
+	RegenerateRecoveryCodes(ctx *gin.Context)
+	RecoveryCodesStatus(ctx *gin.Context)
+}
+
+type WebAuthnHandler interface {
+	BeginRegistration(ctx *gin.Context)
+	FinishRegistration(ctx *gin.Context)
+	BeginLogin(ctx *gin.Context)
+	FinishLogin(ctx *gin.Context)
+}
+
+type ExportHandler interface {
+	RequestExport(ctx *gin.Context)
+	GetExport(ctx *gin.Context)
+	AdminRequestExport(ctx *gin.Context)
+	AdminGetExport(ctx *gin.Context)
+	DownloadExport(ctx *gin.Context)
 }
 
 type Handler struct {
 	AuthHandler
+	KeysHandler
+	UserHandler
+	MFAHandler
+	WebAuthnHandler
+	ExportHandler
+	services service.Service
+	cfg      *configs.Config
 }
 
 func NewHandler(services service.Service, cfg *configs.Config) *Handler {
 	return &Handler{
-		AuthHandler: NewAuth(services, cfg),
+		AuthHandler:     NewAuth(services, cfg),
+		KeysHandler:     NewKeys(services),
+		UserHandler:     NewUsers(services, cfg),
+		MFAHandler:      NewMFA(services),
+		WebAuthnHandler: NewWebAuthn(services, cfg),
+		ExportHandler:   NewExports(services),
+		services:        services,
+		cfg:             cfg,
 	}
 }
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
+	// IP клиента для лимитов входа и сессий берется из X-Forwarded-For только от доверенных прокси,
+	// иначе клиент подставил бы заголовком любой адрес и обошел лимит по IP
+	if err := router.SetTrustedProxies(h.cfg.Server.TrustedProxies); err != nil {
+		logger.Errorf("invalid trusted proxies, forwarded headers are ignored: %v", err)
+		router.SetTrustedProxies(nil)
+	}
 	router.Use(middleware.ErrorHandler())
 
 	docs.SwaggerInfo.BasePath = "/api/v1"
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/.well-known/jwks.json", h.JWKS)
 
 	apiV1 := router.Group("/api/v1")
 	{
@@ -44,8 +113,50 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			auth.POST("/register", h.Register)
 			auth.POST("/login", h.Login)
 			auth.POST("/refresh", h.Refresh)
+			auth.POST("/mfa/verify", h.VerifyMFA)
+			auth.POST("/mfa/enroll", h.EnrollRequiredTOTP)
+			auth.POST("/mfa/enroll/confirm", h.ConfirmRequiredTOTP)
+			auth.POST("/verify-email", h.VerifyEmail)
+			auth.POST("/verify-email/resend", h.ResendVerification)
+			auth.POST("/email-change/confirm", h.ConfirmEmailChange)
+			auth.POST("/restore-account", h.RestoreAccount)
+			auth.POST("/password/forgot", h.ForgotPassword)
+			auth.POST("/password/reset", h.ResetPassword)
+			auth.POST("/webauthn/login/begin", h.BeginLogin)
+			auth.POST("/webauthn/login/finish", h.FinishLogin)
 			auth.DELETE("/revoke-token", h.Revoke)
+			auth.POST("/logout-all", middleware.Authenticate(h.services), h.LogoutAll)
+			auth.GET("/sessions", middleware.Authenticate(h.services), h.Sessions)
+			auth.DELETE("/sessions/:id", middleware.Authenticate(h.services), h.RevokeSession)
+			auth.POST("/introspect",
+				middleware.ClientCredentials("introspection", h.cfg.Auth.IntrospectionCredentials()),
+				h.Introspect)
+		}
+
+		users := apiV1.Group("/users", middleware.Authenticate(h.services))
+		{
+			users.GET("/me", h.Me)
+			users.PATCH("/me", h.UpdateProfile)
+			users.DELETE("/me", h.DeleteAccount)
+			users.POST("/me/password", h.ChangePassword)
+			users.POST("/me/export", h.RequestExport)
+			users.GET("/me/export/:id", h.GetExport)
+			users.POST("/me/mfa/totp", h.EnrollTOTP)
+			users.POST("/me/mfa/totp/confirm", h.ConfirmTOTP)
+			users.GET("/me/mfa/recovery-codes", h.RecoveryCodesStatus)
+			users.POST("/me/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+			users.POST("/me/webauthn/register/begin", h.BeginRegistration)
+			users.POST("/me/webauthn/register/finish", h.FinishRegistration)
 		}
+
+		admin := apiV1.Group("/admin", middleware.AdminKey(h.cfg.Auth.AdminAPIKey))
+		{
+			admin.POST("/keys/rotate", h.RotateKeys)
+			admin.POST("/users/:id/export", h.AdminRequestExport)
+			admin.GET("/users/:id/export/:export_id", h.AdminGetExport)
+		}
+
+		apiV1.GET("/exports/download", h.DownloadExport)
 	}
 
 	return router
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/service"
+)
+
+type Keys struct {
+	services service.Service
+}
+
+func NewKeys(services service.Service) *Keys {
+	return &Keys{services: services}
+}
+
+// JWKS отдает публичные ключи (RFC 7517) для проверки токенов сторонними сервисами.
+// Маршрут находится вне /api/v1, поэтому не описан в swagger.
+func (h *Keys) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, h.services.JWKS())
+}
+
+// RotateKeys godoc
+// @Summary Rotate signing key
+// @Description Promotes a key from the keyring (or a freshly generated one) to the active signing key. The previous key stays available for verification until its tokens expire.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param input body models.RotateKeysInput false "Key to promote"
+// @Success 200 {object} models.KeyIdResponse "Active key id"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Forbidden"
+// @Failure 404 {object} middleware.ValidationErrorResponse "Signing key not found"
+// @Failure 409 {object} middleware.ValidationErrorResponse "Key rotation is disabled"
+// @Router /admin/keys/rotate [post]
+func (h *Keys) RotateKeys(ctx *gin.Context) {
+	var input models.RotateKeysInput
+
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	kid, err := h.services.RotateKeys(input.Kid)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.KeyIdResponse{Kid: kid})
+}
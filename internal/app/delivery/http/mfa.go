@@ -0,0 +1,193 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/service"
+)
+
+type MFA struct {
+	services service.Service
+}
+
+func NewMFA(services service.Service) *MFA {
+	return &MFA{services: services}
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Generates a new TOTP secret and otpauth:// URI. MFA is enabled only after confirmation with a first code
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TOTPEnrollment "TOTP secret and otpauth URI"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 409 {object} middleware.ValidationErrorResponse "MFA already enabled"
+// @Failure 501 {object} middleware.ValidationErrorResponse "MFA is not configured"
+// @Router /users/me/mfa/totp [post]
+func (h *MFA) EnrollTOTP(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.services.EnrollTOTP(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enables TOTP after verifying the first code from the authenticator app and returns single-use recovery codes (shown only once)
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body models.MFACodeInput true "TOTP code"
+// @Success 200 {object} models.RecoveryCodes "MFA enabled, recovery codes"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input or enrollment not started"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized or invalid code"
+// @Failure 409 {object} middleware.ValidationErrorResponse "MFA already enabled"
+// @Failure 429 {object} middleware.ValidationErrorResponse "Too many invalid codes (see Retry-After)"
+// @Router /users/me/mfa/totp/confirm [post]
+func (h *MFA) ConfirmTOTP(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	var input models.MFACodeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	codes, err := h.services.ConfirmTOTP(ctx, userID, input.Code)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, codes)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Issues a new batch of single-use recovery codes (shown only once); the previous batch stops working
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.RecoveryCodes "New recovery codes"
+// @Failure 400 {object} middleware.ValidationErrorResponse "MFA is not enabled"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Router /users/me/mfa/recovery-codes [post]
+func (h *MFA) RegenerateRecoveryCodes(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	codes, err := h.services.RegenerateRecoveryCodes(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, codes)
+}
+
+// RecoveryCodesStatus godoc
+// @Summary Remaining recovery codes
+// @Description Returns how many unused recovery codes remain
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.RecoveryCodesStatus "Remaining recovery codes"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Router /users/me/mfa/recovery-codes [get]
+func (h *MFA) RecoveryCodesStatus(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := h.services.RecoveryCodesStatus(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// EnrollRequiredTOTP godoc
+// @Summary Start required TOTP enrollment
+// @Description Generates a TOTP secret for a user whose role requires MFA, using the mfa_token returned by /auth/login with mfa_enrollment_required
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param input body models.MFAEnrollInput true "MFA enrollment token"
+// @Success 200 {object} models.TOTPEnrollment "TOTP secret and otpauth URI"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input format"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Invalid mfa token"
+// @Failure 409 {object} middleware.ValidationErrorResponse "MFA already enabled"
+// @Failure 501 {object} middleware.ValidationErrorResponse "MFA is not configured"
+// @Router /auth/mfa/enroll [post]
+func (h *MFA) EnrollRequiredTOTP(ctx *gin.Context) {
+	var input models.MFAEnrollInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	enrollment, err := h.services.EnrollRequiredTOTP(ctx, input.MFAToken)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmRequiredTOTP godoc
+// @Summary Confirm required TOTP enrollment
+// @Description Enables TOTP with the first code and returns single-use recovery codes (shown only once). No tokens are issued: the user signs in again and completes /auth/mfa/verify
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param input body models.MFAVerifyInput true "MFA enrollment token and TOTP code"
+// @Success 200 {object} models.RecoveryCodes "MFA enabled, recovery codes"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input or enrollment not started"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Invalid mfa token or code"
+// @Failure 409 {object} middleware.ValidationErrorResponse "MFA already enabled"
+// @Failure 429 {object} middleware.ValidationErrorResponse "Too many invalid codes (see Retry-After)"
+// @Router /auth/mfa/enroll/confirm [post]
+func (h *MFA) ConfirmRequiredTOTP(ctx *gin.Context) {
+	var input models.MFAVerifyInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	codes, err := h.services.ConfirmRequiredTOTP(ctx, input.MFAToken, input.Code)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, codes)
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"service-auth/internal/app/models"
+)
+
+// ForgotPassword godoc
+// @Summary Request password reset
+// @Description Sends a single-use password reset link if the address belongs to an account. Always returns 202 so the response does not reveal registered addresses
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.ForgotPasswordInput true "Email address"
+// @Success 202 {object} SuccessResponse "Request accepted"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input format"
+// @Router /auth/password/forgot [post]
+func (h *Auth) ForgotPassword(ctx *gin.Context) {
+	var input models.ForgotPasswordInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.services.ForgotPassword(ctx, input.Email); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, SuccessResponse{Message: "If the address is registered, a reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Sets a new password using the token from the reset email. The token is single-use; all sessions of the user are revoked
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.ResetPasswordInput true "Reset token and new password"
+// @Success 200 {object} SuccessResponse "Password changed"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid or expired token, or password does not meet policy"
+// @Router /auth/password/reset [post]
+func (h *Auth) ResetPassword(ctx *gin.Context) {
+	var input models.ResetPasswordInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.services.ResetPassword(ctx, input.Token, input.Password); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SuccessResponse{Message: "Password has been reset successfully"})
+}
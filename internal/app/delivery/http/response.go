@@ -1,5 +1,46 @@
 package http
 
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"service-auth/internal/app/delivery/middleware"
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/configs"
+)
+
 type SuccessResponse struct {
 	Message string `json:"message"`
 }
+
+// principalID возвращает id аутентифицированного пользователя.
+// Если principal отсутствует, записывает ошибку в контекст и возвращает false.
+func principalID(ctx *gin.Context) (uuid.UUID, bool) {
+	claims, ok := middleware.Principal(ctx)
+	if !ok {
+		ctx.Error(errs.ErrUnauthorized)
+		return uuid.UUID{}, false
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		ctx.Error(errs.ErrParseUUID)
+		return uuid.UUID{}, false
+	}
+	return userID, true
+}
+
+// clientInfo возвращает данные клиента для записи о сессии
+func clientInfo(ctx *gin.Context) models.ClientInfo {
+	return models.ClientInfo{
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+	}
+}
+
+// setTokenCookies выставляет куки с выданными токенами. MaxAge задается в секундах
+func setTokenCookies(ctx *gin.Context, cfg *configs.Config, tokens models.Tokens) {
+	ctx.SetCookie("access_token", tokens.AccessToken, int(cfg.Auth.AccessTokenTTL.Seconds()), "/", "", true, true)
+	ctx.SetCookie("refresh_token", tokens.RefreshToken, int(cfg.Auth.RefreshTokenTTL.Seconds()), "/", "", true, true)
+}
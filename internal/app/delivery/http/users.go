@@ -0,0 +1,210 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"service-auth/internal/app/delivery/middleware"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/service"
+	"service-auth/internal/configs"
+)
+
+type Users struct {
+	services service.Service
+	cfg      *configs.Config
+}
+
+func NewUsers(services service.Service, cfg *configs.Config) *Users {
+	return &Users{
+		services: services,
+		cfg:      cfg,
+	}
+}
+
+// Me godoc
+// @Summary Current user profile
+// @Description Returns the profile of the authenticated user
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.UserProfile "User profile"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 404 {object} middleware.ValidationErrorResponse "User not found"
+// @Router /users/me [get]
+func (h *Users) Me(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	profile, err := h.services.GetProfile(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, profile)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Changes the password of the authenticated user after checking the current one. The caller's session stays active; all other sessions and tokens issued before the change are revoked
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body models.ChangePasswordInput true "Current and new password"
+// @Success 200 {object} SuccessResponse "Password changed"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input or new password does not meet policy"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Current password is invalid"
+// @Failure 429 {object} middleware.ValidationErrorResponse "Too many attempts (see Retry-After)"
+// @Router /users/me/password [post]
+func (h *Users) ChangePassword(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+	claims, _ := middleware.Principal(ctx)
+
+	var input models.ChangePasswordInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.services.ChangePassword(ctx, userID, claims.SessionID, input, clientInfo(ctx)); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SuccessResponse{Message: "Password changed successfully"})
+}
+
+// UpdateProfile godoc
+// @Summary Update current user profile
+// @Description Updates mutable profile fields. Changing the email requires current_password. A new email is stored as pending: a confirmation link is sent to the new address and a notice to the current one; the email changes only after confirmation
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body models.UpdateProfileInput true "Profile fields to change"
+// @Success 200 {object} models.UserProfile "Updated profile"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input, username or email already used"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Missing or invalid current password"
+// @Failure 429 {object} middleware.ValidationErrorResponse "Email change requested too often, or too many password attempts (see Retry-After)"
+// @Router /users/me [patch]
+func (h *Users) UpdateProfile(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateProfileInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	profile, err := h.services.UpdateProfile(ctx, userID, input, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, profile)
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm email change
+// @Description Makes the pending email the account email using the token sent to the new address. The token is single-use
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.VerifyEmailInput true "Confirmation token"
+// @Success 200 {object} SuccessResponse "Email changed"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid or expired token, or email already used"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Account is scheduled for deletion"
+// @Router /auth/email-change/confirm [post]
+func (h *Users) ConfirmEmailChange(ctx *gin.Context) {
+	var input models.VerifyEmailInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.services.ConfirmEmailChange(ctx, input.Token); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SuccessResponse{Message: "Email changed successfully"})
+}
+
+// DeleteAccount godoc
+// @Summary Delete current user account
+// @Description Schedules the account for deletion after checking the password. All tokens are revoked and login is blocked at once; the deletion can be cancelled until purge_at, after which the account data is erased
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body models.DeleteAccountInput true "Current password"
+// @Success 202 {object} models.AccountDeletion "Account scheduled for deletion"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Password is invalid"
+// @Failure 429 {object} middleware.ValidationErrorResponse "Too many attempts (see Retry-After)"
+// @Router /users/me [delete]
+func (h *Users) DeleteAccount(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	var input models.DeleteAccountInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	deletion, err := h.services.DeleteAccount(ctx, userID, input, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, deletion)
+}
+
+// RestoreAccount godoc
+// @Summary Cancel account deletion
+// @Description Cancels a pending account deletion with the username and password while the grace period lasts. Revoked tokens are not restored, the user has to log in again
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.SignInInput true "Username and password"
+// @Success 200 {object} SuccessResponse "Account restored"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid input"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Password is invalid"
+// @Failure 404 {object} middleware.ValidationErrorResponse "User not found or grace period expired"
+// @Failure 429 {object} middleware.ValidationErrorResponse "Too many attempts (see Retry-After)"
+// @Router /auth/restore-account [post]
+func (h *Users) RestoreAccount(ctx *gin.Context) {
+	var input models.SignInInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.services.RestoreAccount(ctx, input.Username, input.Password, clientInfo(ctx)); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SuccessResponse{Message: "Account restored successfully"})
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/service"
+	"service-auth/internal/configs"
+)
+
+type WebAuthn struct {
+	services service.Service
+	cfg      *configs.Config
+}
+
+func NewWebAuthn(services service.Service, cfg *configs.Config) *WebAuthn {
+	return &WebAuthn{services: services, cfg: cfg}
+}
+
+// BeginRegistration godoc
+// @Summary Begin passkey registration
+// @Description Returns PublicKeyCredentialCreationOptions for navigator.credentials.create (binary fields are base64url)
+// @Tags webauthn
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.WebAuthnCreationOptions "Creation options"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized"
+// @Failure 501 {object} middleware.ValidationErrorResponse "WebAuthn is not configured"
+// @Router /users/me/webauthn/register/begin [post]
+func (h *WebAuthn) BeginRegistration(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	options, err := h.services.BeginRegistration(ctx, userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, options)
+}
+
+// FinishRegistration godoc
+// @Summary Finish passkey registration
+// @Description Verifies the authenticator response (attestation "none") and stores the passkey
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body models.WebAuthnRegistrationInput true "PublicKeyCredential JSON"
+// @Success 201 {object} models.WebAuthnCredential "Registered passkey"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid response or challenge"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Unauthorized or verification failed"
+// @Failure 409 {object} middleware.ValidationErrorResponse "Passkey already registered"
+// @Router /users/me/webauthn/register/finish [post]
+func (h *WebAuthn) FinishRegistration(ctx *gin.Context) {
+	userID, ok := principalID(ctx)
+	if !ok {
+		return
+	}
+
+	var input models.WebAuthnRegistrationInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	cred, err := h.services.FinishRegistration(ctx, userID, input)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, cred)
+}
+
+// BeginLogin godoc
+// @Summary Begin passkey login
+// @Description Returns PublicKeyCredentialRequestOptions for navigator.credentials.get. Without username a discoverable passkey is expected
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param input body models.WebAuthnLoginBeginInput false "Optional username"
+// @Success 200 {object} models.WebAuthnRequestOptions "Request options"
+// @Failure 501 {object} middleware.ValidationErrorResponse "WebAuthn is not configured"
+// @Router /auth/webauthn/login/begin [post]
+func (h *WebAuthn) BeginLogin(ctx *gin.Context) {
+	var input models.WebAuthnLoginBeginInput
+
+	// тело необязательно
+	if err := ctx.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		ctx.Error(err)
+		return
+	}
+
+	options, err := h.services.BeginLogin(ctx, input.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, options)
+}
+
+// FinishLogin godoc
+// @Summary Finish passkey login
+// @Description Verifies the authenticator assertion and returns access and refresh tokens, or mfa_enrollment_required if the user's role requires MFA that is not enabled yet
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param input body models.WebAuthnLoginInput true "PublicKeyCredential JSON"
+// @Success 200 {object} models.Tokens "Access and refresh tokens"
+// @Failure 400 {object} middleware.ValidationErrorResponse "Invalid response or challenge"
+// @Failure 401 {object} middleware.ValidationErrorResponse "Verification failed"
+// @Failure 403 {object} middleware.ValidationErrorResponse "Email is not verified"
+// @Failure 423 {object} middleware.ValidationErrorResponse "Account is temporarily locked (see Retry-After)"
+// @Router /auth/webauthn/login/finish [post]
+func (h *WebAuthn) FinishLogin(ctx *gin.Context) {
+	var input models.WebAuthnLoginInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	tokens, err := h.services.FinishLogin(ctx, input, clientInfo(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// роли нужна MFA, которая еще не настроена: вместо токенов - mfa_token для /auth/mfa/enroll
+	if tokens.MFAEnrollmentRequired {
+		ctx.JSON(http.StatusOK, tokens)
+		return
+	}
+
+	setTokenCookies(ctx, h.cfg, tokens)
+
+	ctx.JSON(http.StatusOK, tokens)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+
+	"service-auth/internal/app/errs"
+)
+
+const AdminKeyHeader = "X-Admin-Key"
+
+// AdminKey пропускает запрос только с ключом администратора в заголовке X-Admin-Key.
+// Если ключ не задан в конфигурации, административные ручки недоступны.
+func AdminKey(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(AdminKeyHeader)
+		if key == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+			c.Error(errs.ErrForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
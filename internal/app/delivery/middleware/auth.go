@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/utils"
+)
+
+const (
+	PrincipalKey      = "principal"
+	AccessTokenCookie = "access_token"
+)
+
+// AccessTokenValidator проверяет access токен и возвращает его claims
+type AccessTokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string) (*utils.Claims, error)
+}
+
+// Authenticate пропускает запрос только с действительным access токеном из заголовка
+// Authorization: Bearer или куки access_token и кладет claims пользователя в контекст
+func Authenticate(validator AccessTokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := AccessToken(c)
+		if token == "" {
+			unauthorized(c)
+			return
+		}
+
+		// Refresh и прочие токены отклоняются валидатором по token_type
+		claims, err := validator.ValidateAccessToken(c, token)
+		if errors.Is(err, errs.ErrValidateInRedis) {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			logger.Debugf("access token rejected: %v", err)
+			unauthorized(c)
+			return
+		}
+
+		c.Set(PrincipalKey, claims)
+		c.Next()
+	}
+}
+
+// Principal возвращает claims пользователя, аутентифицированного Authenticate
+func Principal(c *gin.Context) (*utils.Claims, bool) {
+	value, ok := c.Get(PrincipalKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*utils.Claims)
+	return claims, ok
+}
+
+// AccessToken access токен запроса из заголовка Authorization: Bearer или куки access_token
+func AccessToken(c *gin.Context) string {
+	if token := bearerToken(c); token != "" {
+		return token
+	}
+	token, _ := c.Cookie(AccessTokenCookie)
+	return token
+}
+
+func bearerToken(c *gin.Context) string {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func unauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.Error(errs.ErrUnauthorized)
+	c.Abort()
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+
+	"service-auth/internal/app/errs"
+)
+
+const ClientIDKey = "client_id"
+
+// ClientCredentials проверяет учетные данные клиента (HTTP Basic, client_id:client_secret).
+// Используется для ручек, доступных только доверенным сервисам.
+func ClientCredentials(realm string, clients map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID, clientSecret, ok := c.Request.BasicAuth()
+		secret, known := clients[clientID]
+		if !ok || !known || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(secret)) != 1 {
+			c.Header("WWW-Authenticate", `Basic realm="`+realm+`"`)
+			c.Error(errs.ErrInvalidClient)
+			c.Abort()
+			return
+		}
+
+		c.Set(ClientIDKey, clientID)
+		c.Next()
+	}
+}
@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -32,6 +34,7 @@ func ErrorHandler() gin.HandlerFunc {
 			var fieldErrors map[string]string
 
 			var validationErrs validator.ValidationErrors
+			var fieldsErr *errs.ValidationError
 
 			switch {
 			case errors.Is(err, errs.ErrUserAlreadyExists):
@@ -43,6 +46,33 @@ func ErrorHandler() gin.HandlerFunc {
 			case errors.Is(err, errs.ErrEmailAlreadyUsed):
 				statusCode = http.StatusBadRequest
 				message = "email already used"
+			case errors.Is(err, errs.ErrAccountLocked):
+				statusCode = http.StatusLocked
+				message = "account is temporarily locked"
+			case errors.Is(err, errs.ErrTooManyAttempts):
+				statusCode = http.StatusTooManyRequests
+				message = "too many login attempts, try again later"
+			case errors.Is(err, errs.ErrEmailNotVerified):
+				statusCode = http.StatusForbidden
+				message = "email is not verified"
+			case errors.Is(err, errs.ErrAccountDeleted):
+				statusCode = http.StatusForbidden
+				message = "account is scheduled for deletion"
+			case errors.Is(err, errs.ErrExportNotFound):
+				statusCode = http.StatusNotFound
+				message = "data export not found"
+			case errors.Is(err, errs.ErrExportQueueFull):
+				statusCode = http.StatusServiceUnavailable
+				message = "too many data exports in progress, try again later"
+			case errors.Is(err, errs.ErrExportTokenInvalid):
+				statusCode = http.StatusBadRequest
+				message = "invalid or expired data export link"
+			case errors.Is(err, errs.ErrEmailTokenInvalid):
+				statusCode = http.StatusBadRequest
+				message = "invalid or expired verification token"
+			case errors.Is(err, errs.ErrResetTokenInvalid):
+				statusCode = http.StatusBadRequest
+				message = "invalid or expired password reset token"
 			case errors.Is(err, errs.ErrInvalidPwd):
 				statusCode = http.StatusForbidden
 				message = "invalid username or password"
@@ -59,6 +89,17 @@ func ErrorHandler() gin.HandlerFunc {
 				message = "token invalid"
 				fieldErrors = make(map[string]string)
 				fieldErrors["refresh_token"] = errs.ErrTokenInvalid.Error()
+			case errors.Is(err, errs.ErrTokenRevoked):
+				statusCode = http.StatusUnauthorized
+				message = "token revoked"
+			case errors.Is(err, errs.ErrAccessTokenInvalid):
+				statusCode = http.StatusForbidden
+				message = "invalid token type"
+			case errors.Is(err, errs.ErrTokenReused):
+				statusCode = http.StatusForbidden
+				message = "token invalid"
+				fieldErrors = make(map[string]string)
+				fieldErrors["refresh_token"] = errs.ErrTokenReused.Error()
 			case errors.Is(err, errs.ErrRefreshTokenRequired):
 				statusCode = http.StatusBadRequest
 				message = "token invalid"
@@ -79,6 +120,64 @@ func ErrorHandler() gin.HandlerFunc {
 			case errors.Is(err, errs.ErrFailedToRefresh):
 				statusCode = http.StatusBadRequest
 				message = "try again later"
+			case errors.Is(err, errs.ErrSessionNotFound):
+				statusCode = http.StatusNotFound
+				message = "session not found"
+			case errors.Is(err, errs.ErrUnauthorized):
+				statusCode = http.StatusUnauthorized
+				message = "unauthorized"
+			case errors.Is(err, errs.ErrForbidden):
+				statusCode = http.StatusForbidden
+				message = "forbidden"
+			case errors.Is(err, errs.ErrInvalidClient):
+				statusCode = http.StatusUnauthorized
+				message = "invalid client"
+			case errors.Is(err, errs.ErrKeyNotFound):
+				statusCode = http.StatusNotFound
+				message = "signing key not found"
+			case errors.Is(err, errs.ErrKeyRotationDisabled):
+				statusCode = http.StatusConflict
+				message = "key rotation is disabled"
+			case errors.Is(err, errs.ErrMFANotConfigured):
+				statusCode = http.StatusNotImplemented
+				message = "mfa is not configured"
+			case errors.Is(err, errs.ErrMFAAlreadyEnabled):
+				statusCode = http.StatusConflict
+				message = "mfa already enabled"
+			case errors.Is(err, errs.ErrMFANotEnrolled):
+				statusCode = http.StatusBadRequest
+				message = "mfa enrollment not started"
+			case errors.Is(err, errs.ErrMFATokenInvalid):
+				statusCode = http.StatusUnauthorized
+				message = "invalid or expired mfa token"
+			case errors.Is(err, errs.ErrInvalidMFACode):
+				statusCode = http.StatusUnauthorized
+				message = "invalid mfa code"
+			case errors.Is(err, errs.ErrMFACodeAlreadyUsed):
+				statusCode = http.StatusUnauthorized
+				message = "mfa code already used"
+			case errors.Is(err, errs.ErrMFARequired):
+				statusCode = http.StatusForbidden
+				message = "mfa must be enabled to sign in"
+			case errors.Is(err, errs.ErrWebAuthnNotConfigured):
+				statusCode = http.StatusNotImplemented
+				message = "webauthn is not configured"
+			case errors.Is(err, errs.ErrWebAuthnChallengeInvalid):
+				statusCode = http.StatusBadRequest
+				message = "invalid or expired webauthn challenge"
+			case errors.Is(err, errs.ErrWebAuthnInvalidResponse):
+				statusCode = http.StatusBadRequest
+				message = "invalid webauthn response"
+			case errors.Is(err, errs.ErrWebAuthnVerification), errors.Is(err, errs.ErrWebAuthnCredentialNotFound):
+				statusCode = http.StatusUnauthorized
+				message = "webauthn verification failed"
+			case errors.Is(err, errs.ErrWebAuthnCredentialExists):
+				statusCode = http.StatusConflict
+				message = "webauthn credential already registered"
+			case errors.As(err, &fieldsErr): // Ошибка валидации бизнес-правил (например, политика паролей)
+				statusCode = http.StatusBadRequest
+				message = "Validation error"
+				fieldErrors = fieldsErr.Fields
 			case errors.As(err, &validationErrs): // Проверяем, является ли err ошибкой валидации
 				statusCode = http.StatusBadRequest
 				message = "Validation error"
@@ -92,6 +191,13 @@ func ErrorHandler() gin.HandlerFunc {
 				message = "Internal server error"
 			}
 
+			// Сообщаем клиенту, когда можно повторить запрос
+			var retryErr *errs.RetryAfterError
+			if errors.As(err, &retryErr) {
+				seconds := int(math.Ceil(retryErr.RetryAfter.Seconds()))
+				c.Header("Retry-After", strconv.Itoa(max(seconds, 1)))
+			}
+
 			// Логируем критические ошибки
 			if statusCode == http.StatusInternalServerError {
 				logger.Errorf("Unhandled server error: %v", err)
@@ -1,6 +1,9 @@
 package errs
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // Юзер
 var (
@@ -8,6 +11,13 @@ var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrEmailAlreadyUsed  = errors.New("email already used")
 	ErrInvalidPwd        = errors.New("invalid password")
+	ErrAccountLocked     = errors.New("account is temporarily locked")
+	ErrTooManyAttempts   = errors.New("too many login attempts")
+	ErrWeakPassword      = errors.New("password does not meet policy")
+	ErrEmailNotVerified  = errors.New("email is not verified")
+	ErrEmailTokenInvalid = errors.New("invalid or expired email verification token")
+	ErrResetTokenInvalid = errors.New("invalid or expired password reset token")
+	ErrAccountDeleted    = errors.New("account is scheduled for deletion")
 )
 
 // Токен
@@ -23,4 +33,86 @@ var (
 	ErrFailedToRefresh      = errors.New("failed to refresh token")
 	ErrFailedToSave         = errors.New("failed to save token")
 	ErrParseUUID            = errors.New("failed to parse uuid")
+	ErrTokenReused          = errors.New("refresh token reuse detected")
+	ErrTokenRevoked         = errors.New("token has been revoked")
+	ErrAccessTokenInvalid   = errors.New("invalid token type (need ACCESS)")
+)
+
+// Сессии
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// Выгрузка данных
+var (
+	ErrExportNotFound     = errors.New("data export not found")
+	ErrExportTokenInvalid = errors.New("invalid or expired data export link")
+	ErrExportQueueFull    = errors.New("data export queue is full")
+)
+
+// Ключи подписи
+var (
+	ErrKeyRotationDisabled = errors.New("key rotation is disabled (keys_dir is not configured)")
+	ErrKeyNotFound         = errors.New("signing key not found")
+)
+
+// Доступ
+var (
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrInvalidClient = errors.New("invalid client credentials")
 )
+
+// MFA
+var (
+	ErrMFANotConfigured   = errors.New("mfa is not configured")
+	ErrMFAAlreadyEnabled  = errors.New("mfa already enabled")
+	ErrMFANotEnrolled     = errors.New("mfa enrollment not started")
+	ErrMFATokenInvalid    = errors.New("invalid mfa token")
+	ErrInvalidMFACode     = errors.New("invalid mfa code")
+	ErrMFACodeAlreadyUsed = errors.New("mfa code already used")
+	ErrMFARequired        = errors.New("mfa required")
+)
+
+// WebAuthn
+var (
+	ErrWebAuthnNotConfigured      = errors.New("webauthn is not configured")
+	ErrWebAuthnChallengeInvalid   = errors.New("invalid or expired webauthn challenge")
+	ErrWebAuthnInvalidResponse    = errors.New("invalid webauthn response")
+	ErrWebAuthnVerification       = errors.New("webauthn verification failed")
+	ErrWebAuthnCredentialExists   = errors.New("webauthn credential already registered")
+	ErrWebAuthnCredentialNotFound = errors.New("webauthn credential not found")
+)
+
+// RetryAfterError ошибка, после которой запрос можно повторить не раньше RetryAfter (заголовок Retry-After)
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// WithRetryAfter дополняет ошибку временем, через которое запрос можно повторить
+func WithRetryAfter(err error, retryAfter time.Duration) error {
+	return &RetryAfterError{Err: err, RetryAfter: retryAfter}
+}
+
+// ValidationError ошибка валидации с сообщениями по полям (ValidationErrorResponse.Fields)
+type ValidationError struct {
+	Err    error
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
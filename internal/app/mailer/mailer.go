@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"context"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Message исходящее письмо. HTML необязателен: без него отправляется только текстовая часть
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer отправляет письма пользователям
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer пишет в лог адресата и тему письма вместо отправки (только для разработки).
+// Текст письма не логируется: в нем ссылки с одноразовыми токенами
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(_ context.Context, msg Message) error {
+	logger.WithFields(logger.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info("Mail not sent: log backend")
+	return nil
+}
@@ -0,0 +1,88 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+var ErrQueueFull = errors.New("mail queue is full")
+
+// Queue асинхронная очередь писем поверх Mailer: Send только ставит письмо в очередь,
+// доставку с повторами выполняют фоновые обработчики (Run)
+type Queue struct {
+	mailer     Mailer
+	messages   chan Message
+	retries    int           // повторов после первой неудачной попытки
+	retryDelay time.Duration // задержка перед первым повтором, удваивается с каждым следующим
+}
+
+func NewQueue(mailer Mailer, size, retries int, retryDelay time.Duration) *Queue {
+	return &Queue{
+		mailer:     mailer,
+		messages:   make(chan Message, size),
+		retries:    retries,
+		retryDelay: retryDelay,
+	}
+}
+
+// Send ставит письмо в очередь не блокируя запрос. Контекст запроса не передается доставке
+func (q *Queue) Send(_ context.Context, msg Message) error {
+	select {
+	case q.messages <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run запускает workers обработчиков очереди и ждет их завершения после отмены ctx.
+// Письма, не доставленные к отмене, теряются
+func (q *Queue) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg := <-q.messages:
+					q.deliver(ctx, msg)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if pending := len(q.messages); pending > 0 {
+		logger.Warnf("mail queue stopped with %d undelivered messages", pending)
+	}
+}
+
+// deliver отправляет письмо, повторяя попытки с экспоненциальной задержкой
+func (q *Queue) deliver(ctx context.Context, msg Message) {
+	delay := q.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := q.mailer.Send(ctx, msg)
+		if err == nil {
+			return
+		}
+		if attempt >= q.retries {
+			logger.WithField("subject", msg.Subject).Errorf("mail delivery failed after %d attempts: %v", attempt+1, err)
+			return
+		}
+		logger.WithField("subject", msg.Subject).Warnf("mail delivery attempt %d failed: %v", attempt+1, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
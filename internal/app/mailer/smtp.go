@@ -0,0 +1,130 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// Режимы шифрования соединения с SMTP сервером
+const (
+	SMTPSecurityStartTLS = "starttls" // STARTTLS обязателен
+	SMTPSecurityTLS      = "tls"      // неявный TLS (обычно порт 465)
+	SMTPSecurityNone     = "none"     // без шифрования (локальный relay, тесты)
+)
+
+// SMTPParams параметры подключения к SMTP серверу
+type SMTPParams struct {
+	Host     string
+	Port     int
+	Username string // пусто - без аутентификации
+	Password string
+	From     string
+	Security string
+	Timeout  time.Duration // таймаут отправки одного письма
+}
+
+// SMTPMailer отправляет письма через SMTP сервер
+type SMTPMailer struct {
+	params SMTPParams
+}
+
+func NewSMTPMailer(params SMTPParams) (*SMTPMailer, error) {
+	if params.Host == "" || params.Port <= 0 {
+		return nil, fmt.Errorf("smtp host and port are required")
+	}
+	if _, err := mail.ParseAddress(params.From); err != nil {
+		return nil, fmt.Errorf("invalid smtp from address: %w", err)
+	}
+	switch params.Security {
+	case SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("unsupported smtp security mode %q", params.Security)
+	}
+	return &SMTPMailer{params: params}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	data, err := compose(m.params.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	from, _ := mail.ParseAddress(m.params.From)
+	to, _ := mail.ParseAddress(msg.To)
+
+	if m.params.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.params.Timeout)
+		defer cancel()
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.params.Username != "" {
+		auth := smtp.PlainAuth("", m.params.Username, m.params.Password, m.params.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial устанавливает соединение и при необходимости включает TLS. Срок ctx становится дедлайном соединения
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.params.Host, strconv.Itoa(m.params.Port))
+	tlsConfig := &tls.Config{ServerName: m.params.Host, MinVersion: tls.VersionTLS12}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if m.params.Security == SMTPSecurityTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, m.params.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp handshake: %w", err)
+	}
+	if m.params.Security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	return client, nil
+}
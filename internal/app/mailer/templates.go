@@ -0,0 +1,116 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Шаблоны писем
+const (
+	TemplateVerifyEmail       = "verify_email"
+	TemplatePasswordReset     = "password_reset"
+	TemplatePasswordChanged   = "password_changed"
+	TemplateEmailChange       = "email_change"
+	TemplateEmailChangeNotice = "email_change_notice"
+	TemplateAccountDeletion   = "account_deletion"
+	TemplateDataExport        = "data_export"
+)
+
+// Шаблон письма лежит в templates/<locale>/<name>.txt (блоки subject и text)
+// и необязательном templates/<locale>/<name>.html
+//
+//go:embed templates
+var templatesFS embed.FS
+
+type localizedTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Templates локализованные шаблоны писем
+type Templates struct {
+	templates     map[string]localizedTemplate // ключ - locale/name
+	defaultLocale string
+}
+
+// NewTemplates загружает встроенные шаблоны. defaultLocale используется,
+// если шаблона на запрошенном языке нет
+func NewTemplates(defaultLocale string) (*Templates, error) {
+	t := &Templates{templates: map[string]localizedTemplate{}, defaultLocale: defaultLocale}
+
+	files, err := fs.Glob(templatesFS, "templates/*/*.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		key := strings.TrimSuffix(strings.TrimPrefix(file, "templates/"), ".txt")
+
+		text, err := texttemplate.ParseFS(templatesFS, file)
+		if err != nil {
+			return nil, fmt.Errorf("parse mail template %s: %w", file, err)
+		}
+		if text.Lookup("subject") == nil || text.Lookup("text") == nil {
+			return nil, fmt.Errorf("mail template %s must define subject and text", file)
+		}
+		tmpl := localizedTemplate{text: text}
+
+		htmlFile := path.Join("templates", key+".html")
+		if _, err := fs.Stat(templatesFS, htmlFile); err == nil {
+			tmpl.html, err = htmltemplate.ParseFS(templatesFS, htmlFile)
+			if err != nil {
+				return nil, fmt.Errorf("parse mail template %s: %w", htmlFile, err)
+			}
+		}
+		t.templates[key] = tmpl
+	}
+
+	if !t.hasLocale(defaultLocale) {
+		return nil, fmt.Errorf("no mail templates for default locale %q", defaultLocale)
+	}
+	return t, nil
+}
+
+func (t *Templates) hasLocale(locale string) bool {
+	for key := range t.templates {
+		if strings.HasPrefix(key, locale+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Render заполняет тему и тело письма name на языке locale (или языке по умолчанию)
+func (t *Templates) Render(name, locale string, data any) (Message, error) {
+	tmpl, ok := t.templates[locale+"/"+name]
+	if !ok {
+		tmpl, ok = t.templates[t.defaultLocale+"/"+name]
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("mail template %q not found", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("render mail template %s: %w", name, err)
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, fmt.Errorf("render mail template %s: %w", name, err)
+	}
+	if tmpl.html != nil {
+		if err := tmpl.html.Execute(&html, data); err != nil {
+			return Message{}, fmt.Errorf("render mail template %s: %w", name, err)
+		}
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent событие безопасности из журнала пользователя
+type AuditEvent struct {
+	Event     string    `json:"event"`
+	IP        string    `json:"ip,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Статусы выгрузки данных
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// DataExport состояние выгрузки данных пользователя. Ссылка на скачивание есть только у готовой выгрузки
+type DataExport struct {
+	ID          string     `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	ReadyAt     *time.Time `json:"ready_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"` // после этого выгрузка удаляется
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+// DataExportArchive все данные, которые сервис хранит о пользователе (без секретов)
+type DataExportArchive struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	User        ExportedUser      `json:"user"`
+	Sessions    []Session         `json:"sessions"`
+	MFA         ExportedMFA       `json:"mfa"`
+	AuditEvents []AuditEvent      `json:"audit_events"`
+	Consents    []ExportedConsent `json:"consents"` // сервис согласий не собирает, раздел всегда пуст
+}
+
+// ExportedConsent согласие пользователя на обработку данных. Схема раздела зафиксирована,
+// чтобы потребители выгрузки не зависели от того, начнет ли сервис собирать согласия
+type ExportedConsent struct {
+	Purpose     string     `json:"purpose"` // цель обработки
+	Version     string     `json:"version"` // версия текста, с которым пользователь согласился
+	GrantedAt   time.Time  `json:"granted_at"`
+	WithdrawnAt *time.Time `json:"withdrawn_at,omitempty"`
+}
+
+// ExportedUser строка users без хэша пароля и служебных полей
+type ExportedUser struct {
+	ID                  uuid.UUID  `json:"id"`
+	Username            string     `json:"username"`
+	Email               string     `json:"email"`
+	EmailVerified       bool       `json:"email_verified"`
+	PendingEmail        *string    `json:"pending_email,omitempty"`
+	Role                string     `json:"role"`
+	PasswordChangedAt   *time.Time `json:"password_changed_at,omitempty"`
+	FailedLoginAttempts int        `json:"failed_login_attempts"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+	DeletedAt           *time.Time `json:"deleted_at,omitempty"`
+	CreateAt            time.Time  `json:"created_at"`
+	UpdateAt            time.Time  `json:"updated_at"`
+}
+
+// ExportedMFA сведения о втором факторе без секретов и кодов
+type ExportedMFA struct {
+	TOTPEnabled            bool                 `json:"totp_enabled"`
+	TOTPEnabledAt          *time.Time           `json:"totp_enabled_at,omitempty"`
+	RecoveryCodesRemaining int                  `json:"recovery_codes_remaining"`
+	WebAuthnCredentials    []WebAuthnCredential `json:"webauthn_credentials"`
+}
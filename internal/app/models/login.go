@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// LoginFailures неудачные попытки входа в скользящем окне
+type LoginFailures struct {
+	Count  int64
+	Oldest time.Time // самая ранняя попытка в окне
+	Last   time.Time // последняя попытка
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// TOTP данные TOTP пользователя
+type TOTP struct {
+	Secret    []byte // зашифрованный секрет
+	EnabledAt *time.Time
+	LastStep  int64
+}
+
+// Enabled сообщает, что TOTP подтвержден и обязателен при входе
+func (t TOTP) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// TOTPEnrollment секрет для добавления в приложение-аутентификатор
+type TOTPEnrollment struct {
+	Secret string `json:"secret"` // base32, для ручного ввода
+	URI    string `json:"uri"`    // otpauth:// для QR-кода
+}
+
+// RecoveryCodes новый набор одноразовых кодов восстановления, показывается один раз
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// RecoveryCodesStatus число оставшихся кодов восстановления
+type RecoveryCodesStatus struct {
+	Remaining int `json:"remaining"`
+}
+
+type MFACodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFAEnrollInput обязательная настройка MFA по mfa_token из входа
+type MFAEnrollInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFAVerifyInput второй шаг входа. Code - код TOTP или код восстановления
+type MFAVerifyInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
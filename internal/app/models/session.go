@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ClientInfo данные клиента, с которого выполняется вход
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// TokenCutoff токены пользователя, выпущенные раньше At, отклоняются (кроме токенов сессии SessionID)
+type TokenCutoff struct {
+	At        time.Time
+	SessionID string
+}
+
+// Session активная сессия пользователя (соответствует семейству refresh токенов)
+type Session struct {
+	ID              string    `json:"id"`
+	UserAgent       string    `json:"user_agent"`
+	IP              string    `json:"ip"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastRefreshedAt time.Time `json:"last_refreshed_at"`
+	Current         bool      `json:"current"`
+}
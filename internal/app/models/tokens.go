@@ -1,10 +1,49 @@
 package models
 
+// Tokens токены входа. При включенной MFA вместо них возвращается mfa_token для POST /auth/mfa/verify,
+// а если роли нужна MFA, которая еще не настроена, - mfa_token для POST /auth/mfa/enroll
 type Tokens struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken           string `json:"access_token,omitempty"`
+	RefreshToken          string `json:"refresh_token,omitempty"`
+	MFARequired           bool   `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string `json:"mfa_token,omitempty"`
 }
 
 type InputRefresh struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
+
+// RevokeInput необязательное тело отзыва: access токен, если он не передан в Authorization или куки
+type RevokeInput struct {
+	AccessToken string `json:"access_token"`
+}
+
+type RotateKeysInput struct {
+	Kid string `json:"kid"` // kid уже загруженного в связку ключа, пусто - сгенерировать новый
+}
+
+type KeyIdResponse struct {
+	Kid string `json:"kid"`
+}
+
+// IntrospectionInput запрос интроспекции (RFC 7662, application/x-www-form-urlencoded)
+type IntrospectionInput struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+// IntrospectionResponse ответ интроспекции (RFC 7662). Для неактивного токена заполняется только active.
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Sub       string   `json:"sub,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Aud       []string `json:"aud,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+	Nbf       int64    `json:"nbf,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+}
@@ -14,20 +14,59 @@ func init() {
 }
 
 type UserInput struct {
-	ID       uuid.UUID `json:"-"`
-	Username string    `json:"username" validate:"required,min=5,max=20"`
-	Password string    `json:"password" validate:"required,min=8,max=16"`
-	Email    string    `json:"email" validate:"required,email"`
+	ID            uuid.UUID `json:"-"`
+	Username      string    `json:"username" validate:"required,min=5,max=20"`
+	Password      string    `json:"password" validate:"required"` // длина и состав проверяются политикой паролей
+	Email         string    `json:"email" validate:"required,email"`
+	PepperVersion int       `json:"-"`
 }
 
 type GetUserResponse struct {
-	ID       uuid.UUID `json:"id"`
-	Username string    `json:"username"`
-	Password string    `json:"password"`
-	Email    string    `json:"email"`
-	Role     string    `json:"role"`
-	CreateAt time.Time `json:"created_at"`
-	UpdateAt time.Time `json:"updated_at"`
+	ID                  uuid.UUID  `json:"id"`
+	Username            string     `json:"username"`
+	Password            string     `json:"password"`
+	Email               string     `json:"email"`
+	Role                string     `json:"role"`
+	CreateAt            time.Time  `json:"created_at"`
+	UpdateAt            time.Time  `json:"updated_at"`
+	PepperVersion       int        `json:"-"`
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"-"`
+	MFAEnabled          bool       `json:"-"`
+	EmailVerified       bool       `json:"-"`
+	PasswordChangedAt   *time.Time `json:"-"`
+	PendingEmail        *string    `json:"-"`
+	DeletedAt           *time.Time `json:"-"`
+}
+
+// UserProfile профиль пользователя без секретов
+type UserProfile struct {
+	ID                uuid.UUID  `json:"id"`
+	Username          string     `json:"username"`
+	Email             string     `json:"email"`
+	EmailVerified     bool       `json:"email_verified"`
+	PendingEmail      *string    `json:"pending_email,omitempty"` // новый email, ожидающий подтверждения
+	Role              string     `json:"role"`
+	MFAEnabled        bool       `json:"mfa_enabled"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	CreateAt          time.Time  `json:"created_at"`
+	UpdateAt          time.Time  `json:"updated_at"`
+}
+
+// Profile возвращает профиль пользователя без хэша пароля
+func (u GetUserResponse) Profile() UserProfile {
+	return UserProfile{
+		ID:                u.ID,
+		Username:          u.Username,
+		Email:             u.Email,
+		EmailVerified:     u.EmailVerified,
+		PendingEmail:      u.PendingEmail,
+		Role:              u.Role,
+		MFAEnabled:        u.MFAEnabled,
+		PasswordChangedAt: u.PasswordChangedAt,
+		CreateAt:          u.CreateAt,
+		UpdateAt:          u.UpdateAt,
+	}
 }
 
 func (u *UserInput) Validate() error {
@@ -42,3 +81,49 @@ type SignInInput struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
+
+// VerifyEmailInput токен из письма подтверждения
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordInput адрес, на который отправляется ссылка сброса пароля
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordInput токен из письма и новый пароль
+type ResetPasswordInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// UpdateProfileInput изменяемые поля профиля; отсутствующие поля не меняются.
+// Новый email вступает в силу после подтверждения по ссылке из письма, смена email требует текущий пароль
+type UpdateProfileInput struct {
+	Username        *string `json:"username" binding:"omitempty,min=5,max=20"`
+	Email           *string `json:"email" binding:"omitempty,email"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+// ChangePasswordInput смена пароля аутентифицированным пользователем
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ResendVerificationInput адрес, на который повторно отправляется письмо подтверждения
+type ResendVerificationInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// DeleteAccountInput текущий пароль для подтверждения удаления учетной записи
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// AccountDeletion время удаления учетной записи и окончательной очистки ее данных
+type AccountDeletion struct {
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"` // до этого времени удаление можно отменить
+}
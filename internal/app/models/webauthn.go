@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential зарегистрированный ключ доступа (passkey)
+type WebAuthnCredential struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"-"`
+	CredentialID []byte     `json:"-"`
+	PublicKey    []byte     `json:"-"` // ключ COSE
+	SignCount    uint32     `json:"-"`
+	Name         string     `json:"name"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
+
+// WebAuthnChallenge челлендж церемонии, хранится в redis до ответа браузера
+type WebAuthnChallenge struct {
+	Ceremony string    // webauthn.create или webauthn.get
+	UserID   uuid.UUID // uuid.Nil - вход без логина (discoverable credential)
+}
+
+type WebAuthnRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WebAuthnUser struct {
+	ID          string `json:"id"` // base64url
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type WebAuthnCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"` // base64url
+}
+
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnCreationOptions параметры navigator.credentials.create (PublicKeyCredentialCreationOptions)
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"` // base64url
+	RP                     WebAuthnRelyingParty           `json:"rp"`
+	User                   WebAuthnUser                   `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"` // мс
+	Attestation            string                         `json:"attestation"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+}
+
+// WebAuthnRequestOptions параметры navigator.credentials.get (PublicKeyCredentialRequestOptions)
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"` // base64url
+	RPID             string                         `json:"rpId"`
+	Timeout          int64                          `json:"timeout"` // мс
+	UserVerification string                         `json:"userVerification"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+}
+
+// WebAuthnAttestationResponse ответ аутентификатора при регистрации (поля в base64url)
+type WebAuthnAttestationResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+	AttestationObject string `json:"attestationObject" binding:"required"`
+}
+
+// WebAuthnRegistrationInput PublicKeyCredential.toJSON() после navigator.credentials.create
+type WebAuthnRegistrationInput struct {
+	ID       string                      `json:"id" binding:"required"`
+	RawID    string                      `json:"rawId" binding:"required"`
+	Type     string                      `json:"type" binding:"required"`
+	Response WebAuthnAttestationResponse `json:"response" binding:"required"`
+	Name     string                      `json:"name" binding:"max=64"` // имя ключа для пользователя
+}
+
+// WebAuthnAssertionResponse ответ аутентификатора при входе (поля в base64url)
+type WebAuthnAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+	AuthenticatorData string `json:"authenticatorData" binding:"required"`
+	Signature         string `json:"signature" binding:"required"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// WebAuthnLoginInput PublicKeyCredential.toJSON() после navigator.credentials.get
+type WebAuthnLoginInput struct {
+	ID       string                    `json:"id" binding:"required"`
+	RawID    string                    `json:"rawId" binding:"required"`
+	Type     string                    `json:"type" binding:"required"`
+	Response WebAuthnAssertionResponse `json:"response" binding:"required"`
+}
+
+// WebAuthnLoginBeginInput начало входа. Без username - вход discoverable ключом
+type WebAuthnLoginBeginInput struct {
+	Username string `json:"username"`
+}
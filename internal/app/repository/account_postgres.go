@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+)
+
+// MarkUserDeleted помечает учетную запись удаленной и возвращает время удаления.
+// Уже помеченная запись считается отсутствующей
+func (r *PostgresRepo) MarkUserDeleted(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	query := `UPDATE users SET deleted_at = now()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING deleted_at`
+
+	var deletedAt time.Time
+	err := r.db.QueryRow(ctx, query, id).Scan(&deletedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, errs.ErrUserNotFound
+		}
+		logger.Errorf("query MarkUserDeleted error: %v", err)
+		return time.Time{}, err
+	}
+	return deletedAt, nil
+}
+
+// RestoreUser снимает пометку удаления, если учетная запись удалена позже deletedAfter.
+// Возвращает false, если запись не удалена или срок отмены истек
+func (r *PostgresRepo) RestoreUser(ctx context.Context, id uuid.UUID, deletedAfter time.Time) (bool, error) {
+	query := `UPDATE users SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at > $2`
+
+	tag, err := r.db.Exec(ctx, query, id, deletedAfter)
+	if err != nil {
+		logger.Errorf("query RestoreUser error: %v", err)
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// PurgeDeletedUsers окончательно удаляет учетные записи, помеченные удаленными не позже deletedBefore.
+// Коды восстановления и ключи доступа удаляются каскадно
+func (r *PostgresRepo) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	query := `DELETE FROM users WHERE deleted_at <= $1 RETURNING id`
+
+	rows, err := r.db.Query(ctx, query, deletedBefore)
+	if err != nil {
+		logger.Errorf("query PurgeDeletedUsers error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			logger.Errorf("scan PurgeDeletedUsers error: %v", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
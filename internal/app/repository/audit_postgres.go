@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/models"
+)
+
+// SaveAuditEvent добавляет событие в журнал пользователя
+func (r *PostgresRepo) SaveAuditEvent(ctx context.Context, userID uuid.UUID, event models.AuditEvent) error {
+	query := "INSERT INTO audit_events (user_id, event, ip) VALUES ($1, $2, $3)"
+
+	if _, err := r.db.Exec(ctx, query, userID, event.Event, event.IP); err != nil {
+		logger.Errorf("query SaveAuditEvent error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetAuditEvents возвращает журнал пользователя в хронологическом порядке
+func (r *PostgresRepo) GetAuditEvents(ctx context.Context, userID uuid.UUID) ([]models.AuditEvent, error) {
+	query := "SELECT event, ip, created_at FROM audit_events WHERE user_id = $1 ORDER BY created_at"
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		logger.Errorf("query GetAuditEvents error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var event models.AuditEvent
+		if err := rows.Scan(&event.Event, &event.IP, &event.CreatedAt); err != nil {
+			logger.Errorf("scan GetAuditEvents error: %v", err)
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	logger "github.com/sirupsen/logrus"
@@ -27,35 +29,50 @@ func NewPostgresRepo(db *pgxpool.Pool) *PostgresRepo {
 }
 
 func (r *PostgresRepo) CreateUser(ctx context.Context, user models.UserInput) (uuid.UUID, error) {
-	query := "INSERT INTO users(username, password_hash, email) VALUES ($1, $2, $3) RETURNING id"
+	query := "INSERT INTO users(username, password_hash, email, pepper_version) VALUES ($1, $2, $3, $4) RETURNING id"
 	var id uuid.UUID
-	err := r.db.QueryRow(ctx, query, user.Username, user.Password, user.Email).Scan(&id)
+	err := r.db.QueryRow(ctx, query, user.Username, user.Password, user.Email, user.PepperVersion).Scan(&id)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			// Обработка ошибок PostgreSQL
-			if pgErr.Code == DuplicateValue {
-				if pgErr.ConstraintName == "users_username_key" {
-					return uuid.UUID{}, errs.ErrUserAlreadyExists
-				}
-				if pgErr.ConstraintName == "users_email_key" {
-					return uuid.UUID{}, errs.ErrEmailAlreadyUsed
-				}
+		return uuid.UUID{}, userWriteError(err)
+	}
+	return id, nil
+}
+
+// userWriteError переводит ошибку записи пользователя: нарушение уникальности логина или email
+// становится ErrUserAlreadyExists или ErrEmailAlreadyUsed
+func userWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Обработка ошибок PostgreSQL
+		if pgErr.Code == DuplicateValue {
+			if pgErr.ConstraintName == "users_username_key" {
+				return errs.ErrUserAlreadyExists
+			}
+			if pgErr.ConstraintName == "users_email_key" || pgErr.ConstraintName == "users_pending_email_key" {
+				return errs.ErrEmailAlreadyUsed
 			}
-			return uuid.UUID{}, fmt.Errorf("database error: %v", pgErr.Message)
 		}
-		return uuid.UUID{}, fmt.Errorf("query error: %v", err)
+		return fmt.Errorf("database error: %v", pgErr.Message)
 	}
-	return id, nil
+	return fmt.Errorf("query error: %v", err)
+}
+
+const userColumns = "id, username, password_hash, email, role, created_at, updated_at, pepper_version, " +
+	"failed_login_attempts, locked_until, totp_enabled_at IS NOT NULL, email_verified_at IS NOT NULL, password_changed_at, " +
+	"pending_email, deleted_at"
+
+func scanUser(row pgx.Row, user *models.GetUserResponse) error {
+	return row.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.Role, &user.CreateAt, &user.UpdateAt,
+		&user.PepperVersion, &user.FailedLoginAttempts, &user.LockedUntil, &user.MFAEnabled, &user.EmailVerified,
+		&user.PasswordChangedAt, &user.PendingEmail, &user.DeletedAt)
 }
 
 func (r *PostgresRepo) GetUser(ctx context.Context, username string) (models.GetUserResponse, error) {
 	var user models.GetUserResponse
-	query := "SELECT * FROM users WHERE username = $1"
-	row := r.db.QueryRow(ctx, query, username)
-	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.Role, &user.CreateAt, &user.UpdateAt)
+	query := "SELECT " + userColumns + " FROM users WHERE username = $1"
+	err := scanUser(r.db.QueryRow(ctx, query, username), &user)
 	if err != nil {
-		if err.Error() == "no rows in result set" {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return user, errs.ErrUserNotFound
 		}
 		logger.Errorf("query GetUser error: %v", err)
@@ -63,3 +80,167 @@ func (r *PostgresRepo) GetUser(ctx context.Context, username string) (models.Get
 	}
 	return user, nil
 }
+
+func (r *PostgresRepo) GetUserByID(ctx context.Context, id uuid.UUID) (models.GetUserResponse, error) {
+	var user models.GetUserResponse
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1"
+	err := scanUser(r.db.QueryRow(ctx, query, id), &user)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user, errs.ErrUserNotFound
+		}
+		logger.Errorf("query GetUserByID error: %v", err)
+		return user, err
+	}
+	return user, nil
+}
+
+func (r *PostgresRepo) GetUserByEmail(ctx context.Context, email string) (models.GetUserResponse, error) {
+	var user models.GetUserResponse
+	query := "SELECT " + userColumns + " FROM users WHERE email = $1"
+	err := scanUser(r.db.QueryRow(ctx, query, email), &user)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user, errs.ErrUserNotFound
+		}
+		logger.Errorf("query GetUserByEmail error: %v", err)
+		return user, err
+	}
+	return user, nil
+}
+
+// RegisterFailedLogin увеличивает счетчик неудачных входов. При достижении threshold
+// блокирует аккаунт на lockout и сбрасывает счетчик. Возвращает время окончания блокировки, если она есть.
+func (r *PostgresRepo) RegisterFailedLogin(ctx context.Context, id uuid.UUID, threshold int, lockout time.Duration) (*time.Time, error) {
+	query := `UPDATE users SET
+		failed_login_attempts = CASE WHEN failed_login_attempts + 1 >= $2 THEN 0 ELSE failed_login_attempts + 1 END,
+		locked_until = CASE WHEN failed_login_attempts + 1 >= $2 THEN now() + make_interval(secs => $3) ELSE locked_until END
+		WHERE id = $1
+		RETURNING locked_until`
+
+	var lockedUntil *time.Time
+	err := r.db.QueryRow(ctx, query, id, threshold, lockout.Seconds()).Scan(&lockedUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.ErrUserNotFound
+		}
+		logger.Errorf("query RegisterFailedLogin error: %v", err)
+		return nil, err
+	}
+	return lockedUntil, nil
+}
+
+// ResetFailedLogins сбрасывает счетчик неудачных входов и блокировку после успешного входа
+func (r *PostgresRepo) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE users SET failed_login_attempts = 0, locked_until = NULL
+		WHERE id = $1 AND (failed_login_attempts > 0 OR locked_until IS NOT NULL)`
+
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		logger.Errorf("query ResetFailedLogins error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// UpdatePasswordHash заменяет хэш пароля и версию перца, если хэш не изменился с момента чтения (oldHash)
+func (r *PostgresRepo) UpdatePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string, pepperVersion int) error {
+	query := `UPDATE users SET password_hash = $3, pepper_version = $4 WHERE id = $1 AND password_hash = $2`
+
+	if _, err := r.db.Exec(ctx, query, id, oldHash, newHash, pepperVersion); err != nil {
+		logger.Errorf("query UpdatePasswordHash error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SetPasswordHash устанавливает новый пароль при сбросе. Возвращает время смены пароля
+func (r *PostgresRepo) SetPasswordHash(ctx context.Context, id uuid.UUID, hash string, pepperVersion int) (time.Time, error) {
+	query := `UPDATE users SET password_hash = $2, pepper_version = $3, password_changed_at = now()
+		WHERE id = $1
+		RETURNING password_changed_at`
+
+	var changedAt time.Time
+	err := r.db.QueryRow(ctx, query, id, hash, pepperVersion).Scan(&changedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, errs.ErrUserNotFound
+		}
+		logger.Errorf("query SetPasswordHash error: %v", err)
+		return time.Time{}, err
+	}
+	return changedAt, nil
+}
+
+// ChangePassword заменяет пароль, если хэш не изменился с момента проверки текущего пароля (oldHash).
+// Возвращает время смены пароля; ErrInvalidPwd, если пароль успели сменить параллельно
+func (r *PostgresRepo) ChangePassword(ctx context.Context, id uuid.UUID, oldHash, newHash string, pepperVersion int) (time.Time, error) {
+	query := `UPDATE users SET password_hash = $3, pepper_version = $4, password_changed_at = now()
+		WHERE id = $1 AND password_hash = $2
+		RETURNING password_changed_at`
+
+	var changedAt time.Time
+	err := r.db.QueryRow(ctx, query, id, oldHash, newHash, pepperVersion).Scan(&changedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, errs.ErrInvalidPwd
+		}
+		logger.Errorf("query ChangePassword error: %v", err)
+		return time.Time{}, err
+	}
+	return changedAt, nil
+}
+
+// VerifyEmail отмечает email подтвержденным, если он совпадает с текущим email пользователя.
+// Возвращает false, если email сменился или уже был подтвержден
+func (r *PostgresRepo) VerifyEmail(ctx context.Context, id uuid.UUID, email string) (bool, error) {
+	query := `UPDATE users SET email_verified_at = now()
+		WHERE id = $1 AND email = $2 AND email_verified_at IS NULL`
+
+	tag, err := r.db.Exec(ctx, query, id, email)
+	if err != nil {
+		logger.Errorf("query VerifyEmail error: %v", err)
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UpdateUsername меняет логин пользователя
+func (r *PostgresRepo) UpdateUsername(ctx context.Context, id uuid.UUID, username string) error {
+	query := `UPDATE users SET username = $2 WHERE id = $1`
+
+	tag, err := r.db.Exec(ctx, query, id, username)
+	if err != nil {
+		return userWriteError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrUserNotFound
+	}
+	return nil
+}
+
+// SetPendingEmail сохраняет новый email до подтверждения. Адрес, уже ожидающий подтверждения
+// у другого пользователя, - ErrEmailAlreadyUsed (уникальный индекс users_pending_email_key)
+func (r *PostgresRepo) SetPendingEmail(ctx context.Context, id uuid.UUID, email string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE users SET pending_email = $2 WHERE id = $1`, id, email)
+	if err != nil {
+		logger.Errorf("query SetPendingEmail error: %v", err)
+		return userWriteError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrUserNotFound
+	}
+	return nil
+}
+
+// ConfirmEmailChange делает ожидающий подтверждения email основным (и подтвержденным).
+// Возвращает false, если ожидающий email отличается от email (запрошена другая смена или смена уже завершена)
+func (r *PostgresRepo) ConfirmEmailChange(ctx context.Context, id uuid.UUID, email string) (bool, error) {
+	query := `UPDATE users SET email = pending_email, pending_email = NULL, email_verified_at = now()
+		WHERE id = $1 AND pending_email = $2`
+
+	tag, err := r.db.Exec(ctx, query, id, email)
+	if err != nil {
+		return false, userWriteError(err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -2,81 +2,332 @@ package repository
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	logger "github.com/sirupsen/logrus"
 
 	"service-auth/internal/app/errs"
 )
 
-const ActiveToken = 1
+const (
+	ActiveToken         = 1
+	tokenKeyPrefix      = "refresh:"
+	familyKeyPrefix     = "family:"
+	denyKeyPrefix       = "deny:"
+	userTokensKeyPrefix = "user_tokens:"
 
+	// Поля записи refresh токена
+	tokenFieldUserID   = "user_id"
+	tokenFieldFamilyID = "family_id"
+)
+
+// RedisRepo хранит refresh токены только в виде HMAC-SHA256 (или SHA-256, если секрет не задан):
+// сами токены не попадают ни в ключи, ни в значения, ни в логи
 type RedisRepo struct {
 	redisConn *redis.Client
+	keyPrefix string
+	secret    []byte
+}
+
+func NewRedisRepo(redisConn *redis.Client, keyPrefix string, secret []byte) *RedisRepo {
+	return &RedisRepo{redisConn: redisConn, keyPrefix: keyPrefix, secret: secret}
+}
+
+// digest возвращает отпечаток refresh токена, под которым он хранится
+func (r *RedisRepo) digest(token string) string {
+	if len(r.secret) == 0 {
+		sum := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, r.secret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// key добавляет к ключу пространство имен сервиса
+func (r *RedisRepo) key(key string) string {
+	return r.keyPrefix + key
 }
 
-func NewRedisRepo(redisConn *redis.Client) *RedisRepo {
-	return &RedisRepo{redisConn: redisConn}
+// tokenKey ключ записи refresh токена по его отпечатку
+func (r *RedisRepo) tokenKey(digest string) string {
+	return r.key(tokenKeyPrefix + digest)
 }
 
-func (r *RedisRepo) SaveToken(ctx context.Context, token string, ttl time.Duration) error {
-	err := r.redisConn.Set(ctx, token, ActiveToken, ttl).Err()
+// familyKey ключ семейства refresh токенов, значение - отпечаток текущего (последнего выданного) токена семейства
+func (r *RedisRepo) familyKey(familyID string) string {
+	return r.key(familyKeyPrefix + familyID)
+}
+
+// userTokensKey ключ множества отпечатков refresh токенов пользователя
+func (r *RedisRepo) userTokensKey(userID string) string {
+	return r.key(userTokensKeyPrefix + userID)
+}
+
+// denyKey ключ записи denylist
+func (r *RedisRepo) denyKey(jti string) string {
+	return r.key(denyKeyPrefix + jti)
+}
+
+// saveToken добавляет в транзакцию запись refresh токена, его семейство и индекс пользователя
+func (r *RedisRepo) saveToken(ctx context.Context, pipe redis.Pipeliner, digest string, userID uuid.UUID, familyID string, ttl time.Duration) {
+	key := r.tokenKey(digest)
+	indexKey := r.userTokensKey(userID.String())
+
+	pipe.HSet(ctx, key, tokenFieldUserID, userID.String(), tokenFieldFamilyID, familyID)
+	pipe.Expire(ctx, key, ttl)
+	pipe.Set(ctx, r.familyKey(familyID), digest, ttl)
+	pipe.SAdd(ctx, indexKey, digest)
+	pipe.Expire(ctx, indexKey, ttl)
+}
+
+// SaveToken сохраняет refresh токен пользователя и делает его текущим токеном семейства familyID
+func (r *RedisRepo) SaveToken(ctx context.Context, token string, userID uuid.UUID, familyID string, ttl time.Duration) error {
+	pipe := r.redisConn.TxPipeline()
+	r.saveToken(ctx, pipe, r.digest(token), userID, familyID, ttl)
+
+	_, err := pipe.Exec(ctx)
 	if err != nil {
 		logger.Errorf("save token error: %v", err)
 		return errs.ErrFailedToSave
 	}
-	logger.Debugf("save token: %v", token)
+	logger.Debugf("save token of family %s", familyID)
 	return nil
 }
 
 func (r *RedisRepo) FindTokenInRedis(ctx context.Context, token string) error {
-	logger.Debugf("Checking token %s", token)
-	_, err := r.redisConn.Get(ctx, token).Int()
-	if errors.Is(err, redis.Nil) {
-		logger.Debugf("Token %s does not exist in Redis", token)
-		return errs.ErrTokenNotFound
-	} else if err != nil {
-		logger.Errorf("Failed to get token from Redis: %w", err)
+	exists, err := r.redisConn.Exists(ctx, r.tokenKey(r.digest(token))).Result()
+	if err != nil {
+		logger.Errorf("Failed to get token from Redis: %v", err)
 		return errs.ErrValidateInRedis
+	} else if exists == 0 {
+		logger.Debug("Token does not exist in Redis")
+		return errs.ErrTokenNotFound
 	}
 	return nil
 }
 
+// tokenOwner возвращает пользователя и семейство refresh токена по его отпечатку
+func (r *RedisRepo) tokenOwner(ctx context.Context, digest string) (userID, familyID string) {
+	values, err := r.redisConn.HMGet(ctx, r.tokenKey(digest), tokenFieldUserID, tokenFieldFamilyID).Result()
+	if err != nil {
+		return "", ""
+	}
+	userID, _ = values[0].(string)
+	familyID, _ = values[1].(string)
+	return userID, familyID
+}
+
 func (r *RedisRepo) DeleteToken(ctx context.Context, token string) error {
-	deleted, err := r.redisConn.Del(ctx, token).Result()
+	digest := r.digest(token)
+	userID, _ := r.tokenOwner(ctx, digest)
+
+	pipe := r.redisConn.TxPipeline()
+	deletedCmd := pipe.Del(ctx, r.tokenKey(digest))
+	if userID != "" {
+		pipe.SRem(ctx, r.userTokensKey(userID), digest)
+	}
+	_, err := pipe.Exec(ctx)
 	if err != nil {
-		logger.Errorf("Failed to delete token from Redis (token: %s): %v", token, err)
+		logger.Errorf("Failed to delete token from Redis: %v", err)
 		return fmt.Errorf("failed to delete token: %w", err)
 	}
-	logger.Debugf("Deleted token from Redis (token: %s)", token)
-	if deleted == 0 {
-		logger.Warnf("Token not found in Redis: %s", token)
+	if deletedCmd.Val() == 0 {
+		logger.Warn("Token not found in Redis")
 		return errs.ErrTokenNotFound
 	}
 
-	logger.Debugf("Deleted token from Redis: %s", token)
+	logger.Debugf("Deleted token of user %s from Redis", userID)
 	return nil
 }
 
-func (r *RedisRepo) UpdateRefreshTokenInRedis(ctx context.Context, oldToken, newToken string, ttl time.Duration) error {
-	// Начало транзакции
-	pipe := r.redisConn.TxPipeline()
+// UpdateRefreshTokenInRedis заменяет текущий токен семейства на новый.
+// Если oldToken уже не является текущим токеном семейства, возвращает errs.ErrTokenReused.
+func (r *RedisRepo) UpdateRefreshTokenInRedis(ctx context.Context, oldToken, newToken string, userID uuid.UUID, familyID string, ttl time.Duration) error {
+	key := r.familyKey(familyID)
+	oldDigest := r.digest(oldToken)
 
-	// Удаление старого токена
-	pipe.Del(ctx, oldToken)
+	err := r.redisConn.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			return errs.ErrTokenNotFound
+		} else if err != nil {
+			return err
+		}
 
-	// Сохранение нового токена
-	pipe.Set(ctx, newToken, ActiveToken, ttl)
+		// Ротировать можно только текущий токен семейства
+		if current != oldDigest {
+			return errs.ErrTokenReused
+		}
 
-	// Выполнение транзакции
-	_, err := pipe.Exec(ctx)
-	if err != nil {
+		// Начало транзакции
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			// Удаление старого токена
+			pipe.Del(ctx, r.tokenKey(oldDigest))
+			pipe.SRem(ctx, r.userTokensKey(userID.String()), oldDigest)
+			// Сохранение нового токена и перенос на него семейства
+			r.saveToken(ctx, pipe, r.digest(newToken), userID, familyID, ttl)
+			return nil
+		})
+		return err
+	}, key)
+
+	switch {
+	case errors.Is(err, errs.ErrTokenReused), errors.Is(err, redis.TxFailedErr):
+		// Параллельная ротация тем же токеном - тоже повторное использование
+		return errs.ErrTokenReused
+	case errors.Is(err, errs.ErrTokenNotFound):
+		return err
+	case err != nil:
 		logger.Errorf("Failed to update token in Redis: %s", err)
 		return errs.ErrFailedToRefresh
 	}
 	logger.Debugf("Successfully updated token in Redis")
 	return nil
 }
+
+// FamilyExists проверяет, что семейство refresh токенов еще действует
+func (r *RedisRepo) FamilyExists(ctx context.Context, familyID string) (bool, error) {
+	exists, err := r.redisConn.Exists(ctx, r.familyKey(familyID)).Result()
+	if err != nil {
+		logger.Errorf("Failed to check token family in Redis: %v", err)
+		return false, errs.ErrValidateInRedis
+	}
+	return exists > 0, nil
+}
+
+// RevokeFamily удаляет семейство refresh токенов вместе с его текущим токеном и сессией
+func (r *RedisRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	key := r.familyKey(familyID)
+
+	current, err := r.redisConn.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return errs.ErrTokenNotFound
+	} else if err != nil {
+		logger.Errorf("Failed to get token family from Redis: %v", err)
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+
+	userID, _ := r.tokenOwner(ctx, current)
+
+	pipe := r.redisConn.TxPipeline()
+	pipe.Del(ctx, r.tokenKey(current), key)
+	if userID != "" {
+		pipe.SRem(ctx, r.userTokensKey(userID), current)
+	}
+	// Сессия живет, пока живо ее семейство
+	r.deleteSession(ctx, pipe, userID, familyID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("Failed to revoke token family %s: %v", familyID, err)
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+
+	logger.Debugf("Revoked token family %s", familyID)
+	return nil
+}
+
+// RevokeAllForUser удаляет все refresh токены пользователя вместе с их семействами и сессиями
+func (r *RedisRepo) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.revokeUserTokens(ctx, userID, "")
+}
+
+// RevokeOtherSessions отзывает refresh токены и сессии пользователя, кроме семейства keepFamilyID
+func (r *RedisRepo) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, keepFamilyID string) error {
+	return r.revokeUserTokens(ctx, userID, keepFamilyID)
+}
+
+// revokeUserTokens удаляет refresh токены, семейства и сессии пользователя; keepFamilyID, если задан, сохраняется
+func (r *RedisRepo) revokeUserTokens(ctx context.Context, userID uuid.UUID, keepFamilyID string) error {
+	indexKey := r.userTokensKey(userID.String())
+	sessionsKey := r.userSessionsKey(userID.String())
+
+	digests, err := r.redisConn.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		logger.Errorf("Failed to get user tokens from Redis: %v", err)
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	keys := make([]string, 0, len(digests)*2+2)
+	revokedDigests := make([]any, 0, len(digests))
+	for _, digest := range digests {
+		_, familyID := r.tokenOwner(ctx, digest)
+		if keepFamilyID != "" && familyID == keepFamilyID {
+			continue
+		}
+		keys = append(keys, r.tokenKey(digest))
+		if familyID != "" {
+			keys = append(keys, r.familyKey(familyID))
+		}
+		revokedDigests = append(revokedDigests, digest)
+	}
+
+	sessions, err := r.redisConn.SMembers(ctx, sessionsKey).Result()
+	if err != nil {
+		logger.Errorf("Failed to get user sessions from Redis: %v", err)
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+	revokedSessions := make([]any, 0, len(sessions))
+	for _, sessionID := range sessions {
+		if sessionID == keepFamilyID {
+			continue
+		}
+		keys = append(keys, r.sessionKey(sessionID))
+		revokedSessions = append(revokedSessions, sessionID)
+	}
+
+	pipe := r.redisConn.TxPipeline()
+	if keepFamilyID == "" {
+		keys = append(keys, indexKey, sessionsKey)
+	} else {
+		if len(revokedDigests) > 0 {
+			pipe.SRem(ctx, indexKey, revokedDigests...)
+		}
+		if len(revokedSessions) > 0 {
+			pipe.SRem(ctx, sessionsKey, revokedSessions...)
+		}
+	}
+	if len(keys) > 0 {
+		pipe.Del(ctx, keys...)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("Failed to revoke user tokens: %v", err)
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	logger.Debugf("Revoked %d refresh tokens of user %s", len(revokedDigests), userID)
+	return nil
+}
+
+// DenyToken заносит jti токена в denylist до момента истечения токена
+func (r *RedisRepo) DenyToken(ctx context.Context, jti string, exp time.Time) error {
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		// Истекший токен и так не пройдет проверку
+		return nil
+	}
+
+	if err := r.redisConn.Set(ctx, r.denyKey(jti), ActiveToken, ttl).Err(); err != nil {
+		logger.Errorf("Failed to add token %s to denylist: %v", jti, err)
+		return errs.ErrFailedToSave
+	}
+	logger.Debugf("Token %s added to denylist", jti)
+	return nil
+}
+
+// IsTokenDenied проверяет, что jti токена находится в denylist
+func (r *RedisRepo) IsTokenDenied(ctx context.Context, jti string) (bool, error) {
+	exists, err := r.redisConn.Exists(ctx, r.denyKey(jti)).Result()
+	if err != nil {
+		logger.Errorf("Failed to check denylist in Redis: %v", err)
+		return false, errs.ErrValidateInRedis
+	}
+	return exists > 0, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+const (
+	tokenCutoffKeyPrefix = "token_cutoff:"
+
+	// Поля записи отсечки. at - unix время в миллисекундах
+	cutoffFieldAt        = "at"
+	cutoffFieldSessionID = "session_id"
+)
+
+// tokenCutoffKey ключ отсечки токенов пользователя
+func (r *RedisRepo) tokenCutoffKey(userID uuid.UUID) string {
+	return r.key(tokenCutoffKeyPrefix + userID.String())
+}
+
+// SetTokenCutoff сохраняет отсечку с точностью до миллисекунд: токены пользователя, выпущенные не позже cutoff.At,
+// перестают приниматься.
+// ttl - время, после которого таких токенов заведомо не остается (максимальный TTL токенов)
+func (r *RedisRepo) SetTokenCutoff(ctx context.Context, userID uuid.UUID, cutoff models.TokenCutoff, ttl time.Duration) error {
+	key := r.tokenCutoffKey(userID)
+
+	pipe := r.redisConn.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key,
+		cutoffFieldAt, cutoff.At.UnixMilli(),
+		cutoffFieldSessionID, cutoff.SessionID,
+	)
+	pipe.Expire(ctx, key, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("save token cutoff error: %v", err)
+		return errs.ErrFailedToSave
+	}
+	return nil
+}
+
+// GetTokenCutoff возвращает отсечку токенов пользователя; нулевое At - отсечки нет
+func (r *RedisRepo) GetTokenCutoff(ctx context.Context, userID uuid.UUID) (models.TokenCutoff, error) {
+	fields, err := r.redisConn.HGetAll(ctx, r.tokenCutoffKey(userID)).Result()
+	if err != nil {
+		logger.Errorf("get token cutoff error: %v", err)
+		return models.TokenCutoff{}, errs.ErrValidateInRedis
+	}
+	if len(fields) == 0 {
+		return models.TokenCutoff{}, nil
+	}
+
+	at, err := strconv.ParseInt(fields[cutoffFieldAt], 10, 64)
+	if err != nil {
+		return models.TokenCutoff{}, fmt.Errorf("invalid token cutoff: %w", err)
+	}
+	return models.TokenCutoff{At: time.UnixMilli(at), SessionID: fields[cutoffFieldSessionID]}, nil
+}
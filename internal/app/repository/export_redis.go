@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+const (
+	dataExportKeyPrefix        = "data_export:"
+	dataExportArchiveKeyPrefix = "data_export_archive:"
+	userDataExportsKeyPrefix   = "user_data_exports:"
+
+	// Поля записи выгрузки
+	exportFieldUserID      = "user_id"
+	exportFieldStatus      = "status"
+	exportFieldRequestedAt = "requested_at"
+	exportFieldReadyAt     = "ready_at"
+	exportFieldExpiresAt   = "expires_at"
+)
+
+// dataExportKey ключ записи о выгрузке
+func (r *RedisRepo) dataExportKey(exportID string) string {
+	return r.key(dataExportKeyPrefix + exportID)
+}
+
+// dataExportArchiveKey ключ готового архива выгрузки
+func (r *RedisRepo) dataExportArchiveKey(exportID string) string {
+	return r.key(dataExportArchiveKeyPrefix + exportID)
+}
+
+// userDataExportsKey ключ множества выгрузок пользователя
+func (r *RedisRepo) userDataExportsKey(userID uuid.UUID) string {
+	return r.key(userDataExportsKeyPrefix + userID.String())
+}
+
+// SaveDataExport сохраняет состояние выгрузки до export.ExpiresAt
+func (r *RedisRepo) SaveDataExport(ctx context.Context, export models.DataExport) error {
+	key := r.dataExportKey(export.ID)
+	indexKey := r.userDataExportsKey(export.UserID)
+
+	pipe := r.redisConn.TxPipeline()
+	pipe.HSet(ctx, key, dataExportFields(export)...)
+	pipe.ExpireAt(ctx, key, export.ExpiresAt)
+	// выгрузки пользователя запрашиваются с одинаковым сроком хранения, последняя живет дольше всех
+	pipe.SAdd(ctx, indexKey, export.ID)
+	pipe.ExpireAt(ctx, indexKey, export.ExpiresAt)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("save data export error: %v", err)
+		return errs.ErrFailedToSave
+	}
+	return nil
+}
+
+// CompleteDataExport сохраняет архив и отмечает выгрузку готовой; оба ключа живут до export.ExpiresAt
+func (r *RedisRepo) CompleteDataExport(ctx context.Context, export models.DataExport, archive []byte) error {
+	key := r.dataExportKey(export.ID)
+	archiveKey := r.dataExportArchiveKey(export.ID)
+	indexKey := r.userDataExportsKey(export.UserID)
+
+	pipe := r.redisConn.TxPipeline()
+	pipe.Set(ctx, archiveKey, archive, 0)
+	pipe.ExpireAt(ctx, archiveKey, export.ExpiresAt)
+	pipe.HSet(ctx, key, dataExportFields(export)...)
+	pipe.ExpireAt(ctx, key, export.ExpiresAt)
+	pipe.SAdd(ctx, indexKey, export.ID)
+	pipe.ExpireAt(ctx, indexKey, export.ExpiresAt)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("save data export archive error: %v", err)
+		return errs.ErrFailedToSave
+	}
+	return nil
+}
+
+func dataExportFields(export models.DataExport) []interface{} {
+	var readyAt int64
+	if export.ReadyAt != nil {
+		readyAt = export.ReadyAt.Unix()
+	}
+	return []interface{}{
+		exportFieldUserID, export.UserID.String(),
+		exportFieldStatus, export.Status,
+		exportFieldRequestedAt, export.RequestedAt.Unix(),
+		exportFieldReadyAt, readyAt,
+		exportFieldExpiresAt, export.ExpiresAt.Unix(),
+	}
+}
+
+// GetDataExport возвращает состояние выгрузки; ErrExportNotFound, если ее нет или срок хранения истек
+func (r *RedisRepo) GetDataExport(ctx context.Context, exportID string) (models.DataExport, error) {
+	fields, err := r.redisConn.HGetAll(ctx, r.dataExportKey(exportID)).Result()
+	if err != nil {
+		logger.Errorf("get data export error: %v", err)
+		return models.DataExport{}, errs.ErrValidateInRedis
+	}
+	if len(fields) == 0 {
+		return models.DataExport{}, errs.ErrExportNotFound
+	}
+
+	userID, err := uuid.Parse(fields[exportFieldUserID])
+	if err != nil {
+		return models.DataExport{}, fmt.Errorf("invalid data export: %w", err)
+	}
+	requestedAt, _ := strconv.ParseInt(fields[exportFieldRequestedAt], 10, 64)
+	readyAt, _ := strconv.ParseInt(fields[exportFieldReadyAt], 10, 64)
+	expiresAt, _ := strconv.ParseInt(fields[exportFieldExpiresAt], 10, 64)
+
+	export := models.DataExport{
+		ID:          exportID,
+		UserID:      userID,
+		Status:      fields[exportFieldStatus],
+		RequestedAt: time.Unix(requestedAt, 0),
+		ExpiresAt:   time.Unix(expiresAt, 0),
+	}
+	if readyAt != 0 {
+		t := time.Unix(readyAt, 0)
+		export.ReadyAt = &t
+	}
+	return export, nil
+}
+
+// GetDataExportArchive возвращает готовый архив выгрузки
+func (r *RedisRepo) GetDataExportArchive(ctx context.Context, exportID string) ([]byte, error) {
+	archive, err := r.redisConn.Get(ctx, r.dataExportArchiveKey(exportID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.ErrExportNotFound
+	}
+	if err != nil {
+		logger.Errorf("get data export archive error: %v", err)
+		return nil, errs.ErrValidateInRedis
+	}
+	return archive, nil
+}
+
+// DeleteDataExports удаляет все выгрузки пользователя вместе с архивами
+func (r *RedisRepo) DeleteDataExports(ctx context.Context, userID uuid.UUID) error {
+	indexKey := r.userDataExportsKey(userID)
+
+	exportIDs, err := r.redisConn.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		logger.Errorf("Failed to get user data exports from Redis: %v", err)
+		return fmt.Errorf("failed to delete data exports: %w", err)
+	}
+
+	keys := make([]string, 0, len(exportIDs)*2+1)
+	for _, exportID := range exportIDs {
+		keys = append(keys, r.dataExportKey(exportID), r.dataExportArchiveKey(exportID))
+	}
+	keys = append(keys, indexKey)
+
+	if err := r.redisConn.Del(ctx, keys...).Err(); err != nil {
+		logger.Errorf("Failed to delete data exports from Redis: %v", err)
+		return fmt.Errorf("failed to delete data exports: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+const (
+	loginFailuresKeyPrefix = "login_failures:"
+	cooldownKeyPrefix      = "cooldown:"
+)
+
+// loginFailuresKey ключ скользящего окна неудачных входов (sorted set, score - время попытки)
+func (r *RedisRepo) loginFailuresKey(key string) string {
+	return r.key(loginFailuresKeyPrefix + key)
+}
+
+// RegisterLoginFailure добавляет неудачную попытку входа в скользящее окно
+func (r *RedisRepo) RegisterLoginFailure(ctx context.Context, key string, window time.Duration) error {
+	now := time.Now()
+	redisKey := r.loginFailuresKey(key)
+
+	pipe := r.redisConn.TxPipeline()
+	pipe.ZAdd(ctx, redisKey, redis.Z{Score: float64(now.UnixMilli()), Member: uuid.NewString()})
+	pipe.ZRemRangeByScore(ctx, redisKey, "-inf", strconv.FormatInt(now.Add(-window).UnixMilli(), 10))
+	pipe.Expire(ctx, redisKey, window)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("Failed to register login failure: %v", err)
+		return errs.ErrFailedToSave
+	}
+	return nil
+}
+
+// LoginFailures возвращает неудачные попытки входа в скользящем окне
+func (r *RedisRepo) LoginFailures(ctx context.Context, key string, window time.Duration) (models.LoginFailures, error) {
+	redisKey := r.loginFailuresKey(key)
+	minScore := strconv.FormatInt(time.Now().Add(-window).UnixMilli(), 10)
+
+	pipe := r.redisConn.Pipeline()
+	countCmd := pipe.ZCount(ctx, redisKey, minScore, "+inf")
+	oldestCmd := pipe.ZRangeByScoreWithScores(ctx, redisKey, &redis.ZRangeBy{Min: minScore, Max: "+inf", Count: 1})
+	lastCmd := pipe.ZRevRangeWithScores(ctx, redisKey, 0, 0)
+
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		logger.Errorf("Failed to get login failures: %v", err)
+		return models.LoginFailures{}, fmt.Errorf("failed to get login failures: %w", err)
+	}
+
+	failures := models.LoginFailures{Count: countCmd.Val()}
+	if oldest := oldestCmd.Val(); len(oldest) > 0 {
+		failures.Oldest = time.UnixMilli(int64(oldest[0].Score))
+	}
+	if last := lastCmd.Val(); len(last) > 0 {
+		failures.Last = time.UnixMilli(int64(last[0].Score))
+	}
+	return failures, nil
+}
+
+// ResetLoginFailures очищает окно неудачных попыток (после успешного входа)
+func (r *RedisRepo) ResetLoginFailures(ctx context.Context, key string) error {
+	if err := r.redisConn.Del(ctx, r.loginFailuresKey(key)).Err(); err != nil {
+		logger.Errorf("Failed to reset login failures: %v", err)
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
+
+// AcquireCooldown занимает key на ttl. Возвращает false, если предыдущий ttl еще не истек
+// (например, письмо на этот адрес уже отправлялось недавно)
+func (r *RedisRepo) AcquireCooldown(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := r.redisConn.SetNX(ctx, r.key(cooldownKeyPrefix+key), 1, ttl).Result()
+	if err != nil {
+		logger.Errorf("Failed to acquire cooldown: %v", err)
+		return false, fmt.Errorf("failed to acquire cooldown: %w", err)
+	}
+	return ok, nil
+}
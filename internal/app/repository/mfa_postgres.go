@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+// GetTOTP возвращает зашифрованный секрет и состояние TOTP пользователя
+func (r *PostgresRepo) GetTOTP(ctx context.Context, id uuid.UUID) (models.TOTP, error) {
+	query := "SELECT totp_secret, totp_enabled_at, totp_last_step FROM users WHERE id = $1"
+
+	var totp models.TOTP
+	err := r.db.QueryRow(ctx, query, id).Scan(&totp.Secret, &totp.EnabledAt, &totp.LastStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TOTP{}, errs.ErrUserNotFound
+		}
+		logger.Errorf("query GetTOTP error: %v", err)
+		return models.TOTP{}, err
+	}
+	return totp, nil
+}
+
+// SetPendingTOTP сохраняет новый неподтвержденный секрет. Включенный TOTP не перезаписывается
+func (r *PostgresRepo) SetPendingTOTP(ctx context.Context, id uuid.UUID, secret []byte) error {
+	query := `UPDATE users SET totp_secret = $2, totp_enabled_at = NULL, totp_last_step = 0
+		WHERE id = $1 AND totp_enabled_at IS NULL`
+
+	tag, err := r.db.Exec(ctx, query, id, secret)
+	if err != nil {
+		logger.Errorf("query SetPendingTOTP error: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTOTP включает TOTP после подтверждения кодом шага step
+func (r *PostgresRepo) EnableTOTP(ctx context.Context, id uuid.UUID, step int64) error {
+	query := `UPDATE users SET totp_enabled_at = now(), totp_last_step = $2
+		WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL`
+
+	tag, err := r.db.Exec(ctx, query, id, step)
+	if err != nil {
+		logger.Errorf("query EnableTOTP error: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// UseTOTPStep атомарно отмечает шаг кода использованным.
+// false - код этого или более позднего шага уже принимался (повтор)
+func (r *PostgresRepo) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	query := "UPDATE users SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2"
+
+	tag, err := r.db.Exec(ctx, query, id, step)
+	if err != nil {
+		logger.Errorf("query UseTOTPStep error: %v", err)
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя новым набором
+func (r *PostgresRepo) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		logger.Errorf("begin ReplaceRecoveryCodes error: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		logger.Errorf("query ReplaceRecoveryCodes error: %v", err)
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	for _, hash := range codeHashes {
+		batch.Queue("INSERT INTO recovery_codes(user_id, code_hash) VALUES ($1, $2)", userID, hash)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		logger.Errorf("query ReplaceRecoveryCodes error: %v", err)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseRecoveryCode атомарно погашает код восстановления. false - кода нет или он уже использован
+func (r *PostgresRepo) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	query := `UPDATE recovery_codes SET used_at = now()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	tag, err := r.db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		logger.Errorf("query UseRecoveryCode error: %v", err)
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CountRecoveryCodes возвращает число неиспользованных кодов восстановления
+func (r *PostgresRepo) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := "SELECT count(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL"
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		logger.Errorf("query CountRecoveryCodes error: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN failed_login_attempts integer not null default 0,
+    ADD COLUMN locked_until timestamptz;
@@ -0,0 +1,3 @@
+-- Версия серверного перца, с которым получен password_hash (0 - без перца)
+ALTER TABLE users
+    ADD COLUMN pepper_version integer not null default 0;
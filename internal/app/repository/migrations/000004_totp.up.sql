@@ -0,0 +1,6 @@
+-- TOTP: секрет хранится зашифрованным (AES-GCM), totp_enabled_at заполняется после подтверждения первым кодом,
+-- totp_last_step - шаг последнего принятого кода (защита от повторного использования)
+ALTER TABLE users
+    ADD COLUMN totp_secret bytea,
+    ADD COLUMN totp_enabled_at timestamptz,
+    ADD COLUMN totp_last_step bigint not null default 0;
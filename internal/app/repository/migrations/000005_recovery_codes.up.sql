@@ -0,0 +1,11 @@
+-- Одноразовые коды восстановления MFA, хранится только SHA-256 кода
+CREATE TABLE recovery_codes
+(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID not null references users(id) on delete cascade,
+    code_hash varchar(64) not null,
+    created_at timestamptz not null default now(),
+    used_at timestamptz
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
@@ -0,0 +1,14 @@
+-- Ключи доступа WebAuthn (passkeys): публичный ключ COSE и счетчик подписей
+CREATE TABLE webauthn_credentials
+(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID not null references users(id) on delete cascade,
+    credential_id bytea not null unique,
+    public_key bytea not null,
+    sign_count bigint not null default 0,
+    name varchar(64) not null default '',
+    created_at timestamptz not null default now(),
+    last_used_at timestamptz
+);
+
+CREATE INDEX idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
@@ -0,0 +1,6 @@
+-- email_verified_at заполняется после перехода по ссылке из письма.
+-- Существующие пользователи входили без подтверждения, поэтому считаются подтвержденными
+ALTER TABLE users
+    ADD COLUMN email_verified_at timestamptz;
+
+UPDATE users SET email_verified_at = created_at;
@@ -0,0 +1,3 @@
+-- Время последней смены пароля: токены, выпущенные раньше, отклоняются
+ALTER TABLE users
+    ADD COLUMN password_changed_at timestamptz;
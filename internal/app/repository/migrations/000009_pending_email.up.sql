@@ -0,0 +1,3 @@
+-- Новый email до подтверждения по ссылке, отправленной на него
+ALTER TABLE users
+    ADD COLUMN pending_email varchar(255);
@@ -0,0 +1,5 @@
+-- Время запроса удаления учетной записи. После срока отмены строка удаляется фоновой задачей
+ALTER TABLE users
+    ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,11 @@
+-- Журнал событий безопасности пользователя, попадает в выгрузку персональных данных
+CREATE TABLE audit_events
+(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID not null references users(id) on delete cascade,
+    event varchar(64) not null,
+    ip varchar(64) not null default '',
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX idx_audit_events_user_id ON audit_events(user_id, created_at);
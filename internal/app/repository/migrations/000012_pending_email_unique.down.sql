@@ -0,0 +1 @@
+DROP INDEX IF EXISTS users_pending_email_key;
@@ -0,0 +1,3 @@
+-- Один и тот же новый email не может ожидать подтверждения у нескольких пользователей.
+-- Совпадение с основным email другого пользователя отклоняет users_email_key при подтверждении
+CREATE UNIQUE INDEX users_pending_email_key ON users(pending_email);
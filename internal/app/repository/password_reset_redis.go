@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+)
+
+const (
+	passwordResetKeyPrefix     = "password_reset:"
+	passwordResetUserKeyPrefix = "password_reset_user:"
+)
+
+// passwordResetKey ключ токена сброса пароля по его отпечатку, значение - id пользователя
+func (r *RedisRepo) passwordResetKey(digest string) string {
+	return r.key(passwordResetKeyPrefix + digest)
+}
+
+// passwordResetUserKey ключ последнего выданного пользователю токена сброса, значение - отпечаток токена
+func (r *RedisRepo) passwordResetUserKey(userID uuid.UUID) string {
+	return r.key(passwordResetUserKeyPrefix + userID.String())
+}
+
+// SavePasswordResetToken сохраняет отпечаток токена сброса пароля. У пользователя действует
+// только последний выданный токен: предыдущий удаляется
+func (r *RedisRepo) SavePasswordResetToken(ctx context.Context, token string, userID uuid.UUID, ttl time.Duration) error {
+	userKey := r.passwordResetUserKey(userID)
+	digest := r.digest(token)
+
+	previous, err := r.redisConn.Get(ctx, userKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		logger.Errorf("get password reset token error: %v", err)
+		return errs.ErrFailedToSave
+	}
+
+	pipe := r.redisConn.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, r.passwordResetKey(previous))
+	}
+	pipe.Set(ctx, r.passwordResetKey(digest), userID.String(), ttl)
+	pipe.Set(ctx, userKey, digest, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("save password reset token error: %v", err)
+		return errs.ErrFailedToSave
+	}
+	return nil
+}
+
+// FindPasswordResetToken возвращает пользователя, которому выдан токен сброса, не погашая токен
+func (r *RedisRepo) FindPasswordResetToken(ctx context.Context, token string) (uuid.UUID, error) {
+	value, err := r.redisConn.Get(ctx, r.passwordResetKey(r.digest(token))).Result()
+	if errors.Is(err, redis.Nil) {
+		return uuid.UUID{}, errs.ErrResetTokenInvalid
+	}
+	if err != nil {
+		logger.Errorf("find password reset token error: %v", err)
+		return uuid.UUID{}, fmt.Errorf("failed to find password reset token: %w", err)
+	}
+	return parseResetUserID(value)
+}
+
+// ConsumePasswordResetToken возвращает пользователя и удаляет токен сброса: каждый токен принимается один раз
+func (r *RedisRepo) ConsumePasswordResetToken(ctx context.Context, token string) (uuid.UUID, error) {
+	value, err := r.redisConn.GetDel(ctx, r.passwordResetKey(r.digest(token))).Result()
+	if errors.Is(err, redis.Nil) {
+		return uuid.UUID{}, errs.ErrResetTokenInvalid
+	}
+	if err != nil {
+		logger.Errorf("consume password reset token error: %v", err)
+		return uuid.UUID{}, fmt.Errorf("failed to consume password reset token: %w", err)
+	}
+
+	userID, err := parseResetUserID(value)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if err := r.redisConn.Del(ctx, r.passwordResetUserKey(userID)).Err(); err != nil {
+		logger.Errorf("delete password reset index error: %v", err)
+	}
+	return userID, nil
+}
+
+func parseResetUserID(value string) (uuid.UUID, error) {
+	userID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.UUID{}, errs.ErrResetTokenInvalid
+	}
+	return userID, nil
+}
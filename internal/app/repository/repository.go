@@ -14,13 +14,68 @@ import (
 type PostgresRepository interface {
 	CreateUser(ctx context.Context, user models.UserInput) (uuid.UUID, error)
 	GetUser(ctx context.Context, username string) (models.GetUserResponse, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (models.GetUserResponse, error)
+	GetUserByEmail(ctx context.Context, email string) (models.GetUserResponse, error)
+	VerifyEmail(ctx context.Context, id uuid.UUID, email string) (bool, error)
+	UpdateUsername(ctx context.Context, id uuid.UUID, username string) error
+	SetPendingEmail(ctx context.Context, id uuid.UUID, email string) error
+	ConfirmEmailChange(ctx context.Context, id uuid.UUID, email string) (bool, error)
+	MarkUserDeleted(ctx context.Context, id uuid.UUID) (time.Time, error)
+	RestoreUser(ctx context.Context, id uuid.UUID, deletedAfter time.Time) (bool, error)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error)
+	SaveAuditEvent(ctx context.Context, userID uuid.UUID, event models.AuditEvent) error
+	GetAuditEvents(ctx context.Context, userID uuid.UUID) ([]models.AuditEvent, error)
+	RegisterFailedLogin(ctx context.Context, id uuid.UUID, threshold int, lockout time.Duration) (*time.Time, error)
+	ResetFailedLogins(ctx context.Context, id uuid.UUID) error
+	UpdatePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string, pepperVersion int) error
+	SetPasswordHash(ctx context.Context, id uuid.UUID, hash string, pepperVersion int) (time.Time, error)
+	ChangePassword(ctx context.Context, id uuid.UUID, oldHash, newHash string, pepperVersion int) (time.Time, error)
+	GetTOTP(ctx context.Context, id uuid.UUID) (models.TOTP, error)
+	SetPendingTOTP(ctx context.Context, id uuid.UUID, secret []byte) error
+	EnableTOTP(ctx context.Context, id uuid.UUID, step int64) error
+	UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+	CreateWebAuthnCredential(ctx context.Context, cred models.WebAuthnCredential) (uuid.UUID, error)
+	GetWebAuthnCredential(ctx context.Context, credentialID []byte) (models.WebAuthnCredential, error)
+	GetWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error)
+	UpdateWebAuthnSignCount(ctx context.Context, id uuid.UUID, oldCount, newCount uint32) (bool, error)
 }
 
 type RedisRepository interface {
-	SaveToken(ctx context.Context, token string, ttl time.Duration) error
+	SaveToken(ctx context.Context, token string, userID uuid.UUID, familyID string, ttl time.Duration) error
 	FindTokenInRedis(ctx context.Context, token string) error
 	DeleteToken(ctx context.Context, token string) error
-	UpdateRefreshTokenInRedis(ctx context.Context, oldToken, newToken string, ttl time.Duration) error
+	UpdateRefreshTokenInRedis(ctx context.Context, oldToken, newToken string, userID uuid.UUID, familyID string, ttl time.Duration) error
+	FamilyExists(ctx context.Context, familyID string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, userID uuid.UUID, keepFamilyID string) error
+	SetTokenCutoff(ctx context.Context, userID uuid.UUID, cutoff models.TokenCutoff, ttl time.Duration) error
+	GetTokenCutoff(ctx context.Context, userID uuid.UUID) (models.TokenCutoff, error)
+	SaveDataExport(ctx context.Context, export models.DataExport) error
+	CompleteDataExport(ctx context.Context, export models.DataExport, archive []byte) error
+	GetDataExport(ctx context.Context, exportID string) (models.DataExport, error)
+	GetDataExportArchive(ctx context.Context, exportID string) ([]byte, error)
+	DeleteDataExports(ctx context.Context, userID uuid.UUID) error
+	DenyToken(ctx context.Context, jti string, exp time.Time) error
+	IsTokenDenied(ctx context.Context, jti string) (bool, error)
+	SaveSession(ctx context.Context, userID uuid.UUID, session models.Session, ttl time.Duration) error
+	TouchSession(ctx context.Context, userID uuid.UUID, sessionID string, client models.ClientInfo, ttl time.Duration) error
+	GetSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+	SessionBelongsTo(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error)
+	DenySession(ctx context.Context, sessionID string, ttl time.Duration) error
+	IsSessionDenied(ctx context.Context, sessionID string) (bool, error)
+	RegisterLoginFailure(ctx context.Context, key string, window time.Duration) error
+	LoginFailures(ctx context.Context, key string, window time.Duration) (models.LoginFailures, error)
+	ResetLoginFailures(ctx context.Context, key string) error
+	AcquireCooldown(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	SavePasswordResetToken(ctx context.Context, token string, userID uuid.UUID, ttl time.Duration) error
+	FindPasswordResetToken(ctx context.Context, token string) (uuid.UUID, error)
+	ConsumePasswordResetToken(ctx context.Context, token string) (uuid.UUID, error)
+	SaveWebAuthnChallenge(ctx context.Context, challenge string, data models.WebAuthnChallenge, ttl time.Duration) error
+	ConsumeWebAuthnChallenge(ctx context.Context, challenge string) (models.WebAuthnChallenge, error)
 }
 
 type Repository struct {
@@ -28,9 +83,11 @@ type Repository struct {
 	RedisRepository
 }
 
-func NewRepository(db *pgxpool.Pool, redisConn *redis.Client) *Repository {
+// NewRepository создает репозиторий; ключи redis размещаются под keyPrefix,
+// refresh токены хранятся в виде HMAC с секретом tokenSecret
+func NewRepository(db *pgxpool.Pool, redisConn *redis.Client, keyPrefix string, tokenSecret []byte) *Repository {
 	return &Repository{
 		PostgresRepository: NewPostgresRepo(db),
-		RedisRepository:    NewRedisRepo(redisConn),
+		RedisRepository:    NewRedisRepo(redisConn, keyPrefix, tokenSecret),
 	}
 }
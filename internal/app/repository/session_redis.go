@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+const (
+	sessionKeyPrefix      = "session:"
+	userSessionsKeyPrefix = "user_sessions:"
+	denySessionKeyPrefix  = "deny_session:"
+
+	// Поля записи сессии
+	sessionFieldUserID          = "user_id"
+	sessionFieldUserAgent       = "user_agent"
+	sessionFieldIP              = "ip"
+	sessionFieldCreatedAt       = "created_at"
+	sessionFieldLastRefreshedAt = "last_refreshed_at"
+)
+
+// sessionKey ключ записи сессии (id сессии совпадает с id семейства refresh токенов)
+func (r *RedisRepo) sessionKey(sessionID string) string {
+	return r.key(sessionKeyPrefix + sessionID)
+}
+
+// userSessionsKey ключ множества сессий пользователя
+func (r *RedisRepo) userSessionsKey(userID string) string {
+	return r.key(userSessionsKeyPrefix + userID)
+}
+
+// denySessionKey ключ отметки о завершенной сессии
+func (r *RedisRepo) denySessionKey(sessionID string) string {
+	return r.key(denySessionKeyPrefix + sessionID)
+}
+
+// SaveSession сохраняет запись о новой сессии пользователя
+func (r *RedisRepo) SaveSession(ctx context.Context, userID uuid.UUID, session models.Session, ttl time.Duration) error {
+	key := r.sessionKey(session.ID)
+	indexKey := r.userSessionsKey(userID.String())
+
+	pipe := r.redisConn.TxPipeline()
+	pipe.HSet(ctx, key,
+		sessionFieldUserID, userID.String(),
+		sessionFieldUserAgent, session.UserAgent,
+		sessionFieldIP, session.IP,
+		sessionFieldCreatedAt, session.CreatedAt.Unix(),
+		sessionFieldLastRefreshedAt, session.LastRefreshedAt.Unix(),
+	)
+	pipe.Expire(ctx, key, ttl)
+	pipe.SAdd(ctx, indexKey, session.ID)
+	pipe.Expire(ctx, indexKey, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("save session error: %v", err)
+		return errs.ErrFailedToSave
+	}
+	return nil
+}
+
+// TouchSession обновляет время последнего обновления токенов и адрес клиента, продлевая сессию
+func (r *RedisRepo) TouchSession(ctx context.Context, userID uuid.UUID, sessionID string, client models.ClientInfo, ttl time.Duration) error {
+	key := r.sessionKey(sessionID)
+	indexKey := r.userSessionsKey(userID.String())
+
+	exists, err := r.redisConn.Exists(ctx, key).Result()
+	if err != nil {
+		logger.Errorf("Failed to check session in Redis: %v", err)
+		return errs.ErrValidateInRedis
+	}
+
+	pipe := r.redisConn.TxPipeline()
+	fields := []interface{}{
+		sessionFieldUserID, userID.String(),
+		sessionFieldIP, client.IP,
+		sessionFieldLastRefreshedAt, time.Now().Unix(),
+	}
+	if exists == 0 {
+		// Сессия, начатая до появления записей о сессиях
+		fields = append(fields,
+			sessionFieldUserAgent, client.UserAgent,
+			sessionFieldCreatedAt, time.Now().Unix(),
+		)
+	}
+	pipe.HSet(ctx, key, fields...)
+	pipe.Expire(ctx, key, ttl)
+	pipe.SAdd(ctx, indexKey, sessionID)
+	pipe.Expire(ctx, indexKey, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("touch session error: %v", err)
+		return errs.ErrFailedToRefresh
+	}
+	return nil
+}
+
+// GetSessions возвращает активные сессии пользователя
+func (r *RedisRepo) GetSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	indexKey := r.userSessionsKey(userID.String())
+
+	ids, err := r.redisConn.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		logger.Errorf("Failed to get user sessions from Redis: %v", err)
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	pipe := r.redisConn.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, r.sessionKey(id))
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			logger.Errorf("Failed to get sessions from Redis: %v", err)
+			return nil, fmt.Errorf("failed to get sessions: %w", err)
+		}
+	}
+
+	sessions := make([]models.Session, 0, len(ids))
+	var expired []interface{}
+	for i, id := range ids {
+		fields := cmds[i].Val()
+		if len(fields) == 0 {
+			expired = append(expired, id)
+			continue
+		}
+		sessions = append(sessions, models.Session{
+			ID:              id,
+			UserAgent:       fields[sessionFieldUserAgent],
+			IP:              fields[sessionFieldIP],
+			CreatedAt:       unixField(fields[sessionFieldCreatedAt]),
+			LastRefreshedAt: unixField(fields[sessionFieldLastRefreshedAt]),
+		})
+	}
+
+	// Истекшие сессии убираем из индекса
+	if len(expired) > 0 {
+		r.redisConn.SRem(ctx, indexKey, expired...)
+	}
+	return sessions, nil
+}
+
+// SessionBelongsTo проверяет, что сессия принадлежит пользователю
+func (r *RedisRepo) SessionBelongsTo(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error) {
+	ok, err := r.redisConn.SIsMember(ctx, r.userSessionsKey(userID.String()), sessionID).Result()
+	if err != nil {
+		logger.Errorf("Failed to check session in Redis: %v", err)
+		return false, errs.ErrValidateInRedis
+	}
+	return ok, nil
+}
+
+// deleteSession добавляет в транзакцию удаление записи сессии
+func (r *RedisRepo) deleteSession(ctx context.Context, pipe redis.Pipeliner, userID, sessionID string) {
+	pipe.Del(ctx, r.sessionKey(sessionID))
+	if userID != "" {
+		pipe.SRem(ctx, r.userSessionsKey(userID), sessionID)
+	}
+}
+
+func unixField(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// DenySession отмечает сессию завершенной: access токены с ее sid перестают приниматься.
+// ttl - время, после которого выданных в сессии access токенов заведомо не остается
+func (r *RedisRepo) DenySession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	if err := r.redisConn.Set(ctx, r.denySessionKey(sessionID), ActiveToken, ttl).Err(); err != nil {
+		logger.Errorf("Failed to add session %s to denylist: %v", sessionID, err)
+		return errs.ErrFailedToSave
+	}
+	return nil
+}
+
+// IsSessionDenied проверяет, что сессия завершена
+func (r *RedisRepo) IsSessionDenied(ctx context.Context, sessionID string) (bool, error) {
+	exists, err := r.redisConn.Exists(ctx, r.denySessionKey(sessionID)).Result()
+	if err != nil {
+		logger.Errorf("Failed to check session denylist in Redis: %v", err)
+		return false, errs.ErrValidateInRedis
+	}
+	return exists > 0, nil
+}
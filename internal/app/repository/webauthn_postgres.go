@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+const webauthnColumns = "id, user_id, credential_id, public_key, sign_count, name, created_at, last_used_at"
+
+func scanWebAuthnCredential(row pgx.Row, cred *models.WebAuthnCredential) error {
+	var signCount int64
+	err := row.Scan(&cred.ID, &cred.UserID, &cred.CredentialID, &cred.PublicKey, &signCount, &cred.Name,
+		&cred.CreatedAt, &cred.LastUsedAt)
+	cred.SignCount = uint32(signCount)
+	return err
+}
+
+// CreateWebAuthnCredential сохраняет ключ доступа пользователя
+func (r *PostgresRepo) CreateWebAuthnCredential(ctx context.Context, cred models.WebAuthnCredential) (uuid.UUID, error) {
+	query := `INSERT INTO webauthn_credentials(user_id, credential_id, public_key, sign_count, name)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, query, cred.UserID, cred.CredentialID, cred.PublicKey, int64(cred.SignCount), cred.Name).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == DuplicateValue {
+			return uuid.UUID{}, errs.ErrWebAuthnCredentialExists
+		}
+		logger.Errorf("query CreateWebAuthnCredential error: %v", err)
+		return uuid.UUID{}, err
+	}
+	return id, nil
+}
+
+// GetWebAuthnCredential ищет ключ доступа по credential id аутентификатора
+func (r *PostgresRepo) GetWebAuthnCredential(ctx context.Context, credentialID []byte) (models.WebAuthnCredential, error) {
+	query := "SELECT " + webauthnColumns + " FROM webauthn_credentials WHERE credential_id = $1"
+
+	var cred models.WebAuthnCredential
+	if err := scanWebAuthnCredential(r.db.QueryRow(ctx, query, credentialID), &cred); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.WebAuthnCredential{}, errs.ErrWebAuthnCredentialNotFound
+		}
+		logger.Errorf("query GetWebAuthnCredential error: %v", err)
+		return models.WebAuthnCredential{}, err
+	}
+	return cred, nil
+}
+
+// GetWebAuthnCredentials возвращает ключи доступа пользователя
+func (r *PostgresRepo) GetWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	query := "SELECT " + webauthnColumns + " FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at"
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		logger.Errorf("query GetWebAuthnCredentials error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var creds []models.WebAuthnCredential
+	for rows.Next() {
+		var cred models.WebAuthnCredential
+		if err := scanWebAuthnCredential(rows, &cred); err != nil {
+			logger.Errorf("scan GetWebAuthnCredentials error: %v", err)
+			return nil, err
+		}
+		creds = append(creds, cred)
+	}
+	return creds, rows.Err()
+}
+
+// UpdateWebAuthnSignCount обновляет счетчик подписей после входа, если его не изменил параллельный вход.
+// false - счетчик уже изменился
+func (r *PostgresRepo) UpdateWebAuthnSignCount(ctx context.Context, id uuid.UUID, oldCount, newCount uint32) (bool, error) {
+	query := `UPDATE webauthn_credentials SET sign_count = $3, last_used_at = now()
+		WHERE id = $1 AND sign_count = $2`
+
+	tag, err := r.db.Exec(ctx, query, id, int64(oldCount), int64(newCount))
+	if err != nil {
+		logger.Errorf("query UpdateWebAuthnSignCount error: %v", err)
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+const (
+	webauthnChallengeKeyPrefix = "webauthn_challenge:"
+
+	// Поля записи челленджа
+	challengeFieldCeremony = "ceremony"
+	challengeFieldUserID   = "user_id"
+)
+
+// webauthnChallengeKey ключ челленджа церемонии WebAuthn
+func (r *RedisRepo) webauthnChallengeKey(challenge string) string {
+	return r.key(webauthnChallengeKeyPrefix + challenge)
+}
+
+// SaveWebAuthnChallenge сохраняет выданный браузеру челлендж до ответа аутентификатора
+func (r *RedisRepo) SaveWebAuthnChallenge(ctx context.Context, challenge string, data models.WebAuthnChallenge, ttl time.Duration) error {
+	key := r.webauthnChallengeKey(challenge)
+
+	pipe := r.redisConn.TxPipeline()
+	pipe.HSet(ctx, key,
+		challengeFieldCeremony, data.Ceremony,
+		challengeFieldUserID, data.UserID.String(),
+	)
+	pipe.Expire(ctx, key, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("save webauthn challenge error: %v", err)
+		return errs.ErrFailedToSave
+	}
+	return nil
+}
+
+// ConsumeWebAuthnChallenge возвращает и удаляет челлендж: каждый принимается один раз
+func (r *RedisRepo) ConsumeWebAuthnChallenge(ctx context.Context, challenge string) (models.WebAuthnChallenge, error) {
+	key := r.webauthnChallengeKey(challenge)
+
+	pipe := r.redisConn.TxPipeline()
+	getCmd := pipe.HGetAll(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Errorf("consume webauthn challenge error: %v", err)
+		return models.WebAuthnChallenge{}, fmt.Errorf("failed to consume webauthn challenge: %w", err)
+	}
+
+	fields := getCmd.Val()
+	if len(fields) == 0 {
+		return models.WebAuthnChallenge{}, errs.ErrWebAuthnChallengeInvalid
+	}
+	userID, err := uuid.Parse(fields[challengeFieldUserID])
+	if err != nil {
+		return models.WebAuthnChallenge{}, errs.ErrWebAuthnChallengeInvalid
+	}
+	return models.WebAuthnChallenge{Ceremony: fields[challengeFieldCeremony], UserID: userID}, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/mailer"
+	"service-auth/internal/app/models"
+)
+
+// checkAccountActive запрещает вход в учетную запись, ожидающую удаления
+func checkAccountActive(user models.GetUserResponse) error {
+	if user.DeletedAt != nil {
+		return errs.ErrAccountDeleted
+	}
+	return nil
+}
+
+// DeleteAccount помечает учетную запись удаленной после проверки пароля и отзывает все ее токены.
+// Данные удаляются фоновой задачей по истечении account.deletion_grace_period
+func (s *Auth) DeleteAccount(ctx context.Context, userID uuid.UUID, input models.DeleteAccountInput,
+	client models.ClientInfo) (models.AccountDeletion, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.AccountDeletion{}, err
+	}
+
+	if err := s.checkLoginAllowed(ctx, user.Username, client.IP); err != nil {
+		return models.AccountDeletion{}, err
+	}
+	if err := s.verifyPassword(ctx, user, input.Password, client); err != nil {
+		return models.AccountDeletion{}, err
+	}
+
+	deletedAt, err := s.repo.MarkUserDeleted(ctx, user.ID)
+	if err != nil {
+		return models.AccountDeletion{}, err
+	}
+	deletion := models.AccountDeletion{
+		DeletedAt: deletedAt,
+		PurgeAt:   deletedAt.Add(s.cfg.Account.DeletionGracePeriod),
+	}
+
+	recordSecurityEvent(ctx, s.repo, user.ID, EventAccountDeleted, logger.Fields{
+		"user_id":  user.ID.String(),
+		"ip":       client.IP,
+		"purge_at": deletion.PurgeAt,
+	})
+
+	if err := s.cutOffTokens(ctx, user.ID, deletedAt, ""); err != nil {
+		return models.AccountDeletion{}, err
+	}
+
+	s.sendMail(ctx, user.Email, mailer.TemplateAccountDeletion, mailData{
+		Username: user.Username,
+		PurgeAt:  deletion.PurgeAt,
+	})
+	return deletion, nil
+}
+
+// RestoreAccount отменяет удаление учетной записи по логину и паролю, пока не истек срок отмены.
+// Токены, отозванные при удалении, не восстанавливаются: после отмены нужно войти заново
+func (s *Auth) RestoreAccount(ctx context.Context, username, password string, client models.ClientInfo) error {
+	if err := s.checkLoginAllowed(ctx, username, client.IP); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			s.registerLoginFailure(ctx, username, client.IP)
+		}
+		return err
+	}
+	if err := checkAccountLock(user); err != nil {
+		return err
+	}
+	if err := s.verifyPassword(ctx, user, password, client); err != nil {
+		return err
+	}
+
+	if user.DeletedAt == nil {
+		return nil
+	}
+
+	restored, err := s.repo.RestoreUser(ctx, user.ID, time.Now().Add(-s.cfg.Account.DeletionGracePeriod))
+	if err != nil {
+		return err
+	}
+	if !restored {
+		// срок отмены истек, запись ждет очистки
+		return errs.ErrUserNotFound
+	}
+
+	recordSecurityEvent(ctx, s.repo, user.ID, EventAccountRestored, logger.Fields{
+		"user_id": user.ID.String(),
+		"ip":      client.IP,
+	})
+
+	s.resetLoginFailures(ctx, user)
+	return nil
+}
+
+// PurgeDeletedAccounts окончательно удаляет учетные записи, срок отмены удаления которых истек
+func (s *Auth) PurgeDeletedAccounts(ctx context.Context) error {
+	ids, err := s.repo.PurgeDeletedUsers(ctx, time.Now().Add(-s.cfg.Account.DeletionGracePeriod))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		// готовые выгрузки содержат персональные данные и не должны пережить учетную запись
+		if err := s.repo.DeleteDataExports(ctx, id); err != nil {
+			logger.Errorf("delete data exports of purged account error: %v", err)
+		}
+		emitSecurityEvent(EventAccountPurged, logger.Fields{
+			"user_id": id.String(),
+		})
+	}
+	return nil
+}
+
+// RunAccountPurge периодически очищает удаленные учетные записи до отмены ctx
+func (s *Auth) RunAccountPurge(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.PurgeDeletedAccounts(ctx); err != nil {
+			logger.Errorf("purge deleted accounts error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/mailer"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/utils"
+)
+
+// checkEmailVerified запрещает вход с неподтвержденным email, если этого требует конфиг
+func (s *Auth) checkEmailVerified(user models.GetUserResponse) error {
+	if s.cfg.Email.RequireVerified && !user.EmailVerified {
+		return errs.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// sendVerification отправляет письмо со ссылкой подтверждения email. Ошибки только логируются
+func (s *Auth) sendVerification(ctx context.Context, user models.GetUserResponse) {
+	token, err := s.jwtManager.GenerateEmailToken(user.Username, user.Role, user.ID, user.Email, s.cfg.Email.VerificationTTL)
+	if err != nil {
+		logger.Errorf("generate email verification token error: %v", err)
+		return
+	}
+
+	link, err := linkWithToken(s.cfg.Email.VerifyURL, token)
+	if err != nil {
+		logger.Errorf("build email verification link error: %v", err)
+		return
+	}
+
+	s.sendMail(ctx, user.Email, mailer.TemplateVerifyEmail, mailData{
+		Username:  user.Username,
+		Link:      link,
+		ExpiresAt: time.Now().Add(s.cfg.Email.VerificationTTL),
+	})
+}
+
+// linkWithToken добавляет токен к странице сервиса параметром token
+func linkWithToken(pageURL, token string) (string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// VerifyEmail подтверждает email по токену из письма. Токен действует, только пока email пользователя не сменился
+func (s *Auth) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := s.jwtManager.DecodeJWT(token)
+	if err != nil || claims.TokenType != utils.EmailToken || claims.Email == "" {
+		return errs.ErrEmailTokenInvalid
+	}
+	if err := s.checkDenylist(ctx, claims); err != nil {
+		return errs.ErrEmailTokenInvalid
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return errs.ErrEmailTokenInvalid
+	}
+
+	verified, err := s.repo.VerifyEmail(ctx, userID, claims.Email)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		user, err := s.repo.GetUserByID(ctx, userID)
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return errs.ErrEmailTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+		// повторный переход по ссылке для уже подтвержденного адреса не ошибка
+		if user.Email != claims.Email || !user.EmailVerified {
+			return errs.ErrEmailTokenInvalid
+		}
+		return nil
+	}
+
+	// ссылка одноразовая
+	if err := s.denyToken(ctx, claims); err != nil {
+		logger.Errorf("deny email verification token error: %v", err)
+	}
+	return nil
+}
+
+// ResendVerification повторно отправляет письмо подтверждения. Результат не раскрывает,
+// есть ли такой адрес и подтвержден ли он; письма на один адрес не чаще email.resend_interval
+func (s *Auth) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	ok, err := s.repo.AcquireCooldown(ctx, "verify_email:"+user.ID.String(), s.cfg.Email.ResendInterval)
+	if err != nil {
+		return err
+	}
+	if ok {
+		s.sendVerification(ctx, user)
+	}
+	return nil
+}
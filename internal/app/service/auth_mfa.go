@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/utils"
+)
+
+// mfaChallenge выдает mfa_token вместо токенов, когда после пароля требуется второй фактор
+func (s *Auth) mfaChallenge(user models.GetUserResponse) (models.Tokens, error) {
+	mfaToken, err := s.jwtManager.GenerateMFAToken(user.Username, user.Role, user.ID, s.cfg.MFA.ChallengeTTL)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	return models.Tokens{MFARequired: true, MFAToken: mfaToken}, nil
+}
+
+// mfaEnrollChallenge выдает mfa_token обязательной настройки TOTP вместо токенов, когда роли пользователя
+// нужна MFA, а она не включена. Без ключа шифрования секретов MFA не настроить, и такая роль не входит
+func (s *Auth) mfaEnrollChallenge(user models.GetUserResponse) (models.Tokens, error) {
+	if s.totp.cipher == nil {
+		return models.Tokens{}, errs.ErrMFANotConfigured
+	}
+
+	mfaToken, err := s.jwtManager.GenerateMFAEnrollToken(user.Username, user.Role, user.ID, s.cfg.MFA.ChallengeTTL)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	return models.Tokens{MFAEnrollmentRequired: true, MFAToken: mfaToken}, nil
+}
+
+// mfaEnrollClaims проверяет mfa_token обязательной настройки TOTP
+func (s *Auth) mfaEnrollClaims(ctx context.Context, mfaToken string) (*utils.Claims, uuid.UUID, error) {
+	claims, err := s.jwtManager.DecodeJWT(mfaToken)
+	if err != nil || claims.TokenType != utils.MFAEnrollToken {
+		return nil, uuid.Nil, errs.ErrMFATokenInvalid
+	}
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return nil, uuid.Nil, errs.ErrMFATokenInvalid
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, uuid.Nil, errs.ErrMFATokenInvalid
+	}
+	return claims, userID, nil
+}
+
+// checkMFARequired не продлевает сессии роли, которой нужна MFA, пока MFA не включена
+// (например, сессии, начатые до появления требования)
+func (s *Auth) checkMFARequired(ctx context.Context, userID uuid.UUID, role string) error {
+	if !s.cfg.MFA.Required(role) {
+		return nil
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return errs.ErrMFARequired
+	}
+	return nil
+}
+
+// VerifyMFA завершает вход с MFA: проверяет mfa_token и код, после чего выдает access и refresh
+func (s *Auth) VerifyMFA(ctx context.Context, mfaToken, code string, client models.ClientInfo) (models.Tokens, error) {
+	claims, err := s.jwtManager.DecodeJWT(mfaToken)
+	if err != nil || claims.TokenType != utils.MFAToken {
+		return models.Tokens{}, errs.ErrMFATokenInvalid
+	}
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return models.Tokens{}, errs.ErrMFATokenInvalid
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return models.Tokens{}, errs.ErrMFATokenInvalid
+	}
+
+	if err := s.totp.verify(ctx, userID, code, false); err != nil {
+		return models.Tokens{}, err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	// mfa_token одноразовый
+	if err := s.denyToken(ctx, claims); err != nil {
+		return models.Tokens{}, err
+	}
+
+	return s.issueTokens(ctx, user, client)
+}
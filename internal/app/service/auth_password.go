@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/mailer"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/utils"
+)
+
+const passwordResetTokenSize = 32
+
+// ForgotPassword отправляет ссылку сброса пароля. Результат не раскрывает, зарегистрирован ли адрес;
+// письма на один аккаунт не чаще password.reset_interval
+func (s *Auth) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.repo.AcquireCooldown(ctx, "password_reset:"+user.ID.String(), s.cfg.Password.ResetInterval)
+	if err != nil || !ok {
+		return err
+	}
+
+	raw := make([]byte, passwordResetTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("error generating password reset token: %w", err)
+	}
+	token := utils.EncodeBase64URL(raw)
+
+	// в redis хранится только отпечаток токена
+	if err := s.repo.SavePasswordResetToken(ctx, token, user.ID, s.cfg.Password.ResetTokenTTL); err != nil {
+		return err
+	}
+
+	link, err := linkWithToken(s.cfg.Password.ResetURL, token)
+	if err != nil {
+		return fmt.Errorf("build password reset link: %w", err)
+	}
+
+	s.sendMail(ctx, user.Email, mailer.TemplatePasswordReset, mailData{
+		Username:  user.Username,
+		Link:      link,
+		ExpiresAt: time.Now().Add(s.cfg.Password.ResetTokenTTL),
+	})
+	return nil
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма и завершает все сессии пользователя.
+// Токен гасится только после проверки пароля политикой, чтобы слабый пароль не сжигал ссылку
+func (s *Auth) ResetPassword(ctx context.Context, token, password string) error {
+	userID, err := s.repo.FindPasswordResetToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		return errs.ErrResetTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.policy.Validate(password, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, pepperVersion, err := s.passwords.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	// токен одноразовый: из параллельных запросов пароль установит только один
+	consumedID, err := s.repo.ConsumePasswordResetToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if consumedID != user.ID {
+		return errs.ErrResetTokenInvalid
+	}
+
+	changedAt, err := s.repo.SetPasswordHash(ctx, user.ID, hashedPassword, pepperVersion)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(ctx, s.repo, user.ID, EventPasswordReset, logger.Fields{
+		"user_id": user.ID.String(),
+	})
+
+	// новый пароль снимает блокировку за перебор старого
+	s.resetLoginFailures(ctx, user)
+	return s.afterPasswordChange(ctx, user, changedAt, "")
+}
+
+// ChangePassword меняет пароль аутентифицированного пользователя после проверки текущего.
+// Сессия sessionID, из которой сменен пароль, сохраняется, остальные сессии и токены отзываются
+func (s *Auth) ChangePassword(ctx context.Context, userID uuid.UUID, sessionID string, input models.ChangePasswordInput,
+	client models.ClientInfo) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// подбор текущего пароля с украденным access токеном ограничен так же, как вход
+	if err := s.checkLoginAllowed(ctx, user.Username, client.IP); err != nil {
+		return err
+	}
+	if err := s.verifyPassword(ctx, user, input.CurrentPassword, client); err != nil {
+		return err
+	}
+
+	if input.NewPassword == input.CurrentPassword {
+		return &errs.ValidationError{
+			Err:    errs.ErrWeakPassword,
+			Fields: map[string]string{utils.PasswordField: "must differ from the current password"},
+		}
+	}
+	if err := s.policy.Validate(input.NewPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, pepperVersion, err := s.passwords.Hash(input.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	changedAt, err := s.repo.ChangePassword(ctx, user.ID, user.Password, hashedPassword, pepperVersion)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(ctx, s.repo, user.ID, EventPasswordChanged, logger.Fields{
+		"user_id": user.ID.String(),
+		"ip":      client.IP,
+	})
+
+	s.resetLoginFailures(ctx, user)
+	return s.afterPasswordChange(ctx, user, changedAt, sessionID)
+}
+
+// afterPasswordChange отзывает токены, выпущенные до смены пароля (кроме сессии keepSessionID,
+// если она задана), и уведомляет пользователя письмом
+func (s *Auth) afterPasswordChange(ctx context.Context, user models.GetUserResponse, changedAt time.Time, keepSessionID string) error {
+	if err := s.cutOffTokens(ctx, user.ID, changedAt, keepSessionID); err != nil {
+		return err
+	}
+
+	s.sendMail(ctx, user.Email, mailer.TemplatePasswordChanged, mailData{
+		Username:  user.Username,
+		ChangedAt: changedAt,
+	})
+	return nil
+}
+
+// cutOffTokens отзывает токены пользователя, выпущенные до at: access токены отклоняются по отметке
+// отсечения, refresh токены удаляются. Сессия keepSessionID, если она задана, сохраняется
+func (s *Auth) cutOffTokens(ctx context.Context, userID uuid.UUID, at time.Time, keepSessionID string) error {
+	cutoff := models.TokenCutoff{At: at, SessionID: keepSessionID}
+	if err := s.repo.SetTokenCutoff(ctx, userID, cutoff, s.cfg.KeyRetention()); err != nil {
+		return err
+	}
+
+	if keepSessionID == "" {
+		return s.repo.RevokeAllForUser(ctx, userID)
+	}
+	return s.repo.RevokeOtherSessions(ctx, userID, keepSessionID)
+}
+
+// verifyPassword проверяет пароль пользователя для подтверждения действия; неудача учитывается
+// в лимитах входа так же, как неверный пароль при входе
+func (s *Auth) verifyPassword(ctx context.Context, user models.GetUserResponse, password string, client models.ClientInfo) error {
+	ok, err := s.passwords.Verify(password, user.Password, user.PepperVersion)
+	if err != nil {
+		logger.Errorf("verify password hash error: %v", err)
+	}
+	if !ok {
+		s.registerLoginFailure(ctx, user.Username, client.IP)
+		return errs.ErrInvalidPwd
+	}
+	return nil
+}
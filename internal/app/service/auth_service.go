@@ -2,13 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	logger "github.com/sirupsen/logrus"
 
 	"service-auth/internal/app/errs"
+	"service-auth/internal/app/mailer"
 	"service-auth/internal/app/models"
 	"service-auth/internal/app/repository"
 	"service-auth/internal/app/utils"
@@ -18,54 +21,154 @@ import (
 type Auth struct {
 	repo       *repository.Repository
 	jwtManager *utils.JWTManager
+	passwords  *utils.PasswordManager
+	policy     *utils.PasswordPolicy
+	totp       *totpVerifier
+	mailer     mailer.Mailer
+	templates  *mailer.Templates
 	cfg        *configs.Config
 }
 
-func NewAuth(repo *repository.Repository, jwtManager *utils.JWTManager, cfg *configs.Config) *Auth {
-	return &Auth{repo: repo, jwtManager: jwtManager, cfg: cfg}
+func NewAuth(repo *repository.Repository, jwtManager *utils.JWTManager, passwords *utils.PasswordManager,
+	policy *utils.PasswordPolicy, cipher *utils.SecretCipher,
+	mail mailer.Mailer, templates *mailer.Templates, cfg *configs.Config) *Auth {
+	return &Auth{
+		repo:       repo,
+		jwtManager: jwtManager,
+		passwords:  passwords,
+		policy:     policy,
+		totp:       newTOTPVerifier(repo, cipher, cfg),
+		mailer:     mail,
+		templates:  templates,
+		cfg:        cfg,
+	}
 }
 
 func (s *Auth) CreateUser(ctx context.Context, user models.UserInput) (uuid.UUID, error) {
+	if err := s.policy.Validate(user.Password, user.Username, user.Email); err != nil {
+		return uuid.UUID{}, err
+	}
+
 	// Хэшируем пароль пользователя
-	hashedPassword, err := utils.HashPassword(user.Password)
+	hashedPassword, pepperVersion, err := s.passwords.Hash(user.Password)
 	if err != nil {
 		logger.Errorf(err.Error())
 		return uuid.UUID{}, err
 	}
 
 	user.Password = hashedPassword
+	user.PepperVersion = pepperVersion
 
 	res, err := s.repo.CreateUser(ctx, user)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
+
+	// письмо не доставлено - регистрация все равно состоялась, письмо можно запросить повторно
+	s.sendVerification(ctx, models.GetUserResponse{ID: res, Username: user.Username, Email: user.Email})
 	return res, nil
 }
 
 // GenerateTokens создает токены access, refresh, сохраняет refresh в redis
-func (s *Auth) GenerateTokens(ctx context.Context, username, password string) (models.Tokens, error) {
+func (s *Auth) GenerateTokens(ctx context.Context, username, password string, client models.ClientInfo) (models.Tokens, error) {
+	// лимиты по IP и прогрессивная задержка по логину проверяются до обращения к паролю
+	if err := s.checkLoginAllowed(ctx, username, client.IP); err != nil {
+		return models.Tokens{}, err
+	}
+
 	user, err := s.repo.GetUser(ctx, username)
 	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			s.registerLoginFailure(ctx, username, client.IP)
+		}
+		return models.Tokens{}, err
+	}
+
+	if err := checkAccountLock(user); err != nil {
+		s.registerLoginFailure(ctx, username, client.IP)
 		return models.Tokens{}, err
 	}
 
-	checkPwd := utils.CheckPasswordHash(password, user.Password)
-	if checkPwd != nil {
+	ok, err := s.passwords.Verify(password, user.Password, user.PepperVersion)
+	if err != nil {
+		logger.Errorf("verify password hash error: %v", err)
+	}
+	if !ok {
+		s.registerLoginFailure(ctx, username, client.IP)
+		if err := s.lockAccountOnFailure(ctx, user, client); err != nil {
+			return models.Tokens{}, err
+		}
 		return models.Tokens{}, errs.ErrInvalidPwd
 	}
 
-	accessToken, err := s.jwtManager.GenerateAccessToken(user.Username, user.Role, user.ID, s.cfg.Auth.AccessTokenTTL)
+	s.resetLoginFailures(ctx, user)
+	s.rehashPassword(ctx, user, password)
+
+	// состояние учетной записи и email сообщается только после верного пароля, чтобы не раскрывать его посторонним
+	if err := checkAccountActive(user); err != nil {
+		return models.Tokens{}, err
+	}
+	if err := s.checkEmailVerified(user); err != nil {
+		return models.Tokens{}, err
+	}
+
+	if user.MFAEnabled {
+		return s.mfaChallenge(user)
+	}
+	if s.cfg.MFA.Required(user.Role) {
+		return s.mfaEnrollChallenge(user)
+	}
+
+	return s.issueTokens(ctx, user, client)
+}
+
+// rehashPassword перехэширует пароль, если хэш получен со старым перцем, устаревшим алгоритмом
+// или более слабыми параметрами. Ошибки только логируются: вход уже подтвержден
+func (s *Auth) rehashPassword(ctx context.Context, user models.GetUserResponse, password string) {
+	if !s.passwords.NeedsRehash(user.Password, user.PepperVersion) {
+		return
+	}
+
+	newHash, pepperVersion, err := s.passwords.Hash(password)
+	if err != nil {
+		logger.Errorf("rehash password error: %v", err)
+		return
+	}
+	if err := s.repo.UpdatePasswordHash(ctx, user.ID, user.Password, newHash, pepperVersion); err != nil {
+		logger.Errorf("update password hash error: %v", err)
+	}
+}
+
+// issueTokens начинает новую сессию пользователя: выдает access и refresh токены,
+// сохраняет refresh и запись о сессии в redis
+func (s *Auth) issueTokens(ctx context.Context, user models.GetUserResponse, client models.ClientInfo) (models.Tokens, error) {
+	// каждый логин начинает новое семейство refresh токенов, оно же - сессия
+	familyID := uuid.NewString()
+
+	accessToken, err := s.jwtManager.GenerateAccessToken(user.Username, user.Role, user.ID, familyID, s.cfg.Auth.AccessTokenTTL)
 	if err != nil {
 		return models.Tokens{}, err
 	}
 
-	refreshToken, err := s.jwtManager.GenerateRefreshToken(user.Username, user.Role, user.ID, s.cfg.Auth.RefreshTokenTTL)
+	refreshToken, err := s.jwtManager.GenerateRefreshToken(user.Username, user.Role, user.ID, familyID, s.cfg.Auth.RefreshTokenTTL)
 	if err != nil {
 		return models.Tokens{}, err
 	}
 
 	// сохраняем refresh в redis
-	err = s.repo.SaveToken(ctx, refreshToken, s.cfg.Auth.RefreshTokenTTL)
+	err = s.repo.SaveToken(ctx, refreshToken, user.ID, familyID, s.cfg.Auth.RefreshTokenTTL)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	now := time.Now().UTC()
+	err = s.repo.SaveSession(ctx, user.ID, models.Session{
+		ID:              familyID,
+		UserAgent:       client.UserAgent,
+		IP:              client.IP,
+		CreatedAt:       now,
+		LastRefreshedAt: now,
+	}, s.cfg.Auth.RefreshTokenTTL)
 	if err != nil {
 		return models.Tokens{}, err
 	}
@@ -77,76 +180,356 @@ func (s *Auth) GenerateTokens(ctx context.Context, username, password string) (m
 }
 
 // RefreshTokens обновляет access и refresh токен доступа
-func (s *Auth) RefreshTokens(ctx context.Context, oldRefreshToken string) (models.Tokens, error) {
-	claims, err := s.ValidateRefreshToken(oldRefreshToken)
+func (s *Auth) RefreshTokens(ctx context.Context, oldRefreshToken string, client models.ClientInfo) (models.Tokens, error) {
+	claims, err := s.ValidateRefreshToken(ctx, oldRefreshToken)
 	if err != nil {
 		return models.Tokens{}, err
 	}
 
-	username := claims.(jwt.MapClaims)["username"].(string)
-	role := claims.(jwt.MapClaims)["role"].(string)
-	sub := claims.(jwt.MapClaims)["sub"].(string)
+	username := claims.Username
+	role := claims.Role
+	sub := claims.Subject
 
-	uuidObj, err := uuid.Parse(sub)
+	uuidObj, err := claims.UserID()
 	if err != nil {
 		logger.Errorf("error parse UUID: %s", err.Error())
 		return models.Tokens{}, errs.ErrParseUUID
 	}
 
+	if err := s.checkMFARequired(ctx, uuidObj, role); err != nil {
+		return models.Tokens{}, err
+	}
+
+	familyID := claims.FamilyID
+
 	err = s.repo.FindTokenInRedis(ctx, oldRefreshToken)
+	if errors.Is(err, errs.ErrTokenNotFound) && familyID != "" {
+		// токена нет, но семейство живо - предъявлен уже использованный токен
+		return models.Tokens{}, s.detectReuse(ctx, familyID, sub)
+	}
 	if err != nil {
 		return models.Tokens{}, err
 	}
 
-	newAccessToken, err := s.jwtManager.GenerateAccessToken(username, role, uuidObj, s.cfg.Auth.AccessTokenTTL)
+	newAccessToken, err := s.jwtManager.GenerateAccessToken(username, role, uuidObj, familyID, s.cfg.Auth.AccessTokenTTL)
 	if err != nil {
 		return models.Tokens{}, fmt.Errorf("error generating access token: %w", err)
 	}
 
-	newRefreshToken, err := s.jwtManager.GenerateRefreshToken(username, role, uuidObj, s.cfg.Auth.RefreshTokenTTL)
+	newRefreshToken, err := s.jwtManager.GenerateRefreshToken(username, role, uuidObj, familyID, s.cfg.Auth.RefreshTokenTTL)
 	if err != nil {
 		return models.Tokens{}, fmt.Errorf("error generating refresh token: %w", err)
 	}
 
 	logger.Debug("Access tokens refreshed successfully for user: ", username)
 
-	err = s.repo.UpdateRefreshTokenInRedis(ctx, oldRefreshToken, newRefreshToken, s.cfg.Auth.RefreshTokenTTL)
+	err = s.repo.UpdateRefreshTokenInRedis(ctx, oldRefreshToken, newRefreshToken, uuidObj, familyID, s.cfg.Auth.RefreshTokenTTL)
+	if errors.Is(err, errs.ErrTokenReused) {
+		return models.Tokens{}, s.revokeReusedFamily(ctx, familyID, sub)
+	}
 	if err != nil {
 		return models.Tokens{}, fmt.Errorf("failed to update refresh token: %w", err)
 	}
+
+	err = s.repo.TouchSession(ctx, uuidObj, familyID, client, s.cfg.Auth.RefreshTokenTTL)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
 	return models.Tokens{
 		AccessToken:  newAccessToken,
 		RefreshToken: newRefreshToken,
 	}, nil
 }
 
-func (s *Auth) ValidateRefreshToken(token string) (jwt.Claims, error) {
+func (s *Auth) ValidateRefreshToken(ctx context.Context, token string) (*utils.Claims, error) {
 	claims, err := s.jwtManager.DecodeJWT(token)
 	if err != nil {
 		return nil, errs.ErrTokenInvalid
 	}
 
-	tokenType, ok := claims.(jwt.MapClaims)["token_type"].(string)
-	if !ok || tokenType != utils.RefreshToken {
+	if claims.TokenType != utils.RefreshToken {
 		return nil, errs.ErrInvalidTokenType
 	}
 
-	_, ok = claims.(jwt.MapClaims)["username"].(string)
-	if !ok {
-		logger.Debugf("missing username in payload: %v", err)
+	if claims.Username == "" {
+		logger.Debug("missing username in payload")
 		return nil, errs.ErrMissingUsername
 	}
 
-	_, ok = claims.(jwt.MapClaims)["sub"].(string)
-	if !ok {
-		logger.Debugf("missing sub in payload: %v", err)
+	if claims.Subject == "" {
+		logger.Debug("missing sub in payload")
 		return nil, errs.ErrMissingUserID
 	}
 
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
-// RevokeToken удаляет refresh токен из redis
+// ValidateAccessToken проверяет подпись и тип access токена, а также что он не отозван
+func (s *Auth) ValidateAccessToken(ctx context.Context, token string) (*utils.Claims, error) {
+	claims, err := s.jwtManager.DecodeJWT(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != utils.AccessToken {
+		return nil, errs.ErrAccessTokenInvalid
+	}
+
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// checkRevoked отклоняет отозванные токены: занесенные в denylist, выданные в завершенной сессии
+// и выпущенные до смены пароля
+func (s *Auth) checkRevoked(ctx context.Context, claims *utils.Claims) error {
+	if err := s.checkDenylist(ctx, claims); err != nil {
+		return err
+	}
+	if err := s.checkSessionDenied(ctx, claims); err != nil {
+		return err
+	}
+	return s.checkTokenCutoff(ctx, claims)
+}
+
+// checkSessionDenied отклоняет access токены сессии, завершенной пользователем
+func (s *Auth) checkSessionDenied(ctx context.Context, claims *utils.Claims) error {
+	if claims.SessionID == "" {
+		return nil
+	}
+
+	denied, err := s.repo.IsSessionDenied(ctx, claims.SessionID)
+	if err != nil {
+		return err
+	}
+	if denied {
+		return errs.ErrTokenRevoked
+	}
+	return nil
+}
+
+// checkTokenCutoff отклоняет токены, выпущенные до последней смены пароля, кроме токенов сессии,
+// из которой пароль был сменен. Отсечка и iat_ms хранятся с точностью до миллисекунд; токен,
+// выпущенный в ту же миллисекунду, что и отсечка, отклоняется
+func (s *Auth) checkTokenCutoff(ctx context.Context, claims *utils.Claims) error {
+	issuedAt := claims.IssuedAtPrecise()
+	if issuedAt.IsZero() {
+		return nil
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil
+	}
+
+	cutoff, err := s.repo.GetTokenCutoff(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if cutoff.At.IsZero() || issuedAt.After(cutoff.At) {
+		return nil
+	}
+
+	sessionID := claims.SessionID
+	if sessionID == "" {
+		sessionID = claims.FamilyID
+	}
+	if cutoff.SessionID != "" && sessionID == cutoff.SessionID {
+		return nil
+	}
+	return errs.ErrTokenRevoked
+}
+
+// checkDenylist отклоняет токены, jti которых занесен в denylist.
+// Токены без jti выпущены до появления denylist и отозваны быть не могут.
+func (s *Auth) checkDenylist(ctx context.Context, claims *utils.Claims) error {
+	if claims.ID == "" {
+		return nil
+	}
+
+	denied, err := s.repo.IsTokenDenied(ctx, claims.ID)
+	if err != nil {
+		return err
+	}
+	if denied {
+		return errs.ErrTokenRevoked
+	}
+	return nil
+}
+
+// denyToken заносит токен в denylist до истечения его срока действия
+func (s *Auth) denyToken(ctx context.Context, claims *utils.Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return s.repo.DenyToken(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// detectReuse проверяет, что семейство отозванного токена еще действует, и если так - отзывает его целиком
+func (s *Auth) detectReuse(ctx context.Context, familyID, sub string) error {
+	exists, err := s.repo.FamilyExists(ctx, familyID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errs.ErrTokenNotFound
+	}
+	return s.revokeReusedFamily(ctx, familyID, sub)
+}
+
+// revokeReusedFamily отзывает семейство, в котором обнаружено повторное использование токена (OAuth 2.0 Security BCP)
+func (s *Auth) revokeReusedFamily(ctx context.Context, familyID, sub string) error {
+	fields := logger.Fields{
+		"user_id":   sub,
+		"family_id": familyID,
+	}
+	if userID, err := uuid.Parse(sub); err == nil {
+		recordSecurityEvent(ctx, s.repo, userID, EventRefreshTokenReuse, fields)
+	} else {
+		emitSecurityEvent(EventRefreshTokenReuse, fields)
+	}
+
+	if err := s.repo.RevokeFamily(ctx, familyID); err != nil && !errors.Is(err, errs.ErrTokenNotFound) {
+		return err
+	}
+	return errs.ErrTokenReused
+}
+
+// RevokeToken удаляет refresh токен из redis вместе с его семейством и заносит его в denylist
 func (s *Auth) RevokeToken(ctx context.Context, token string) error {
-	return s.repo.DeleteToken(ctx, token)
+	if err := s.repo.DeleteToken(ctx, token); err != nil {
+		return err
+	}
+
+	claims, err := s.jwtManager.DecodeJWT(token)
+	if err != nil {
+		return nil
+	}
+	if err := s.denyToken(ctx, claims); err != nil {
+		return err
+	}
+
+	// Без семейства последующее предъявление токена не будет принято за повторное использование
+	if claims.FamilyID != "" {
+		if err := s.repo.RevokeFamily(ctx, claims.FamilyID); err != nil && !errors.Is(err, errs.ErrTokenNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeAccessToken заносит access токен в denylist, после чего он перестает приниматься до истечения срока
+func (s *Auth) RevokeAccessToken(ctx context.Context, token string) error {
+	claims, err := s.jwtManager.DecodeJWT(token)
+	if err != nil {
+		return err
+	}
+
+	if claims.TokenType != utils.AccessToken {
+		return errs.ErrAccessTokenInvalid
+	}
+
+	return s.denyToken(ctx, claims)
+}
+
+// Introspect проверяет токен (подпись, срок, denylist, наличие refresh в redis) и
+// возвращает его состояние в формате RFC 7662. Недействительный токен - active=false без ошибки.
+func (s *Auth) Introspect(ctx context.Context, token string) (models.IntrospectionResponse, error) {
+	inactive := models.IntrospectionResponse{Active: false}
+
+	claims, err := s.jwtManager.DecodeJWT(token)
+	if err != nil {
+		return inactive, nil
+	}
+	switch claims.TokenType {
+	case utils.AccessToken:
+		err = s.checkRevoked(ctx, claims)
+	case utils.RefreshToken:
+		err = s.checkRevoked(ctx, claims)
+		if err == nil {
+			err = s.repo.FindTokenInRedis(ctx, token)
+		}
+	default:
+		return inactive, nil
+	}
+	if errors.Is(err, errs.ErrValidateInRedis) {
+		return models.IntrospectionResponse{}, err
+	}
+	if err != nil {
+		return inactive, nil
+	}
+
+	response := models.IntrospectionResponse{
+		Active:    true,
+		Sub:       claims.Subject,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		TokenType: claims.TokenType + "_token",
+		Iss:       claims.Issuer,
+		Aud:       claims.Audience,
+		Jti:       claims.ID,
+	}
+	if claims.ExpiresAt != nil {
+		response.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.Iat = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		response.Nbf = claims.NotBefore.Unix()
+	}
+	return response, nil
+}
+
+// RevokeAllForUser отзывает все токены пользователя (выход со всех устройств): refresh токены удаляются,
+// ранее выданные access токены отклоняются по отметке отсечения
+func (s *Auth) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	if err := s.cutOffTokens(ctx, userID, time.Now(), ""); err != nil {
+		return err
+	}
+
+	logger.Infof("All sessions revoked for user %s", userID)
+	return nil
+}
+
+// GetSessions возвращает активные сессии пользователя, отмечая сессию текущего запроса
+func (s *Auth) GetSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]models.Session, error) {
+	sessions, err := s.repo.GetSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastRefreshedAt.After(sessions[j].LastRefreshedAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию пользователя: семейство ее refresh токенов отзывается,
+// выданные в ней access токены перестают приниматься
+func (s *Auth) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	ok, err := s.repo.SessionBelongsTo(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrSessionNotFound
+	}
+
+	if err := s.repo.RevokeFamily(ctx, sessionID); err != nil {
+		if errors.Is(err, errs.ErrTokenNotFound) {
+			return errs.ErrSessionNotFound
+		}
+		return err
+	}
+	return s.repo.DenySession(ctx, sessionID, s.cfg.Auth.AccessTokenTTL)
 }
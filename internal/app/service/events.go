@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/repository"
+)
+
+// События безопасности
+const (
+	EventRefreshTokenReuse = "refresh_token_reuse"
+	EventAccountLocked     = "account_locked"
+	EventRecoveryCodeUsed  = "mfa_recovery_code_used"
+	EventPasswordReset     = "password_reset"
+	EventPasswordChanged   = "password_changed"
+	EventEmailChanged      = "email_changed"
+	EventAccountDeleted    = "account_deleted"
+	EventAccountRestored   = "account_restored"
+	EventAccountPurged     = "account_purged"
+
+	EventWebAuthnCounterRegression = "webauthn_counter_regression"
+)
+
+// emitSecurityEvent записывает событие безопасности в лог (поле security_event используется для алертов)
+func emitSecurityEvent(event string, fields logger.Fields) {
+	entry := logger.WithField("security_event", event)
+	entry.WithFields(fields).Warn("security event")
+}
+
+// recordSecurityEvent записывает событие в лог и в журнал аудита пользователя (журнал попадает в выгрузку данных).
+// Ошибка записи в журнал только логируется
+func recordSecurityEvent(ctx context.Context, repo *repository.Repository, userID uuid.UUID, event string, fields logger.Fields) {
+	emitSecurityEvent(event, fields)
+
+	ip, _ := fields["ip"].(string)
+	if err := repo.SaveAuditEvent(ctx, userID, models.AuditEvent{Event: event, IP: ip}); err != nil {
+		logger.Errorf("save audit event %s error: %v", event, err)
+	}
+}
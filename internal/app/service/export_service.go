@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/mailer"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/repository"
+	"service-auth/internal/app/utils"
+	"service-auth/internal/configs"
+)
+
+// exportJob выгрузка, ожидающая сборки
+type exportJob struct {
+	export models.DataExport
+	notify bool // отправить пользователю письмо со ссылкой
+}
+
+type Export struct {
+	repo *repository.Repository
+	auth *Auth
+	jobs chan exportJob // очередь сборки, обрабатывается RunExports
+	cfg  *configs.Config
+}
+
+func NewExport(repo *repository.Repository, auth *Auth, cfg *configs.Config) *Export {
+	return &Export{repo: repo, auth: auth, jobs: make(chan exportJob, max(cfg.Export.QueueSize, 1)), cfg: cfg}
+}
+
+// RequestExport запускает сборку выгрузки данных по запросу пользователя. Запросы не чаще
+// export.request_interval, ссылка на готовую выгрузку отправляется письмом
+func (s *Export) RequestExport(ctx context.Context, userID uuid.UUID) (models.DataExport, error) {
+	ok, err := s.repo.AcquireCooldown(ctx, "data_export:"+userID.String(), s.cfg.Export.RequestInterval)
+	if err != nil {
+		return models.DataExport{}, err
+	}
+	if !ok {
+		return models.DataExport{}, errs.WithRetryAfter(errs.ErrTooManyAttempts, s.cfg.Export.RequestInterval)
+	}
+	return s.start(ctx, userID, true)
+}
+
+// RequestExportByAdmin запускает сборку выгрузки по запросу администратора: без ограничения частоты и письма
+func (s *Export) RequestExportByAdmin(ctx context.Context, userID uuid.UUID) (models.DataExport, error) {
+	return s.start(ctx, userID, false)
+}
+
+func (s *Export) start(ctx context.Context, userID uuid.UUID, notify bool) (models.DataExport, error) {
+	if _, err := s.repo.GetUserByID(ctx, userID); err != nil {
+		return models.DataExport{}, err
+	}
+
+	now := time.Now()
+	export := models.DataExport{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		Status:      models.ExportPending,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(s.cfg.Export.TTL),
+	}
+	if err := s.repo.SaveDataExport(ctx, export); err != nil {
+		return models.DataExport{}, err
+	}
+
+	// выгрузка собирается в фоне (RunExports) и не зависит от запроса
+	select {
+	case s.jobs <- exportJob{export: export, notify: notify}:
+		return export, nil
+	default:
+		export.Status = models.ExportFailed
+		if err := s.repo.SaveDataExport(ctx, export); err != nil {
+			logger.Errorf("save data export %s error: %v", export.ID, err)
+		}
+		return models.DataExport{}, errs.ErrExportQueueFull
+	}
+}
+
+// RunExports запускает workers сборщиков выгрузок и ждет их завершения после отмены ctx.
+// Выгрузки, не собранные к отмене, остаются в статусе pending до истечения export.ttl
+func (s *Export) RunExports(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.jobs:
+					s.build(ctx, job.export, job.notify)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if pending := len(s.jobs); pending > 0 {
+		logger.Warnf("data export queue stopped with %d pending exports", pending)
+	}
+}
+
+// build собирает архив и отмечает выгрузку готовой (или неудачной)
+func (s *Export) build(ctx context.Context, export models.DataExport, notify bool) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Export.Timeout)
+	defer cancel()
+
+	archive, user, err := s.collect(ctx, export.UserID)
+	var data []byte
+	if err == nil {
+		data, err = json.MarshalIndent(archive, "", "  ")
+	}
+	if err != nil {
+		logger.Errorf("build data export %s error: %v", export.ID, err)
+		export.Status = models.ExportFailed
+		if err := s.repo.SaveDataExport(ctx, export); err != nil {
+			logger.Errorf("save data export %s error: %v", export.ID, err)
+		}
+		return
+	}
+
+	readyAt := time.Now()
+	export.Status = models.ExportReady
+	export.ReadyAt = &readyAt
+	export.ExpiresAt = readyAt.Add(s.cfg.Export.TTL)
+	if err := s.repo.CompleteDataExport(ctx, export, data); err != nil {
+		logger.Errorf("save data export %s error: %v", export.ID, err)
+		return
+	}
+
+	if !notify {
+		return
+	}
+	link, err := s.downloadLink(export)
+	if err != nil {
+		logger.Errorf("build data export link error: %v", err)
+		return
+	}
+	s.auth.sendMail(ctx, user.Email, mailer.TemplateDataExport, mailData{
+		Username:  user.Username,
+		Link:      link,
+		ExpiresAt: export.ExpiresAt,
+	})
+}
+
+// collect собирает все данные пользователя, кроме секретов (хэш пароля, секрет TOTP, хэши кодов, ключи)
+func (s *Export) collect(ctx context.Context, userID uuid.UUID) (models.DataExportArchive, models.GetUserResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.DataExportArchive{}, user, err
+	}
+
+	sessions, err := s.auth.GetSessions(ctx, userID, "")
+	if err != nil {
+		return models.DataExportArchive{}, user, err
+	}
+	totp, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return models.DataExportArchive{}, user, err
+	}
+	recoveryCodes, err := s.repo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return models.DataExportArchive{}, user, err
+	}
+	credentials, err := s.repo.GetWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return models.DataExportArchive{}, user, err
+	}
+	events, err := s.repo.GetAuditEvents(ctx, userID)
+	if err != nil {
+		return models.DataExportArchive{}, user, err
+	}
+
+	archive := models.DataExportArchive{
+		GeneratedAt: time.Now(),
+		User: models.ExportedUser{
+			ID:                  user.ID,
+			Username:            user.Username,
+			Email:               user.Email,
+			EmailVerified:       user.EmailVerified,
+			PendingEmail:        user.PendingEmail,
+			Role:                user.Role,
+			PasswordChangedAt:   user.PasswordChangedAt,
+			FailedLoginAttempts: user.FailedLoginAttempts,
+			LockedUntil:         user.LockedUntil,
+			DeletedAt:           user.DeletedAt,
+			CreateAt:            user.CreateAt,
+			UpdateAt:            user.UpdateAt,
+		},
+		// пустые разделы выгружаются как [], а не null
+		Sessions: append([]models.Session{}, sessions...),
+		MFA: models.ExportedMFA{
+			TOTPEnabled:            totp.Enabled(),
+			TOTPEnabledAt:          totp.EnabledAt,
+			RecoveryCodesRemaining: recoveryCodes,
+			WebAuthnCredentials:    append([]models.WebAuthnCredential{}, credentials...),
+		},
+		AuditEvents: append([]models.AuditEvent{}, events...),
+		Consents:    []models.ExportedConsent{},
+	}
+	return archive, user, nil
+}
+
+// GetExport возвращает состояние выгрузки пользователя userID; у готовой выгрузки есть ссылка на скачивание
+func (s *Export) GetExport(ctx context.Context, userID uuid.UUID, exportID string) (models.DataExport, error) {
+	export, err := s.repo.GetDataExport(ctx, exportID)
+	if err != nil {
+		return models.DataExport{}, err
+	}
+	if export.UserID != userID {
+		return models.DataExport{}, errs.ErrExportNotFound
+	}
+
+	if export.Status == models.ExportReady {
+		if export.DownloadURL, err = s.downloadLink(export); err != nil {
+			return models.DataExport{}, err
+		}
+	}
+	return export, nil
+}
+
+// downloadLink подписывает ссылку на скачивание, действующую до конца хранения выгрузки
+func (s *Export) downloadLink(export models.DataExport) (string, error) {
+	token, err := s.auth.jwtManager.GenerateDataExportToken(export.UserID, export.ID, time.Until(export.ExpiresAt))
+	if err != nil {
+		return "", err
+	}
+	return linkWithToken(s.cfg.Export.DownloadURL, token)
+}
+
+// DownloadExport возвращает архив выгрузки по токену из ссылки. Смена пароля и удаление учетной записи
+// делают выданные ранее ссылки недействительными
+func (s *Export) DownloadExport(ctx context.Context, token string) ([]byte, error) {
+	claims, err := s.auth.jwtManager.DecodeJWT(token)
+	if err != nil || claims.TokenType != utils.DataExportToken || claims.ExportID == "" {
+		return nil, errs.ErrExportTokenInvalid
+	}
+	if err := s.auth.checkRevoked(ctx, claims); err != nil {
+		return nil, errs.ErrExportTokenInvalid
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, errs.ErrExportTokenInvalid
+	}
+	export, err := s.repo.GetDataExport(ctx, claims.ExportID)
+	if err != nil {
+		return nil, err
+	}
+	if export.UserID != userID || export.Status != models.ExportReady {
+		return nil, errs.ErrExportNotFound
+	}
+	return s.repo.GetDataExportArchive(ctx, export.ID)
+}
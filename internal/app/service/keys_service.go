@@ -0,0 +1,23 @@
+package service
+
+import (
+	"service-auth/internal/app/utils"
+)
+
+type Keys struct {
+	jwtManager *utils.JWTManager
+}
+
+func NewKeys(jwtManager *utils.JWTManager) *Keys {
+	return &Keys{jwtManager: jwtManager}
+}
+
+// JWKS возвращает набор публичных ключей, которыми проверяются выданные токены
+func (s *Keys) JWKS() utils.JWKS {
+	return s.jwtManager.JWKS()
+}
+
+// RotateKeys делает ключ kid активным ключом подписи (пустой kid - сгенерировать новый ключ)
+func (s *Keys) RotateKeys(kid string) (string, error) {
+	return s.jwtManager.RotateKey(kid)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+// Ключи скользящих окон неудачных входов
+func loginFailuresUserKey(username string) string {
+	return "user:" + username
+}
+
+func loginFailuresIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// maxDelayShift ограничивает степень удвоения задержки, чтобы не переполнить time.Duration
+const maxDelayShift = 20
+
+// checkLoginAllowed проверяет лимит неудачных попыток с IP и прогрессивную задержку по логину.
+// Без username (вход ключом доступа) проверяется только лимит по IP
+func (s *Auth) checkLoginAllowed(ctx context.Context, username, ip string) error {
+	cfg := s.cfg.Login
+	now := time.Now()
+
+	if cfg.IPMaxFailures > 0 && ip != "" {
+		failures, err := s.repo.LoginFailures(ctx, loginFailuresIPKey(ip), cfg.Window)
+		if err != nil {
+			return err
+		}
+		if failures.Count >= int64(cfg.IPMaxFailures) {
+			// лимит снимется, когда самая ранняя попытка выйдет из окна
+			return errs.WithRetryAfter(errs.ErrTooManyAttempts, failures.Oldest.Add(cfg.Window).Sub(now))
+		}
+	}
+
+	if username != "" && cfg.DelayAfter > 0 && cfg.BaseDelay > 0 {
+		failures, err := s.repo.LoginFailures(ctx, loginFailuresUserKey(username), cfg.Window)
+		if err != nil {
+			return err
+		}
+		if failures.Count >= int64(cfg.DelayAfter) {
+			next := failures.Last.Add(loginDelay(cfg.BaseDelay, cfg.MaxDelay, failures.Count-int64(cfg.DelayAfter)))
+			if now.Before(next) {
+				return errs.WithRetryAfter(errs.ErrTooManyAttempts, next.Sub(now))
+			}
+		}
+	}
+
+	return nil
+}
+
+// loginDelay задержка перед следующей попыткой: base * 2^n, не больше maxDelay
+func loginDelay(base, maxDelay time.Duration, n int64) time.Duration {
+	delay := base << min(n, maxDelayShift)
+	if delay <= 0 || delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// checkAccountLock возвращает ErrAccountLocked, если блокировка аккаунта еще действует
+func checkAccountLock(user models.GetUserResponse) error {
+	if user.LockedUntil == nil {
+		return nil
+	}
+	if left := time.Until(*user.LockedUntil); left > 0 {
+		return errs.WithRetryAfter(errs.ErrAccountLocked, left)
+	}
+	return nil
+}
+
+// registerLoginFailure учитывает неудачную попытку в окнах по логину и IP.
+// Ошибки только логируются: ответ клиенту определяет сама неудачная попытка
+func (s *Auth) registerLoginFailure(ctx context.Context, username, ip string) {
+	var keys []string
+	if username != "" {
+		keys = append(keys, loginFailuresUserKey(username))
+	}
+	if ip != "" {
+		keys = append(keys, loginFailuresIPKey(ip))
+	}
+	for _, key := range keys {
+		if err := s.repo.RegisterLoginFailure(ctx, key, s.cfg.Login.Window); err != nil {
+			logger.Errorf("register login failure error: %v", err)
+		}
+	}
+}
+
+// lockAccountOnFailure увеличивает счетчик неудач пользователя и блокирует аккаунт по достижении порога
+func (s *Auth) lockAccountOnFailure(ctx context.Context, user models.GetUserResponse, client models.ClientInfo) error {
+	cfg := s.cfg.Login
+	if cfg.LockoutThreshold <= 0 {
+		return nil
+	}
+
+	lockedUntil, err := s.repo.RegisterFailedLogin(ctx, user.ID, cfg.LockoutThreshold, cfg.LockoutDuration)
+	if err != nil {
+		logger.Errorf("register failed login error: %v", err)
+		return nil
+	}
+	if lockedUntil == nil || !lockedUntil.After(time.Now()) {
+		return nil
+	}
+
+	recordSecurityEvent(ctx, s.repo, user.ID, EventAccountLocked, logger.Fields{
+		"user_id":      user.ID.String(),
+		"ip":           client.IP,
+		"locked_until": lockedUntil.UTC().Format(time.RFC3339),
+	})
+	return errs.WithRetryAfter(errs.ErrAccountLocked, time.Until(*lockedUntil))
+}
+
+// resetLoginFailures сбрасывает счетчики неудач пользователя после успешного входа
+func (s *Auth) resetLoginFailures(ctx context.Context, user models.GetUserResponse) {
+	if err := s.repo.ResetLoginFailures(ctx, loginFailuresUserKey(user.Username)); err != nil {
+		logger.Errorf("reset login failures error: %v", err)
+	}
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return
+	}
+	if err := s.repo.ResetFailedLogins(ctx, user.ID); err != nil {
+		logger.Errorf("reset failed logins error: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// mailData данные шаблонов писем
+type mailData struct {
+	Username  string
+	Link      string
+	ExpiresAt time.Time
+	ChangedAt time.Time
+	NewEmail  string
+	PurgeAt   time.Time
+}
+
+// sendMail отправляет письмо по шаблону на языке mail.locale. Ошибки только логируются:
+// недоставленное письмо не отменяет уже выполненное действие
+func (s *Auth) sendMail(ctx context.Context, to, template string, data any) {
+	msg, err := s.templates.Render(template, s.cfg.Mail.Locale, data)
+	if err != nil {
+		logger.Errorf("render mail %s error: %v", template, err)
+		return
+	}
+	msg.To = to
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		logger.Errorf("send mail %s error: %v", template, err)
+	}
+}
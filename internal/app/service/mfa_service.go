@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/repository"
+	"service-auth/internal/app/utils"
+	"service-auth/internal/configs"
+)
+
+type MFA struct {
+	repo   *repository.Repository
+	auth   *Auth // проверка mfa_token обязательной настройки
+	totp   *totpVerifier
+	cipher *utils.SecretCipher
+	cfg    *configs.Config
+}
+
+func NewMFA(repo *repository.Repository, auth *Auth, cipher *utils.SecretCipher, cfg *configs.Config) *MFA {
+	return &MFA{repo: repo, auth: auth, totp: newTOTPVerifier(repo, cipher, cfg), cipher: cipher, cfg: cfg}
+}
+
+// EnrollTOTP создает новый секрет TOTP. Он начнет требоваться при входе только после ConfirmTOTP
+func (s *MFA) EnrollTOTP(ctx context.Context, userID uuid.UUID) (models.TOTPEnrollment, error) {
+	if s.cipher == nil {
+		return models.TOTPEnrollment{}, errs.ErrMFANotConfigured
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	if user.MFAEnabled {
+		return models.TOTPEnrollment{}, errs.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	// секрет привязан к пользователю: чужой шифртекст не расшифруется
+	encrypted, err := s.cipher.Encrypt(secret, userID[:])
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	if err := s.repo.SetPendingTOTP(ctx, userID, encrypted); err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+
+	return models.TOTPEnrollment{
+		Secret: utils.EncodeTOTPSecret(secret),
+		URI:    utils.TOTPURI(s.cfg.MFA.Issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP включает TOTP после проверки первого кода из приложения и выдает коды восстановления
+func (s *MFA) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) (models.RecoveryCodes, error) {
+	if err := s.totp.verify(ctx, userID, code, true); err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	return s.newRecoveryCodes(ctx, userID)
+}
+
+// EnrollRequiredTOTP начинает обязательную настройку TOTP по mfa_token, выданному при входе вместо токенов
+func (s *MFA) EnrollRequiredTOTP(ctx context.Context, mfaToken string) (models.TOTPEnrollment, error) {
+	_, userID, err := s.auth.mfaEnrollClaims(ctx, mfaToken)
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	return s.EnrollTOTP(ctx, userID)
+}
+
+// ConfirmRequiredTOTP завершает обязательную настройку TOTP. Токены не выдаются: после настройки
+// пользователь входит заново, подтверждая вход кодом
+func (s *MFA) ConfirmRequiredTOTP(ctx context.Context, mfaToken, code string) (models.RecoveryCodes, error) {
+	claims, userID, err := s.auth.mfaEnrollClaims(ctx, mfaToken)
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+
+	codes, err := s.ConfirmTOTP(ctx, userID, code)
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+
+	// mfa_token одноразовый; коды восстановления показываются только сейчас, поэтому ошибка лишь логируется
+	if err := s.auth.denyToken(ctx, claims); err != nil {
+		logger.Errorf("deny mfa enroll token error: %v", err)
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes выдает новый набор кодов восстановления, старые перестают действовать
+func (s *MFA) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) (models.RecoveryCodes, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	if !user.MFAEnabled {
+		return models.RecoveryCodes{}, errs.ErrMFANotEnrolled
+	}
+	return s.newRecoveryCodes(ctx, userID)
+}
+
+// RecoveryCodesStatus возвращает число оставшихся кодов восстановления
+func (s *MFA) RecoveryCodesStatus(ctx context.Context, userID uuid.UUID) (models.RecoveryCodesStatus, error) {
+	remaining, err := s.repo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return models.RecoveryCodesStatus{}, err
+	}
+	return models.RecoveryCodesStatus{Remaining: remaining}, nil
+}
+
+// newRecoveryCodes генерирует коды и сохраняет только их хэши
+func (s *MFA) newRecoveryCodes(ctx context.Context, userID uuid.UUID) (models.RecoveryCodes, error) {
+	codes, err := utils.GenerateRecoveryCodes(s.cfg.MFA.RecoveryCodes)
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashRecoveryCode(code))
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	return models.RecoveryCodes{Codes: codes}, nil
+}
+
+// totpVerifier проверяет коды TOTP: ограничивает число попыток и не принимает код повторно
+type totpVerifier struct {
+	repo   *repository.Repository
+	cipher *utils.SecretCipher
+	cfg    *configs.Config
+}
+
+func newTOTPVerifier(repo *repository.Repository, cipher *utils.SecretCipher, cfg *configs.Config) *totpVerifier {
+	return &totpVerifier{repo: repo, cipher: cipher, cfg: cfg}
+}
+
+// mfaFailuresKey окно неверных кодов пользователя. Счет идет по пользователю, а не по mfa_token,
+// иначе знающий пароль мог бы перебирать коды, получая новые токены
+func mfaFailuresKey(userID uuid.UUID) string {
+	return "mfa:" + userID.String()
+}
+
+// verify проверяет код пользователя. enrolling - подтверждение нового секрета, иначе - вход,
+// при котором вместо кода TOTP можно ввести код восстановления
+func (v *totpVerifier) verify(ctx context.Context, userID uuid.UUID, code string, enrolling bool) error {
+	if v.cipher == nil {
+		return errs.ErrMFANotConfigured
+	}
+
+	if err := v.checkAttempts(ctx, userID); err != nil {
+		return err
+	}
+
+	if !enrolling && utils.IsRecoveryCode(code) {
+		return v.verifyRecoveryCode(ctx, userID, code)
+	}
+
+	totp, err := v.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case enrolling && totp.Enabled():
+		return errs.ErrMFAAlreadyEnabled
+	case totp.Secret == nil, !enrolling && !totp.Enabled():
+		return errs.ErrMFANotEnrolled
+	}
+
+	secret, err := v.cipher.Decrypt(totp.Secret, userID[:])
+	if err != nil {
+		logger.Errorf("decrypt totp secret error: %v", err)
+		return err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now(), v.cfg.MFA.Skew)
+	if !ok {
+		v.registerFailure(ctx, userID)
+		return errs.ErrInvalidMFACode
+	}
+	if step <= totp.LastStep {
+		return errs.ErrMFACodeAlreadyUsed
+	}
+
+	if enrolling {
+		if err := v.repo.EnableTOTP(ctx, userID, step); err != nil {
+			return err
+		}
+	} else {
+		used, err := v.repo.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return errs.ErrMFACodeAlreadyUsed
+		}
+	}
+
+	v.resetFailures(ctx, userID)
+	return nil
+}
+
+// verifyRecoveryCode погашает код восстановления вместо проверки TOTP
+func (v *totpVerifier) verifyRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error {
+	used, err := v.repo.UseRecoveryCode(ctx, userID, utils.HashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		v.registerFailure(ctx, userID)
+		return errs.ErrInvalidMFACode
+	}
+	v.resetFailures(ctx, userID)
+
+	remaining, err := v.repo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		logger.Errorf("count recovery codes error: %v", err)
+	}
+	recordSecurityEvent(ctx, v.repo, userID, EventRecoveryCodeUsed, logger.Fields{
+		"user_id":   userID.String(),
+		"remaining": remaining,
+	})
+	return nil
+}
+
+// checkAttempts отклоняет проверку, пока число неверных кодов в окне не ниже лимита
+func (v *totpVerifier) checkAttempts(ctx context.Context, userID uuid.UUID) error {
+	window := v.cfg.Login.Window
+	failures, err := v.repo.LoginFailures(ctx, mfaFailuresKey(userID), window)
+	if err != nil {
+		return err
+	}
+	if failures.Count >= int64(v.cfg.MFA.MaxAttempts) {
+		return errs.WithRetryAfter(errs.ErrTooManyAttempts, time.Until(failures.Oldest.Add(window)))
+	}
+	return nil
+}
+
+func (v *totpVerifier) registerFailure(ctx context.Context, userID uuid.UUID) {
+	if err := v.repo.RegisterLoginFailure(ctx, mfaFailuresKey(userID), v.cfg.Login.Window); err != nil {
+		logger.Errorf("register mfa failure error: %v", err)
+	}
+}
+
+func (v *totpVerifier) resetFailures(ctx context.Context, userID uuid.UUID) {
+	if err := v.repo.ResetLoginFailures(ctx, mfaFailuresKey(userID)); err != nil {
+		logger.Errorf("reset mfa failures error: %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	context "context"
 	reflect "reflect"
 	models "service-auth/internal/app/models"
+	utils "service-auth/internal/app/utils"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -36,6 +38,20 @@ func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockAuthService) ChangePassword(ctx context.Context, userID uuid.UUID, sessionID string, input models.ChangePasswordInput, client models.ClientInfo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, sessionID, input, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthServiceMockRecorder) ChangePassword(ctx, userID, sessionID, input, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthService)(nil).ChangePassword), ctx, userID, sessionID, input, client)
+}
+
 // CreateUser mocks base method.
 func (m *MockAuthService) CreateUser(ctx context.Context, user models.UserInput) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -51,34 +67,191 @@ func (mr *MockAuthServiceMockRecorder) CreateUser(ctx, user interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthService)(nil).CreateUser), ctx, user)
 }
 
+// DeleteAccount mocks base method.
+func (m *MockAuthService) DeleteAccount(ctx context.Context, userID uuid.UUID, input models.DeleteAccountInput, client models.ClientInfo) (models.AccountDeletion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, userID, input, client)
+	ret0, _ := ret[0].(models.AccountDeletion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockAuthServiceMockRecorder) DeleteAccount(ctx, userID, input, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAuthService)(nil).DeleteAccount), ctx, userID, input, client)
+}
+
+// ForgotPassword mocks base method.
+func (m *MockAuthService) ForgotPassword(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockAuthServiceMockRecorder) ForgotPassword(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockAuthService)(nil).ForgotPassword), ctx, email)
+}
+
 // GenerateTokens mocks base method.
-func (m *MockAuthService) GenerateTokens(ctx context.Context, username, password string) (models.Tokens, error) {
+func (m *MockAuthService) GenerateTokens(ctx context.Context, username, password string, client models.ClientInfo) (models.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateTokens", ctx, username, password)
+	ret := m.ctrl.Call(m, "GenerateTokens", ctx, username, password, client)
 	ret0, _ := ret[0].(models.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateTokens indicates an expected call of GenerateTokens.
-func (mr *MockAuthServiceMockRecorder) GenerateTokens(ctx, username, password interface{}) *gomock.Call {
+func (mr *MockAuthServiceMockRecorder) GenerateTokens(ctx, username, password, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTokens", reflect.TypeOf((*MockAuthService)(nil).GenerateTokens), ctx, username, password, client)
+}
+
+// GetSessions mocks base method.
+func (m *MockAuthService) GetSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, userID, currentSessionID)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockAuthServiceMockRecorder) GetSessions(ctx, userID, currentSessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockAuthService)(nil).GetSessions), ctx, userID, currentSessionID)
+}
+
+// Introspect mocks base method.
+func (m *MockAuthService) Introspect(ctx context.Context, token string) (models.IntrospectionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect", ctx, token)
+	ret0, _ := ret[0].(models.IntrospectionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockAuthServiceMockRecorder) Introspect(ctx, token interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTokens", reflect.TypeOf((*MockAuthService)(nil).GenerateTokens), ctx, username, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockAuthService)(nil).Introspect), ctx, token)
+}
+
+// PurgeDeletedAccounts mocks base method.
+func (m *MockAuthService) PurgeDeletedAccounts(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedAccounts", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeDeletedAccounts indicates an expected call of PurgeDeletedAccounts.
+func (mr *MockAuthServiceMockRecorder) PurgeDeletedAccounts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedAccounts", reflect.TypeOf((*MockAuthService)(nil).PurgeDeletedAccounts), ctx)
 }
 
 // RefreshTokens mocks base method.
-func (m *MockAuthService) RefreshTokens(ctx context.Context, oldRefreshToken string) (models.Tokens, error) {
+func (m *MockAuthService) RefreshTokens(ctx context.Context, oldRefreshToken string, client models.ClientInfo) (models.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RefreshTokens", ctx, oldRefreshToken)
+	ret := m.ctrl.Call(m, "RefreshTokens", ctx, oldRefreshToken, client)
 	ret0, _ := ret[0].(models.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // RefreshTokens indicates an expected call of RefreshTokens.
-func (mr *MockAuthServiceMockRecorder) RefreshTokens(ctx, oldRefreshToken interface{}) *gomock.Call {
+func (mr *MockAuthServiceMockRecorder) RefreshTokens(ctx, oldRefreshToken, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockAuthService)(nil).RefreshTokens), ctx, oldRefreshToken, client)
+}
+
+// ResendVerification mocks base method.
+func (m *MockAuthService) ResendVerification(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockAuthServiceMockRecorder) ResendVerification(ctx, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockAuthService)(nil).RefreshTokens), ctx, oldRefreshToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockAuthService)(nil).ResendVerification), ctx, email)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAuthService) ResetPassword(ctx context.Context, token, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, token, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAuthServiceMockRecorder) ResetPassword(ctx, token, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAuthService)(nil).ResetPassword), ctx, token, password)
+}
+
+// RestoreAccount mocks base method.
+func (m *MockAuthService) RestoreAccount(ctx context.Context, username, password string, client models.ClientInfo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreAccount", ctx, username, password, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreAccount indicates an expected call of RestoreAccount.
+func (mr *MockAuthServiceMockRecorder) RestoreAccount(ctx, username, password, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreAccount", reflect.TypeOf((*MockAuthService)(nil).RestoreAccount), ctx, username, password, client)
+}
+
+// RevokeAccessToken mocks base method.
+func (m *MockAuthService) RevokeAccessToken(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccessToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAccessToken indicates an expected call of RevokeAccessToken.
+func (mr *MockAuthServiceMockRecorder) RevokeAccessToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccessToken", reflect.TypeOf((*MockAuthService)(nil).RevokeAccessToken), ctx, token)
+}
+
+// RevokeAllForUser mocks base method.
+func (m *MockAuthService) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllForUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAllForUser indicates an expected call of RevokeAllForUser.
+func (mr *MockAuthServiceMockRecorder) RevokeAllForUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllForUser", reflect.TypeOf((*MockAuthService)(nil).RevokeAllForUser), ctx, userID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAuthService) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthServiceMockRecorder) RevokeSession(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuthService)(nil).RevokeSession), ctx, userID, sessionID)
 }
 
 // RevokeToken mocks base method.
@@ -94,3 +267,469 @@ func (mr *MockAuthServiceMockRecorder) RevokeToken(ctx, token interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockAuthService)(nil).RevokeToken), ctx, token)
 }
+
+// RunAccountPurge mocks base method.
+func (m *MockAuthService) RunAccountPurge(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunAccountPurge", ctx, interval)
+}
+
+// RunAccountPurge indicates an expected call of RunAccountPurge.
+func (mr *MockAuthServiceMockRecorder) RunAccountPurge(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunAccountPurge", reflect.TypeOf((*MockAuthService)(nil).RunAccountPurge), ctx, interval)
+}
+
+// ValidateAccessToken mocks base method.
+func (m *MockAuthService) ValidateAccessToken(ctx context.Context, token string) (*utils.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateAccessToken", ctx, token)
+	ret0, _ := ret[0].(*utils.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateAccessToken indicates an expected call of ValidateAccessToken.
+func (mr *MockAuthServiceMockRecorder) ValidateAccessToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateAccessToken", reflect.TypeOf((*MockAuthService)(nil).ValidateAccessToken), ctx, token)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAuthService) VerifyEmail(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthServiceMockRecorder) VerifyEmail(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuthService)(nil).VerifyEmail), ctx, token)
+}
+
+// VerifyMFA mocks base method.
+func (m *MockAuthService) VerifyMFA(ctx context.Context, mfaToken, code string, client models.ClientInfo) (models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFA", ctx, mfaToken, code, client)
+	ret0, _ := ret[0].(models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockAuthServiceMockRecorder) VerifyMFA(ctx, mfaToken, code, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockAuthService)(nil).VerifyMFA), ctx, mfaToken, code, client)
+}
+
+// MockKeysService is a mock of KeysService interface.
+type MockKeysService struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeysServiceMockRecorder
+}
+
+// MockKeysServiceMockRecorder is the mock recorder for MockKeysService.
+type MockKeysServiceMockRecorder struct {
+	mock *MockKeysService
+}
+
+// NewMockKeysService creates a new mock instance.
+func NewMockKeysService(ctrl *gomock.Controller) *MockKeysService {
+	mock := &MockKeysService{ctrl: ctrl}
+	mock.recorder = &MockKeysServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeysService) EXPECT() *MockKeysServiceMockRecorder {
+	return m.recorder
+}
+
+// JWKS mocks base method.
+func (m *MockKeysService) JWKS() utils.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(utils.JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockKeysServiceMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockKeysService)(nil).JWKS))
+}
+
+// RotateKeys mocks base method.
+func (m *MockKeysService) RotateKeys(kid string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateKeys", kid)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateKeys indicates an expected call of RotateKeys.
+func (mr *MockKeysServiceMockRecorder) RotateKeys(kid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateKeys", reflect.TypeOf((*MockKeysService)(nil).RotateKeys), kid)
+}
+
+// MockUserService is a mock of UserService interface.
+type MockUserService struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserServiceMockRecorder
+}
+
+// MockUserServiceMockRecorder is the mock recorder for MockUserService.
+type MockUserServiceMockRecorder struct {
+	mock *MockUserService
+}
+
+// NewMockUserService creates a new mock instance.
+func NewMockUserService(ctrl *gomock.Controller) *MockUserService {
+	mock := &MockUserService{ctrl: ctrl}
+	mock.recorder = &MockUserServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
+	return m.recorder
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockUserService) ConfirmEmailChange(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockUserServiceMockRecorder) ConfirmEmailChange(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockUserService)(nil).ConfirmEmailChange), ctx, token)
+}
+
+// GetProfile mocks base method.
+func (m *MockUserService) GetProfile(ctx context.Context, id uuid.UUID) (models.UserProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", ctx, id)
+	ret0, _ := ret[0].(models.UserProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockUserServiceMockRecorder) GetProfile(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockUserService)(nil).GetProfile), ctx, id)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUserService) UpdateProfile(ctx context.Context, id uuid.UUID, input models.UpdateProfileInput, client models.ClientInfo) (models.UserProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, id, input, client)
+	ret0, _ := ret[0].(models.UserProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserServiceMockRecorder) UpdateProfile(ctx, id, input, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserService)(nil).UpdateProfile), ctx, id, input, client)
+}
+
+// MockMFAService is a mock of MFAService interface.
+type MockMFAService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAServiceMockRecorder
+}
+
+// MockMFAServiceMockRecorder is the mock recorder for MockMFAService.
+type MockMFAServiceMockRecorder struct {
+	mock *MockMFAService
+}
+
+// NewMockMFAService creates a new mock instance.
+func NewMockMFAService(ctrl *gomock.Controller) *MockMFAService {
+	mock := &MockMFAService{ctrl: ctrl}
+	mock.recorder = &MockMFAServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFAService) EXPECT() *MockMFAServiceMockRecorder {
+	return m.recorder
+}
+
+// ConfirmRequiredTOTP mocks base method.
+func (m *MockMFAService) ConfirmRequiredTOTP(ctx context.Context, mfaToken, code string) (models.RecoveryCodes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmRequiredTOTP", ctx, mfaToken, code)
+	ret0, _ := ret[0].(models.RecoveryCodes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmRequiredTOTP indicates an expected call of ConfirmRequiredTOTP.
+func (mr *MockMFAServiceMockRecorder) ConfirmRequiredTOTP(ctx, mfaToken, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmRequiredTOTP", reflect.TypeOf((*MockMFAService)(nil).ConfirmRequiredTOTP), ctx, mfaToken, code)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockMFAService) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) (models.RecoveryCodes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", ctx, userID, code)
+	ret0, _ := ret[0].(models.RecoveryCodes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockMFAServiceMockRecorder) ConfirmTOTP(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockMFAService)(nil).ConfirmTOTP), ctx, userID, code)
+}
+
+// EnrollRequiredTOTP mocks base method.
+func (m *MockMFAService) EnrollRequiredTOTP(ctx context.Context, mfaToken string) (models.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollRequiredTOTP", ctx, mfaToken)
+	ret0, _ := ret[0].(models.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollRequiredTOTP indicates an expected call of EnrollRequiredTOTP.
+func (mr *MockMFAServiceMockRecorder) EnrollRequiredTOTP(ctx, mfaToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollRequiredTOTP", reflect.TypeOf((*MockMFAService)(nil).EnrollRequiredTOTP), ctx, mfaToken)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockMFAService) EnrollTOTP(ctx context.Context, userID uuid.UUID) (models.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", ctx, userID)
+	ret0, _ := ret[0].(models.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockMFAServiceMockRecorder) EnrollTOTP(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockMFAService)(nil).EnrollTOTP), ctx, userID)
+}
+
+// RecoveryCodesStatus mocks base method.
+func (m *MockMFAService) RecoveryCodesStatus(ctx context.Context, userID uuid.UUID) (models.RecoveryCodesStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoveryCodesStatus", ctx, userID)
+	ret0, _ := ret[0].(models.RecoveryCodesStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoveryCodesStatus indicates an expected call of RecoveryCodesStatus.
+func (mr *MockMFAServiceMockRecorder) RecoveryCodesStatus(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryCodesStatus", reflect.TypeOf((*MockMFAService)(nil).RecoveryCodesStatus), ctx, userID)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockMFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) (models.RecoveryCodes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", ctx, userID)
+	ret0, _ := ret[0].(models.RecoveryCodes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockMFAServiceMockRecorder) RegenerateRecoveryCodes(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockMFAService)(nil).RegenerateRecoveryCodes), ctx, userID)
+}
+
+// MockWebAuthnService is a mock of WebAuthnService interface.
+type MockWebAuthnService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnServiceMockRecorder
+}
+
+// MockWebAuthnServiceMockRecorder is the mock recorder for MockWebAuthnService.
+type MockWebAuthnServiceMockRecorder struct {
+	mock *MockWebAuthnService
+}
+
+// NewMockWebAuthnService creates a new mock instance.
+func NewMockWebAuthnService(ctrl *gomock.Controller) *MockWebAuthnService {
+	mock := &MockWebAuthnService{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnService) EXPECT() *MockWebAuthnServiceMockRecorder {
+	return m.recorder
+}
+
+// BeginLogin mocks base method.
+func (m *MockWebAuthnService) BeginLogin(ctx context.Context, username string) (models.WebAuthnRequestOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginLogin", ctx, username)
+	ret0, _ := ret[0].(models.WebAuthnRequestOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginLogin indicates an expected call of BeginLogin.
+func (mr *MockWebAuthnServiceMockRecorder) BeginLogin(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginLogin", reflect.TypeOf((*MockWebAuthnService)(nil).BeginLogin), ctx, username)
+}
+
+// BeginRegistration mocks base method.
+func (m *MockWebAuthnService) BeginRegistration(ctx context.Context, userID uuid.UUID) (models.WebAuthnCreationOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginRegistration", ctx, userID)
+	ret0, _ := ret[0].(models.WebAuthnCreationOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginRegistration indicates an expected call of BeginRegistration.
+func (mr *MockWebAuthnServiceMockRecorder) BeginRegistration(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginRegistration", reflect.TypeOf((*MockWebAuthnService)(nil).BeginRegistration), ctx, userID)
+}
+
+// FinishLogin mocks base method.
+func (m *MockWebAuthnService) FinishLogin(ctx context.Context, input models.WebAuthnLoginInput, client models.ClientInfo) (models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishLogin", ctx, input, client)
+	ret0, _ := ret[0].(models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishLogin indicates an expected call of FinishLogin.
+func (mr *MockWebAuthnServiceMockRecorder) FinishLogin(ctx, input, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishLogin", reflect.TypeOf((*MockWebAuthnService)(nil).FinishLogin), ctx, input, client)
+}
+
+// FinishRegistration mocks base method.
+func (m *MockWebAuthnService) FinishRegistration(ctx context.Context, userID uuid.UUID, input models.WebAuthnRegistrationInput) (models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishRegistration", ctx, userID, input)
+	ret0, _ := ret[0].(models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishRegistration indicates an expected call of FinishRegistration.
+func (mr *MockWebAuthnServiceMockRecorder) FinishRegistration(ctx, userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishRegistration", reflect.TypeOf((*MockWebAuthnService)(nil).FinishRegistration), ctx, userID, input)
+}
+
+// MockExportService is a mock of ExportService interface.
+type MockExportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportServiceMockRecorder
+}
+
+// MockExportServiceMockRecorder is the mock recorder for MockExportService.
+type MockExportServiceMockRecorder struct {
+	mock *MockExportService
+}
+
+// NewMockExportService creates a new mock instance.
+func NewMockExportService(ctrl *gomock.Controller) *MockExportService {
+	mock := &MockExportService{ctrl: ctrl}
+	mock.recorder = &MockExportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportService) EXPECT() *MockExportServiceMockRecorder {
+	return m.recorder
+}
+
+// DownloadExport mocks base method.
+func (m *MockExportService) DownloadExport(ctx context.Context, token string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadExport", ctx, token)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadExport indicates an expected call of DownloadExport.
+func (mr *MockExportServiceMockRecorder) DownloadExport(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadExport", reflect.TypeOf((*MockExportService)(nil).DownloadExport), ctx, token)
+}
+
+// GetExport mocks base method.
+func (m *MockExportService) GetExport(ctx context.Context, userID uuid.UUID, exportID string) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExport", ctx, userID, exportID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExport indicates an expected call of GetExport.
+func (mr *MockExportServiceMockRecorder) GetExport(ctx, userID, exportID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExport", reflect.TypeOf((*MockExportService)(nil).GetExport), ctx, userID, exportID)
+}
+
+// RequestExport mocks base method.
+func (m *MockExportService) RequestExport(ctx context.Context, userID uuid.UUID) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestExport", ctx, userID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestExport indicates an expected call of RequestExport.
+func (mr *MockExportServiceMockRecorder) RequestExport(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestExport", reflect.TypeOf((*MockExportService)(nil).RequestExport), ctx, userID)
+}
+
+// RequestExportByAdmin mocks base method.
+func (m *MockExportService) RequestExportByAdmin(ctx context.Context, userID uuid.UUID) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestExportByAdmin", ctx, userID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestExportByAdmin indicates an expected call of RequestExportByAdmin.
+func (mr *MockExportServiceMockRecorder) RequestExportByAdmin(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestExportByAdmin", reflect.TypeOf((*MockExportService)(nil).RequestExportByAdmin), ctx, userID)
+}
+
+// RunExports mocks base method.
+func (m *MockExportService) RunExports(ctx context.Context, workers int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunExports", ctx, workers)
+}
+
+// RunExports indicates an expected call of RunExports.
+func (mr *MockExportServiceMockRecorder) RunExports(ctx, workers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunExports", reflect.TypeOf((*MockExportService)(nil).RunExports), ctx, workers)
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
+	"service-auth/internal/app/mailer"
 	"service-auth/internal/app/models"
 	"service-auth/internal/app/repository"
 	"service-auth/internal/app/utils"
@@ -13,17 +15,81 @@ import (
 
 type AuthService interface {
 	CreateUser(ctx context.Context, user models.UserInput) (uuid.UUID, error)
-	GenerateTokens(ctx context.Context, username, password string) (models.Tokens, error)
-	RefreshTokens(ctx context.Context, oldRefreshToken string) (models.Tokens, error)
+	GenerateTokens(ctx context.Context, username, password string, client models.ClientInfo) (models.Tokens, error)
+	RefreshTokens(ctx context.Context, oldRefreshToken string, client models.ClientInfo) (models.Tokens, error)
 	RevokeToken(ctx context.Context, token string) error
+	RevokeAccessToken(ctx context.Context, token string) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+	GetSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error
+	ValidateAccessToken(ctx context.Context, token string) (*utils.Claims, error)
+	Introspect(ctx context.Context, token string) (models.IntrospectionResponse, error)
+	VerifyMFA(ctx context.Context, mfaToken, code string, client models.ClientInfo) (models.Tokens, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	ChangePassword(ctx context.Context, userID uuid.UUID, sessionID string, input models.ChangePasswordInput, client models.ClientInfo) error
+	DeleteAccount(ctx context.Context, userID uuid.UUID, input models.DeleteAccountInput, client models.ClientInfo) (models.AccountDeletion, error)
+	RestoreAccount(ctx context.Context, username, password string, client models.ClientInfo) error
+	PurgeDeletedAccounts(ctx context.Context) error
+	RunAccountPurge(ctx context.Context, interval time.Duration)
+}
+
+type KeysService interface {
+	JWKS() utils.JWKS
+	RotateKeys(kid string) (string, error)
+}
+
+type UserService interface {
+	GetProfile(ctx context.Context, id uuid.UUID) (models.UserProfile, error)
+	UpdateProfile(ctx context.Context, id uuid.UUID, input models.UpdateProfileInput, client models.ClientInfo) (models.UserProfile, error)
+	ConfirmEmailChange(ctx context.Context, token string) error
+}
+
+type MFAService interface {
+	EnrollTOTP(ctx context.Context, userID uuid.UUID) (models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) (models.RecoveryCodes, error)
+	EnrollRequiredTOTP(ctx context.Context, mfaToken string) (models.TOTPEnrollment, error)
+	ConfirmRequiredTOTP(ctx context.Context, mfaToken, code string) (models.RecoveryCodes, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) (models.RecoveryCodes, error)
+	RecoveryCodesStatus(ctx context.Context, userID uuid.UUID) (models.RecoveryCodesStatus, error)
+}
+
+type WebAuthnService interface {
+	BeginRegistration(ctx context.Context, userID uuid.UUID) (models.WebAuthnCreationOptions, error)
+	FinishRegistration(ctx context.Context, userID uuid.UUID, input models.WebAuthnRegistrationInput) (models.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context, username string) (models.WebAuthnRequestOptions, error)
+	FinishLogin(ctx context.Context, input models.WebAuthnLoginInput, client models.ClientInfo) (models.Tokens, error)
+}
+
+type ExportService interface {
+	RequestExport(ctx context.Context, userID uuid.UUID) (models.DataExport, error)
+	RequestExportByAdmin(ctx context.Context, userID uuid.UUID) (models.DataExport, error)
+	GetExport(ctx context.Context, userID uuid.UUID, exportID string) (models.DataExport, error)
+	DownloadExport(ctx context.Context, token string) ([]byte, error)
+	RunExports(ctx context.Context, workers int)
 }
 
 type Service struct {
 	AuthService
+	KeysService
+	UserService
+	MFAService
+	WebAuthnService
+	ExportService
 }
 
-func NewService(repo *repository.Repository, jwtManager *utils.JWTManager, cfg *configs.Config) Service {
+func NewService(repo *repository.Repository, jwtManager *utils.JWTManager, passwords *utils.PasswordManager,
+	policy *utils.PasswordPolicy, cipher *utils.SecretCipher,
+	mail mailer.Mailer, templates *mailer.Templates, cfg *configs.Config) Service {
+	auth := NewAuth(repo, jwtManager, passwords, policy, cipher, mail, templates, cfg)
 	return Service{
-		AuthService: NewAuth(repo, jwtManager, cfg),
+		AuthService:     auth,
+		KeysService:     NewKeys(jwtManager),
+		UserService:     NewUser(repo, auth, cfg),
+		MFAService:      NewMFA(repo, auth, cipher, cfg),
+		WebAuthnService: NewWebAuthn(repo, auth, cfg),
+		ExportService:   NewExport(repo, auth, cfg),
 	}
 }
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/mailer"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/repository"
+	"service-auth/internal/app/utils"
+	"service-auth/internal/configs"
+)
+
+type User struct {
+	repo *repository.Repository
+	auth *Auth
+	cfg  *configs.Config
+}
+
+func NewUser(repo *repository.Repository, auth *Auth, cfg *configs.Config) *User {
+	return &User{repo: repo, auth: auth, cfg: cfg}
+}
+
+// GetProfile возвращает профиль пользователя без хэша пароля
+func (s *User) GetProfile(ctx context.Context, id uuid.UUID) (models.UserProfile, error) {
+	user, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return models.UserProfile{}, err
+	}
+	return user.Profile(), nil
+}
+
+// UpdateProfile меняет поля профиля. Новый email сохраняется как ожидающий подтверждения:
+// на него отправляется ссылка, на текущий адрес - уведомление. Смена email, как и смена пароля,
+// подтверждается текущим паролем: иначе украденная сессия позволила бы перехватить учетную запись
+func (s *User) UpdateProfile(ctx context.Context, id uuid.UUID, input models.UpdateProfileInput,
+	client models.ClientInfo) (models.UserProfile, error) {
+	user, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return models.UserProfile{}, err
+	}
+
+	changeEmail := input.Email != nil && !strings.EqualFold(*input.Email, user.Email)
+	if changeEmail {
+		if err := s.checkPassword(ctx, user, input.CurrentPassword, client); err != nil {
+			return models.UserProfile{}, err
+		}
+	}
+
+	if input.Username != nil && *input.Username != user.Username {
+		if err := s.repo.UpdateUsername(ctx, id, *input.Username); err != nil {
+			return models.UserProfile{}, err
+		}
+	}
+
+	if changeEmail {
+		if err := s.requestEmailChange(ctx, user, *input.Email); err != nil {
+			return models.UserProfile{}, err
+		}
+	}
+
+	return s.GetProfile(ctx, id)
+}
+
+// checkPassword проверяет текущий пароль пользователя с учетом лимитов входа
+func (s *User) checkPassword(ctx context.Context, user models.GetUserResponse, password string, client models.ClientInfo) error {
+	if password == "" {
+		return errs.ErrInvalidPwd
+	}
+	if err := s.auth.checkLoginAllowed(ctx, user.Username, client.IP); err != nil {
+		return err
+	}
+	return s.auth.verifyPassword(ctx, user, password, client)
+}
+
+// requestEmailChange сохраняет новый email и отправляет письма. Смена адреса запрашивается
+// не чаще email.resend_interval: интервал занимается до сохранения адреса, поэтому его расходует
+// и отклоненный (уже занятый) адрес, а запрос в пределах интервала не меняет ожидающий email
+func (s *User) requestEmailChange(ctx context.Context, user models.GetUserResponse, email string) error {
+	ok, err := s.repo.AcquireCooldown(ctx, "email_change:"+user.ID.String(), s.cfg.Email.ResendInterval)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.WithRetryAfter(errs.ErrTooManyAttempts, s.cfg.Email.ResendInterval)
+	}
+
+	if err := s.repo.SetPendingEmail(ctx, user.ID, email); err != nil {
+		return err
+	}
+
+	ttl := s.cfg.Email.VerificationTTL
+	token, err := s.auth.jwtManager.GenerateEmailChangeToken(user.Username, user.Role, user.ID, email, ttl)
+	if err != nil {
+		return err
+	}
+	link, err := linkWithToken(s.cfg.Email.ChangeURL, token)
+	if err != nil {
+		return err
+	}
+
+	data := mailData{
+		Username:  user.Username,
+		Link:      link,
+		ExpiresAt: time.Now().Add(ttl),
+		NewEmail:  email,
+	}
+	s.auth.sendMail(ctx, email, mailer.TemplateEmailChange, data)
+	s.auth.sendMail(ctx, user.Email, mailer.TemplateEmailChangeNotice, data)
+	return nil
+}
+
+// ConfirmEmailChange делает новый email основным по токену из письма
+func (s *User) ConfirmEmailChange(ctx context.Context, token string) error {
+	claims, err := s.auth.jwtManager.DecodeJWT(token)
+	if err != nil || claims.TokenType != utils.EmailChangeToken || claims.Email == "" {
+		return errs.ErrEmailTokenInvalid
+	}
+	// ссылка, выданная до смены пароля или выхода со всех устройств, больше не действует
+	if err := s.auth.checkRevoked(ctx, claims); err != nil {
+		return errs.ErrEmailTokenInvalid
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return errs.ErrEmailTokenInvalid
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkAccountActive(user); err != nil {
+		return err
+	}
+
+	changed, err := s.repo.ConfirmEmailChange(ctx, userID, claims.Email)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return errs.ErrEmailTokenInvalid
+	}
+
+	recordSecurityEvent(ctx, s.repo, userID, EventEmailChanged, logger.Fields{
+		"user_id": userID.String(),
+	})
+
+	// ссылка одноразовая
+	if err := s.auth.denyToken(ctx, claims); err != nil {
+		logger.Errorf("deny email change token error: %v", err)
+	}
+	return nil
+}
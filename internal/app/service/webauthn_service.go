@@ -0,0 +1,377 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/repository"
+	"service-auth/internal/app/utils"
+	"service-auth/internal/configs"
+)
+
+const (
+	webauthnCredentialType = "public-key"
+	webauthnChallengeSize  = 32
+	userVerificationReq    = "required"
+)
+
+type WebAuthn struct {
+	repo *repository.Repository
+	auth *Auth // выдача токенов после входа
+	cfg  *configs.Config
+}
+
+func NewWebAuthn(repo *repository.Repository, auth *Auth, cfg *configs.Config) *WebAuthn {
+	return &WebAuthn{repo: repo, auth: auth, cfg: cfg}
+}
+
+// BeginRegistration выдает параметры navigator.credentials.create для нового ключа доступа
+func (s *WebAuthn) BeginRegistration(ctx context.Context, userID uuid.UUID) (models.WebAuthnCreationOptions, error) {
+	if s.cfg.WebAuthn.RPID == "" {
+		return models.WebAuthnCreationOptions{}, errs.ErrWebAuthnNotConfigured
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.WebAuthnCreationOptions{}, err
+	}
+	creds, err := s.repo.GetWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return models.WebAuthnCreationOptions{}, err
+	}
+
+	challenge, err := s.newChallenge(ctx, models.WebAuthnChallenge{Ceremony: utils.WebAuthnTypeCreate, UserID: userID})
+	if err != nil {
+		return models.WebAuthnCreationOptions{}, err
+	}
+
+	return models.WebAuthnCreationOptions{
+		Challenge: challenge,
+		RP:        models.WebAuthnRelyingParty{ID: s.cfg.WebAuthn.RPID, Name: s.cfg.WebAuthn.RPName},
+		User: models.WebAuthnUser{
+			ID:          utils.EncodeBase64URL(userID[:]),
+			Name:        user.Username,
+			DisplayName: user.Username,
+		},
+		PubKeyCredParams: []models.WebAuthnCredentialParameter{
+			{Type: webauthnCredentialType, Alg: utils.COSEAlgES256},
+			{Type: webauthnCredentialType, Alg: utils.COSEAlgEdDSA},
+			{Type: webauthnCredentialType, Alg: utils.COSEAlgRS256},
+		},
+		Timeout:            s.cfg.WebAuthn.Timeout.Milliseconds(),
+		Attestation:        "none",
+		ExcludeCredentials: credentialDescriptors(creds),
+		AuthenticatorSelection: models.WebAuthnAuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: s.cfg.WebAuthn.UserVerification,
+		},
+	}, nil
+}
+
+// FinishRegistration проверяет ответ аутентификатора и сохраняет ключ доступа
+func (s *WebAuthn) FinishRegistration(ctx context.Context, userID uuid.UUID, input models.WebAuthnRegistrationInput) (models.WebAuthnCredential, error) {
+	if s.cfg.WebAuthn.RPID == "" {
+		return models.WebAuthnCredential{}, errs.ErrWebAuthnNotConfigured
+	}
+	if input.Type != webauthnCredentialType {
+		return models.WebAuthnCredential{}, errs.ErrWebAuthnInvalidResponse
+	}
+
+	clientDataJSON, err := utils.DecodeBase64URL(input.Response.ClientDataJSON)
+	if err != nil {
+		return models.WebAuthnCredential{}, errs.ErrWebAuthnInvalidResponse
+	}
+	challenge, err := s.verifyClientData(ctx, clientDataJSON, utils.WebAuthnTypeCreate)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	if challenge.UserID != userID {
+		return models.WebAuthnCredential{}, errs.ErrWebAuthnChallengeInvalid
+	}
+
+	attestationObject, err := utils.DecodeBase64URL(input.Response.AttestationObject)
+	if err != nil {
+		return models.WebAuthnCredential{}, errs.ErrWebAuthnInvalidResponse
+	}
+	format, rawAuthData, err := utils.ParseAttestationObject(attestationObject)
+	if err != nil {
+		return models.WebAuthnCredential{}, fmt.Errorf("%w: %v", errs.ErrWebAuthnInvalidResponse, err)
+	}
+	// запрашивается аттестация none: сервис не доверяет заявлениям производителя
+	if format != "none" {
+		return models.WebAuthnCredential{}, fmt.Errorf("%w: unsupported attestation format %q", errs.ErrWebAuthnInvalidResponse, format)
+	}
+
+	authData, err := s.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	if !authData.HasFlag(utils.AuthFlagAttestedCredData) {
+		return models.WebAuthnCredential{}, fmt.Errorf("%w: no attested credential data", errs.ErrWebAuthnInvalidResponse)
+	}
+
+	rawID, err := utils.DecodeBase64URL(input.RawID)
+	if err != nil || !bytes.Equal(rawID, authData.CredentialID) {
+		return models.WebAuthnCredential{}, fmt.Errorf("%w: credential id mismatch", errs.ErrWebAuthnInvalidResponse)
+	}
+	if _, _, err := utils.ParseCOSEKey(authData.CredentialPublicKey); err != nil {
+		return models.WebAuthnCredential{}, fmt.Errorf("%w: %v", errs.ErrWebAuthnInvalidResponse, err)
+	}
+
+	cred := models.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: authData.CredentialID,
+		PublicKey:    authData.CredentialPublicKey,
+		SignCount:    authData.SignCount,
+		Name:         input.Name,
+	}
+	if cred.ID, err = s.repo.CreateWebAuthnCredential(ctx, cred); err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	return cred, nil
+}
+
+// BeginLogin выдает параметры navigator.credentials.get. Без username - вход discoverable ключом.
+// Для неизвестного username ответ не отличается от ответа для пользователя без ключей
+func (s *WebAuthn) BeginLogin(ctx context.Context, username string) (models.WebAuthnRequestOptions, error) {
+	if s.cfg.WebAuthn.RPID == "" {
+		return models.WebAuthnRequestOptions{}, errs.ErrWebAuthnNotConfigured
+	}
+
+	data := models.WebAuthnChallenge{Ceremony: utils.WebAuthnTypeGet}
+	allowCredentials := []models.WebAuthnCredentialDescriptor{}
+	if username != "" {
+		user, err := s.repo.GetUser(ctx, username)
+		switch {
+		case err == nil:
+			creds, err := s.repo.GetWebAuthnCredentials(ctx, user.ID)
+			if err != nil {
+				return models.WebAuthnRequestOptions{}, err
+			}
+			data.UserID = user.ID
+			allowCredentials = credentialDescriptors(creds)
+		case !errors.Is(err, errs.ErrUserNotFound):
+			return models.WebAuthnRequestOptions{}, err
+		}
+	}
+
+	challenge, err := s.newChallenge(ctx, data)
+	if err != nil {
+		return models.WebAuthnRequestOptions{}, err
+	}
+
+	return models.WebAuthnRequestOptions{
+		Challenge:        challenge,
+		RPID:             s.cfg.WebAuthn.RPID,
+		Timeout:          s.cfg.WebAuthn.Timeout.Milliseconds(),
+		UserVerification: s.cfg.WebAuthn.UserVerification,
+		AllowCredentials: allowCredentials,
+	}, nil
+}
+
+// FinishLogin проверяет подпись аутентификатора и выдает те же токены, что и вход по паролю.
+// Неудачные проверки учитываются в лимите неудачных входов с IP
+func (s *WebAuthn) FinishLogin(ctx context.Context, input models.WebAuthnLoginInput, client models.ClientInfo) (models.Tokens, error) {
+	if s.cfg.WebAuthn.RPID == "" {
+		return models.Tokens{}, errs.ErrWebAuthnNotConfigured
+	}
+	// до проверки подписи пользователь неизвестен, поэтому ограничивается только IP
+	if err := s.auth.checkLoginAllowed(ctx, "", client.IP); err != nil {
+		return models.Tokens{}, err
+	}
+
+	tokens, err := s.finishLogin(ctx, input, client)
+	if isWebAuthnFailure(err) {
+		s.auth.registerLoginFailure(ctx, "", client.IP)
+	}
+	return tokens, err
+}
+
+// finishLogin проверяет ответ аутентификатора и определяет, нужен ли еще второй фактор
+func (s *WebAuthn) finishLogin(ctx context.Context, input models.WebAuthnLoginInput, client models.ClientInfo) (models.Tokens, error) {
+	if input.Type != webauthnCredentialType {
+		return models.Tokens{}, errs.ErrWebAuthnInvalidResponse
+	}
+
+	clientDataJSON, err := utils.DecodeBase64URL(input.Response.ClientDataJSON)
+	if err != nil {
+		return models.Tokens{}, errs.ErrWebAuthnInvalidResponse
+	}
+	challenge, err := s.verifyClientData(ctx, clientDataJSON, utils.WebAuthnTypeGet)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	rawID, err := utils.DecodeBase64URL(input.RawID)
+	if err != nil {
+		return models.Tokens{}, errs.ErrWebAuthnInvalidResponse
+	}
+	cred, err := s.repo.GetWebAuthnCredential(ctx, rawID)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	// челлендж, выданный под конкретного пользователя, не принимается для чужого ключа
+	if challenge.UserID != uuid.Nil && challenge.UserID != cred.UserID {
+		return models.Tokens{}, errs.ErrWebAuthnVerification
+	}
+	if input.Response.UserHandle != "" {
+		userHandle, err := utils.DecodeBase64URL(input.Response.UserHandle)
+		if err != nil || !bytes.Equal(userHandle, cred.UserID[:]) {
+			return models.Tokens{}, errs.ErrWebAuthnVerification
+		}
+	}
+
+	rawAuthData, err := utils.DecodeBase64URL(input.Response.AuthenticatorData)
+	if err != nil {
+		return models.Tokens{}, errs.ErrWebAuthnInvalidResponse
+	}
+	authData, err := s.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	signature, err := utils.DecodeBase64URL(input.Response.Signature)
+	if err != nil {
+		return models.Tokens{}, errs.ErrWebAuthnInvalidResponse
+	}
+	if err := utils.VerifyAssertionSignature(cred.PublicKey, rawAuthData, clientDataJSON, signature); err != nil {
+		logger.Debugf("webauthn signature verification failed: %v", err)
+		return models.Tokens{}, errs.ErrWebAuthnVerification
+	}
+
+	if err := s.updateSignCount(ctx, cred, authData.SignCount); err != nil {
+		return models.Tokens{}, err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, cred.UserID)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+	if err := checkAccountLock(user); err != nil {
+		return models.Tokens{}, err
+	}
+	if err := checkAccountActive(user); err != nil {
+		return models.Tokens{}, err
+	}
+	if err := s.auth.checkEmailVerified(user); err != nil {
+		return models.Tokens{}, err
+	}
+	if !user.MFAEnabled && s.cfg.MFA.Required(user.Role) {
+		return s.auth.mfaEnrollChallenge(user)
+	}
+	// ключ с проверкой пользователя (PIN, биометрия) - уже два фактора. Без флага UV ключ
+	// подтверждает только владение устройством и заменяет пароль, но не второй фактор
+	if user.MFAEnabled && !authData.HasFlag(utils.AuthFlagUserVerified) {
+		return s.auth.mfaChallenge(user)
+	}
+
+	return s.auth.issueTokens(ctx, user, client)
+}
+
+// isWebAuthnFailure сообщает, что ответ аутентификатора не прошел проверку
+func isWebAuthnFailure(err error) bool {
+	return errors.Is(err, errs.ErrWebAuthnChallengeInvalid) ||
+		errors.Is(err, errs.ErrWebAuthnInvalidResponse) ||
+		errors.Is(err, errs.ErrWebAuthnVerification) ||
+		errors.Is(err, errs.ErrWebAuthnCredentialNotFound)
+}
+
+// updateSignCount проверяет, что счетчик подписей растет. Откат счетчика означает клон аутентификатора.
+// Аутентификаторы без счетчика (всегда 0) допускаются
+func (s *WebAuthn) updateSignCount(ctx context.Context, cred models.WebAuthnCredential, signCount uint32) error {
+	if (signCount != 0 || cred.SignCount != 0) && signCount <= cred.SignCount {
+		recordSecurityEvent(ctx, s.repo, cred.UserID, EventWebAuthnCounterRegression, logger.Fields{
+			"user_id":         cred.UserID.String(),
+			"credential":      cred.ID.String(),
+			"stored_count":    cred.SignCount,
+			"presented_count": signCount,
+		})
+		return errs.ErrWebAuthnVerification
+	}
+
+	updated, err := s.repo.UpdateWebAuthnSignCount(ctx, cred.ID, cred.SignCount, signCount)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errs.ErrWebAuthnVerification
+	}
+	return nil
+}
+
+// newChallenge создает и сохраняет челлендж церемонии
+func (s *WebAuthn) newChallenge(ctx context.Context, data models.WebAuthnChallenge) (string, error) {
+	raw := make([]byte, webauthnChallengeSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("error generating webauthn challenge: %w", err)
+	}
+	challenge := utils.EncodeBase64URL(raw)
+
+	if err := s.repo.SaveWebAuthnChallenge(ctx, challenge, data, s.cfg.WebAuthn.Timeout); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// verifyClientData проверяет clientDataJSON и погашает челлендж церемонии ceremony
+func (s *WebAuthn) verifyClientData(ctx context.Context, raw []byte, ceremony string) (models.WebAuthnChallenge, error) {
+	clientData, err := utils.ParseClientData(raw)
+	if err != nil {
+		return models.WebAuthnChallenge{}, fmt.Errorf("%w: %v", errs.ErrWebAuthnInvalidResponse, err)
+	}
+	if clientData.Type != ceremony || clientData.Challenge == "" {
+		return models.WebAuthnChallenge{}, errs.ErrWebAuthnInvalidResponse
+	}
+
+	challenge, err := s.repo.ConsumeWebAuthnChallenge(ctx, clientData.Challenge)
+	if err != nil {
+		return models.WebAuthnChallenge{}, err
+	}
+	if challenge.Ceremony != ceremony {
+		return models.WebAuthnChallenge{}, errs.ErrWebAuthnChallengeInvalid
+	}
+
+	if clientData.CrossOrigin || !slices.Contains(s.cfg.WebAuthn.Origins, clientData.Origin) {
+		return models.WebAuthnChallenge{}, fmt.Errorf("%w: origin %q is not allowed", errs.ErrWebAuthnInvalidResponse, clientData.Origin)
+	}
+	return challenge, nil
+}
+
+// verifyAuthenticatorData проверяет rpIdHash и флаги присутствия и проверки пользователя
+func (s *WebAuthn) verifyAuthenticatorData(raw []byte) (utils.AuthenticatorData, error) {
+	authData, err := utils.ParseAuthenticatorData(raw)
+	if err != nil {
+		return utils.AuthenticatorData{}, fmt.Errorf("%w: %v", errs.ErrWebAuthnInvalidResponse, err)
+	}
+	if !authData.VerifyRPIDHash(s.cfg.WebAuthn.RPID) {
+		return utils.AuthenticatorData{}, fmt.Errorf("%w: rp id hash mismatch", errs.ErrWebAuthnVerification)
+	}
+	if !authData.HasFlag(utils.AuthFlagUserPresent) {
+		return utils.AuthenticatorData{}, fmt.Errorf("%w: user not present", errs.ErrWebAuthnVerification)
+	}
+	if s.cfg.WebAuthn.UserVerification == userVerificationReq && !authData.HasFlag(utils.AuthFlagUserVerified) {
+		return utils.AuthenticatorData{}, fmt.Errorf("%w: user not verified", errs.ErrWebAuthnVerification)
+	}
+	return authData, nil
+}
+
+// credentialDescriptors описания ключей пользователя для excludeCredentials/allowCredentials
+func credentialDescriptors(creds []models.WebAuthnCredential) []models.WebAuthnCredentialDescriptor {
+	descriptors := make([]models.WebAuthnCredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		descriptors = append(descriptors, models.WebAuthnCredentialDescriptor{
+			Type: webauthnCredentialType,
+			ID:   utils.EncodeBase64URL(cred.CredentialID),
+		})
+	}
+	return descriptors
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Claims payload токенов сервиса
+type Claims struct {
+	jwt.RegisteredClaims
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	TokenType string `json:"token_type"`
+	FamilyID  string `json:"fid,omitempty"`   // семейство refresh токенов
+	SessionID string `json:"sid,omitempty"`   // сессия, в рамках которой выдан access токен
+	Email     string `json:"email,omitempty"` // подтверждаемый адрес (токен подтверждения email)
+	ExportID  string `json:"xid,omitempty"`   // выгрузка данных (токен ссылки на скачивание)
+	// iat с точностью до миллисекунд: отсечка токенов при смене пароля сравнивается с ним,
+	// и токен, выпущенный в ту же секунду до смены, не должен ее пережить
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
+}
+
+// UserID возвращает id пользователя из sub
+func (c *Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+// IssuedAtPrecise время выпуска из iat_ms, для токенов без него - из iat (начало секунды)
+func (c *Claims) IssuedAtPrecise() time.Time {
+	if c.IssuedAtMs != 0 {
+		return time.UnixMilli(c.IssuedAtMs)
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
+// newClaims заполняет общие claims токена
+func (j *JWTManager) newClaims(tokenType, username, role string, id uuid.UUID, ttl time.Duration) *Claims {
+	now := time.Now()
+
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   id.String(),
+			Issuer:    j.issuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Username:   username,
+		Role:       role,
+		TokenType:  tokenType,
+		IssuedAtMs: now.UnixMilli(),
+	}
+	if j.audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.audience}
+	}
+	return claims
+}
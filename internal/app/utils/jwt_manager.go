@@ -1,32 +1,52 @@
 package utils
 
 import (
-	"crypto/rsa"
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	logger "github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 
 	"service-auth/internal/app/errs"
 )
 
 const (
-	RefreshToken = "refresh"
+	AccessToken      = "access"
+	RefreshToken     = "refresh"
+	MFAToken         = "mfa"          // токен второго шага входа, обменивается на access и refresh после проверки кода
+	MFAEnrollToken   = "mfa_enroll"   // токен обязательной настройки MFA, без нее токены роли не выдаются
+	EmailToken       = "email_verify" // токен из письма подтверждения email
+	EmailChangeToken = "email_change" // токен подтверждения нового email
+	DataExportToken  = "data_export"  // токен ссылки на скачивание выгрузки данных
 )
 
-// JWTManager управляет генерацией токенов
+// JWTManager управляет генерацией токенов.
+// Хранит связку ключей: один активный ключ подписи и выведенные ключи,
+// которые используются только для проверки уже выданных токенов.
 type JWTManager struct {
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
+	mu        sync.RWMutex
+	keys      map[string]*signingKey
+	active    *signingKey
+	method    jwt.SigningMethod // алгоритм для новых ключей
+	keysDir   string            // директория связки, пусто - статический ключ без ротации
+	retention time.Duration     // сколько выведенный ключ остается доступен для проверки
+	issuer    string            // iss выдаваемых токенов, пусто - не проверяется
+	audience  string            // aud выдаваемых токенов, пусто - не проверяется
+	leeway    time.Duration     // допуск расхождения часов при проверке exp, nbf и iat
 }
 
-// NewJWTManager загружает RSA-ключи и создает JWT-менеджер
-func NewJWTManager(privateKeyPath, publicKeyPath string) (*JWTManager, error) {
+// NewJWTManager загружает пару ключей для алгоритма alg (RS256, ES256, EdDSA) и создает JWT-менеджер
+func NewJWTManager(alg, privateKeyPath, publicKeyPath string) (*JWTManager, error) {
+	method, err := SigningMethod(alg)
+	if err != nil {
+		return nil, err
+	}
+
 	// Загружаем приватный ключ
 	privateKeyData, err := os.ReadFile(privateKeyPath)
 	if err != nil {
@@ -34,7 +54,7 @@ func NewJWTManager(privateKeyPath, publicKeyPath string) (*JWTManager, error) {
 		return nil, fmt.Errorf("failed to read private key: %w", err)
 	}
 
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyData)
+	privateKey, err := parsePrivateKeyPEM(privateKeyData)
 	if err != nil {
 		logger.WithError(err).Error("failed to parse private key")
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
@@ -47,74 +67,142 @@ func NewJWTManager(privateKeyPath, publicKeyPath string) (*JWTManager, error) {
 		return nil, fmt.Errorf("failed to read public key: %w", err)
 	}
 
-	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyData)
+	publicKey, err := parsePublicKeyPEM(publicKeyData)
 	if err != nil {
 		logger.WithError(err).Error("failed to parse public key")
 		return nil, fmt.Errorf("failed to parse public key: %w", err)
 	}
 
+	key, err := newSigningKey(privateKey, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if key.method != method {
+		return nil, fmt.Errorf("key type does not match signing algorithm %s", method.Alg())
+	}
+
 	return &JWTManager{
-		privateKey: privateKey,
-		publicKey:  publicKey,
+		keys:   map[string]*signingKey{key.kid: key},
+		active: key,
+		method: method,
 	}, nil
 }
 
-func HashPassword(password string) (string, error) {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// NewJWTManagerFromDir создает JWT-менеджер со связкой ключей из директории.
+// Если в директории нет ключей, генерирует первый ключ для алгоритма alg.
+// Загруженные ключи сохраняют свой алгоритм, alg определяет только новые ключи.
+// retention - время, в течение которого выведенный ключ принимается при проверке
+// (должно быть не меньше самого долгого TTL токенов).
+func NewJWTManagerFromDir(alg, dir string, retention time.Duration) (*JWTManager, error) {
+	method, err := SigningMethod(alg)
 	if err != nil {
-		return "", fmt.Errorf("error hashing password: %w", err)
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		logger.WithError(err).Error("failed to create keys dir")
+		return nil, fmt.Errorf("failed to create keys dir: %w", err)
+	}
+
+	j := &JWTManager{method: method, keysDir: dir, retention: retention}
+	if err := j.Reload(); err != nil {
+		return nil, err
 	}
 
-	return string(hashed), nil
+	if j.active == nil {
+		if _, err := j.RotateKey(""); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
 }
 
-func CheckPasswordHash(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+// WithIssuer задает iss и aud для выдаваемых токенов; при проверке они обязательны
+func (j *JWTManager) WithIssuer(issuer, audience string) *JWTManager {
+	j.issuer = issuer
+	j.audience = audience
+	return j
 }
 
-// GenerateAccessToken создает токен доступа (подписан приватным ключом)
-func (j *JWTManager) GenerateAccessToken(username, role string, id uuid.UUID, accessTTL time.Duration) (string, error) {
-	claims := jwt.MapClaims{
-		"token_type": "access",
-		"sub":        id.String(),
-		"username":   username,
-		"role":       role,
-		"exp":        time.Now().Add(accessTTL).Unix(),
-		"iat":        time.Now().Unix(),
-	}
+// WithLeeway задает допуск расхождения часов с узлами, выпустившими токен
+func (j *JWTManager) WithLeeway(leeway time.Duration) *JWTManager {
+	j.leeway = leeway
+	return j
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	return token.SignedString(j.privateKey)
+// GenerateAccessToken создает токен доступа сессии sessionID (подписан приватным ключом)
+func (j *JWTManager) GenerateAccessToken(username, role string, id uuid.UUID, sessionID string, accessTTL time.Duration) (string, error) {
+	claims := j.newClaims(AccessToken, username, role, id, accessTTL)
+	claims.SessionID = sessionID
+	return j.sign(claims)
 }
 
-// GenerateRefreshToken создает refresh токен (подписан приватным ключом)
-func (j *JWTManager) GenerateRefreshToken(username, role string, id uuid.UUID, refreshTTL time.Duration) (string, error) {
-	claims := jwt.MapClaims{
-		"token_type": "refresh",
-		"sub":        id.String(),
-		"username":   username,
-		"role":       role,
-		"exp":        time.Now().Add(refreshTTL).Unix(),
-		"iat":        time.Now().Unix(),
-	}
+// GenerateRefreshToken создает refresh токен семейства familyID (подписан приватным ключом)
+func (j *JWTManager) GenerateRefreshToken(username, role string, id uuid.UUID, familyID string, refreshTTL time.Duration) (string, error) {
+	claims := j.newClaims(RefreshToken, username, role, id, refreshTTL)
+	claims.FamilyID = familyID
+	return j.sign(claims)
+}
+
+// GenerateMFAToken создает короткоживущий токен MFA-челленджа после проверки пароля
+func (j *JWTManager) GenerateMFAToken(username, role string, id uuid.UUID, mfaTTL time.Duration) (string, error) {
+	return j.sign(j.newClaims(MFAToken, username, role, id, mfaTTL))
+}
+
+// GenerateMFAEnrollToken создает короткоживущий токен обязательной настройки MFA после проверки пароля
+func (j *JWTManager) GenerateMFAEnrollToken(username, role string, id uuid.UUID, mfaTTL time.Duration) (string, error) {
+	return j.sign(j.newClaims(MFAEnrollToken, username, role, id, mfaTTL))
+}
+
+// GenerateEmailToken создает токен подтверждения адреса email пользователя
+func (j *JWTManager) GenerateEmailToken(username, role string, id uuid.UUID, email string, ttl time.Duration) (string, error) {
+	claims := j.newClaims(EmailToken, username, role, id, ttl)
+	claims.Email = email
+	return j.sign(claims)
+}
+
+// GenerateEmailChangeToken создает токен подтверждения нового адреса email
+func (j *JWTManager) GenerateEmailChangeToken(username, role string, id uuid.UUID, email string, ttl time.Duration) (string, error) {
+	claims := j.newClaims(EmailChangeToken, username, role, id, ttl)
+	claims.Email = email
+	return j.sign(claims)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	return token.SignedString(j.privateKey)
+// GenerateDataExportToken создает токен ссылки на скачивание выгрузки exportID
+func (j *JWTManager) GenerateDataExportToken(id uuid.UUID, exportID string, ttl time.Duration) (string, error) {
+	claims := j.newClaims(DataExportToken, "", "", id, ttl)
+	claims.ExportID = exportID
+	return j.sign(claims)
 }
 
-// DecodeJWT парсит токен и проверяет его подпись публичным ключом
-func (j *JWTManager) DecodeJWT(tokenString string) (jwt.Claims, error) {
+// DecodeJWT парсит токен, проверяет его подпись публичным ключом, срок действия, iss и aud
+func (j *JWTManager) DecodeJWT(tokenString string) (*Claims, error) {
 	logger.Debug("Parsing token")
 
+	options := []jwt.ParserOption{jwt.WithIssuedAt()}
+	if j.leeway > 0 {
+		options = append(options, jwt.WithLeeway(j.leeway))
+	}
+	if j.issuer != "" {
+		options = append(options, jwt.WithIssuer(j.issuer))
+	}
+	if j.audience != "" {
+		options = append(options, jwt.WithAudience(j.audience))
+	}
+
 	// Разбираем и проверяем подпись токена
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Проверяем, что используется алгоритм RS256
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		key, err := j.verificationKey(token)
+		if err != nil {
+			return nil, err
+		}
+		// Алгоритм токена должен строго совпадать с алгоритмом, к которому привязан ключ
+		if token.Method.Alg() != key.method.Alg() {
 			logger.Errorf("Unexpected signing method: %v", token.Header["alg"])
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return j.publicKey, nil // Возвращаем публичный ключ для проверки подписи
-	})
+		return key.publicKey, nil // Возвращаем публичный ключ для проверки подписи
+	}, options...)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -126,8 +214,188 @@ func (j *JWTManager) DecodeJWT(tokenString string) (jwt.Claims, error) {
 	}
 
 	// Проверяем валидность токена
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
 	return nil, errs.ErrTokenInvalid
 }
+
+// sign подписывает claims активным ключом и проставляет его kid
+func (j *JWTManager) sign(claims jwt.Claims) (string, error) {
+	j.mu.RLock()
+	key := j.active
+	j.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.privateKey)
+}
+
+// verificationKey выбирает ключ проверки по kid из заголовка токена
+func (j *JWTManager) verificationKey(token *jwt.Token) (*signingKey, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	// Токены без kid выпущены до его появления и проверяются активным ключом
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return j.active, nil
+	}
+
+	key, ok := j.keys[kid]
+	if !ok {
+		logger.Debugf("Unknown key id: %v", kid)
+		return nil, fmt.Errorf("unknown key id: %v", kid)
+	}
+	return key, nil
+}
+
+// KeyID возвращает идентификатор (kid) активного ключа подписи
+func (j *JWTManager) KeyID() string {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.active.kid
+}
+
+// JWKS возвращает публичные ключи для проверки подписи токенов:
+// активный ключ и выведенные ключи, срок хранения которых еще не истек
+func (j *JWTManager) JWKS() JWKS {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(j.keys))}
+	for _, key := range j.keys {
+		jwk := publicJWK(key.publicKey)
+		jwk.Use = "sig"
+		jwk.Alg = key.method.Alg()
+		jwk.Kid = key.kid
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// RotateKey делает ключ kid активным. Если kid пустой, генерирует новый ключ.
+// Предыдущий активный ключ выводится и удаляется после истечения retention.
+func (j *JWTManager) RotateKey(kid string) (string, error) {
+	if j.keysDir == "" {
+		return "", errs.ErrKeyRotationDisabled
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	keys, state, err := loadKeyring(j.keysDir)
+	if err != nil {
+		logger.Errorf("failed to load keyring: %v", err)
+		return "", err
+	}
+
+	var next *signingKey
+	if kid == "" {
+		next, err = generateKeyFile(j.keysDir, j.method)
+		if err != nil {
+			logger.Errorf("failed to generate signing key: %v", err)
+			return "", err
+		}
+		keys[next.kid] = next
+	} else {
+		var ok bool
+		if next, ok = keys[kid]; !ok {
+			return "", errs.ErrKeyNotFound
+		}
+	}
+
+	// Выводим текущий активный ключ: он остается доступен для проверки выданных им токенов
+	if prev, ok := keys[state.Active]; ok && prev.kid != next.kid {
+		prev.retireAt = time.Now().Add(j.retention)
+		state.Retired[prev.kid] = prev.retireAt
+	}
+	next.retireAt = time.Time{}
+	delete(state.Retired, next.kid)
+	state.Active = next.kid
+
+	if err := saveKeyringState(j.keysDir, state); err != nil {
+		logger.Errorf("failed to save keyring state: %v", err)
+		return "", err
+	}
+
+	j.apply(keys, state)
+	logger.Infof("Signing key %s promoted to active", next.kid)
+	return next.kid, nil
+}
+
+// Reload перечитывает связку ключей из директории (изменения могли сделать другие экземпляры сервиса)
+// и удаляет выведенные ключи, срок хранения которых истек
+func (j *JWTManager) Reload() error {
+	if j.keysDir == "" {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	keys, state, err := loadKeyring(j.keysDir)
+	if err != nil {
+		logger.Errorf("failed to load keyring: %v", err)
+		return err
+	}
+
+	j.apply(keys, state)
+	return nil
+}
+
+// apply применяет загруженную связку, вызывается под блокировкой
+func (j *JWTManager) apply(keys map[string]*signingKey, state keyringState) {
+	now := time.Now()
+	for kid, key := range keys {
+		if key.retireAt.IsZero() || key.retireAt.After(now) || kid == state.Active {
+			continue
+		}
+		if err := os.Remove(key.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Errorf("failed to remove retired key %s: %v", kid, err)
+			continue
+		}
+		delete(keys, kid)
+		logger.Infof("Retired signing key %s removed", kid)
+	}
+
+	active, ok := keys[state.Active]
+	if !ok {
+		// Активный ключ не задан: берем самый новый из невыведенных
+		for _, key := range keys {
+			if !key.retireAt.IsZero() {
+				continue
+			}
+			if active == nil || key.modTime.After(active.modTime) {
+				active = key
+			}
+		}
+	}
+
+	if active == nil {
+		logger.Warnf("Keyring %s has no active signing key", j.keysDir)
+		return
+	}
+
+	j.keys = keys
+	j.active = active
+}
+
+// RunKeyMaintenance периодически перечитывает связку и удаляет истекшие ключи
+func (j *JWTManager) RunKeyMaintenance(ctx context.Context, interval time.Duration) {
+	if j.keysDir == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = j.Reload()
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	keyringStateFile = "keyring.json"
+	keyFileExt       = ".pem"
+	rsaKeyBits       = 2048
+)
+
+// signingKey ключ из связки JWTManager, привязанный к одному алгоритму подписи
+type signingKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+	path       string // файл ключа, пусто для статического ключа
+	modTime    time.Time
+	retireAt   time.Time // момент удаления выведенного ключа, нулевой - ключ не выведен
+}
+
+func newSigningKey(privateKey crypto.Signer, publicKey crypto.PublicKey) (*signingKey, error) {
+	method, err := methodForKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signingKey{
+		kid:        thumbprint(publicJWK(publicKey)),
+		method:     method,
+		privateKey: privateKey,
+		publicKey:  publicKey,
+	}, nil
+}
+
+// keyringState состояние связки ключей, хранится рядом с ключами в keyring.json
+type keyringState struct {
+	Active  string               `json:"active"`
+	Retired map[string]time.Time `json:"retired,omitempty"`
+}
+
+// loadKeyring читает все приватные ключи *.pem и состояние связки из директории
+func loadKeyring(dir string) (map[string]*signingKey, keyringState, error) {
+	state := keyringState{Retired: map[string]time.Time{}}
+
+	data, err := os.ReadFile(filepath.Join(dir, keyringStateFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, state, fmt.Errorf("failed to parse keyring state: %w", err)
+		}
+		if state.Retired == nil {
+			state.Retired = map[string]time.Time{}
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, state, fmt.Errorf("failed to read keyring state: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, state, fmt.Errorf("failed to read keys dir: %w", err)
+	}
+
+	keys := make(map[string]*signingKey)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), keyFileExt) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		keyData, err := os.ReadFile(path)
+		if err != nil {
+			return nil, state, fmt.Errorf("failed to read key %s: %w", entry.Name(), err)
+		}
+		privateKey, err := parsePrivateKeyPEM(keyData)
+		if err != nil {
+			return nil, state, fmt.Errorf("failed to parse key %s: %w", entry.Name(), err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, state, fmt.Errorf("failed to stat key %s: %w", entry.Name(), err)
+		}
+
+		key, err := newSigningKey(privateKey, privateKey.Public())
+		if err != nil {
+			return nil, state, fmt.Errorf("failed to load key %s: %w", entry.Name(), err)
+		}
+		key.path = path
+		key.modTime = info.ModTime()
+		key.retireAt = state.Retired[key.kid]
+		keys[key.kid] = key
+	}
+
+	return keys, state, nil
+}
+
+// saveKeyringState атомарно записывает состояние связки
+func saveKeyringState(dir string, state keyringState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal keyring state: %w", err)
+	}
+
+	tmp := filepath.Join(dir, keyringStateFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write keyring state: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(dir, keyringStateFile))
+}
+
+// generateKeyFile создает новый ключ для метода подписи и сохраняет его в директорию связки
+func generateKeyFile(dir string, method jwt.SigningMethod) (*signingKey, error) {
+	privateKey, err := generatePrivateKey(method)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	key, err := newSigningKey(privateKey, privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.path = filepath.Join(dir, key.kid+keyFileExt)
+	key.modTime = time.Now()
+
+	data, err := marshalPrivateKeyPEM(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+	if err := os.WriteFile(key.path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write key: %w", err)
+	}
+
+	logger.Infof("Generated new %s signing key %s", key.method.Alg(), key.kid)
+	return key, nil
+}
@@ -0,0 +1,248 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordAlgArgon2id = "argon2id"
+	PasswordAlgBcrypt   = "bcrypt"
+)
+
+var errUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher хэширует и проверяет пароли
+type PasswordHasher interface {
+	// Hash возвращает закодированный хэш пароля (формат определяет алгоритм)
+	Hash(password string) (string, error)
+	// Verify проверяет пароль по хэшу, ошибка - только для поврежденного хэша
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash сообщает, что хэш получен другим алгоритмом или более слабыми параметрами
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2Params параметры argon2id
+type Argon2Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Верхние границы параметров argon2id. Параметры берутся из сохраненного хэша,
+// и испорченная запись в БД не должна заставить сервис выделять гигабайты памяти на каждый вход
+const (
+	maxArgon2Memory     = 1024 * 1024 // KiB, 1 GiB
+	maxArgon2Iterations = 64
+	maxArgon2KeyLength  = 1024
+)
+
+// DefaultArgon2Params рекомендации OWASP для argon2id
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// validate проверяет границы параметров: при t или p меньше 1 argon2 паникует
+func (p Argon2Params) validate() error {
+	switch {
+	case p.Memory > maxArgon2Memory:
+		return fmt.Errorf("argon2id memory %d KiB exceeds %d KiB", p.Memory, maxArgon2Memory)
+	case p.Iterations < 1 || p.Iterations > maxArgon2Iterations:
+		return fmt.Errorf("argon2id iterations %d out of range [1, %d]", p.Iterations, maxArgon2Iterations)
+	case p.Parallelism < 1:
+		return fmt.Errorf("argon2id parallelism must be at least 1")
+	case p.KeyLength > maxArgon2KeyLength:
+		return fmt.Errorf("argon2id key length %d exceeds %d", p.KeyLength, maxArgon2KeyLength)
+	}
+	return nil
+}
+
+// Argon2idHasher хэширует пароли argon2id и кодирует результат в PHC строку:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	params Argon2Params
+}
+
+func NewArgon2idHasher(params Argon2Params) *Argon2idHasher {
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2Params.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2Params.KeyLength
+	}
+	return &Argon2idHasher{params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("error generating salt: %w", err)
+	}
+
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	params, version, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	if version != argon2.Version {
+		return false, fmt.Errorf("unsupported argon2 version: %d", version)
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, version, _, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return version != argon2.Version ||
+		params.Memory < h.params.Memory ||
+		params.Iterations < h.params.Iterations ||
+		params.Parallelism < h.params.Parallelism ||
+		uint32(len(key)) < h.params.KeyLength
+}
+
+// decodeArgon2id разбирает PHC строку argon2id
+func decodeArgon2id(encoded string) (params Argon2Params, version int, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != PasswordAlgArgon2id {
+		return params, 0, nil, nil, errUnknownPasswordHash
+	}
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, 0, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, 0, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, 0, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, 0, nil, nil, fmt.Errorf("invalid argon2id hash: %w", err)
+	}
+	if len(key) == 0 {
+		return params, 0, nil, nil, errUnknownPasswordHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	if err = params.validate(); err != nil {
+		return params, 0, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	return params, version, salt, key, nil
+}
+
+// BcryptHasher хэширует пароли bcrypt (пароль длиннее 72 байт отклоняется)
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.cost
+}
+
+// passwordHashers хэширует выбранным алгоритмом, а проверяет хэши всех поддерживаемых алгоритмов,
+// чтобы старые хэши продолжали работать до перехэширования при входе
+type passwordHashers struct {
+	algorithm string
+	hashers   map[string]PasswordHasher
+}
+
+// NewPasswordHasher создает хэшер паролей с алгоритмом algorithm (argon2id или bcrypt)
+func NewPasswordHasher(algorithm string, argonParams Argon2Params, bcryptCost int) (PasswordHasher, error) {
+	hashers := map[string]PasswordHasher{
+		PasswordAlgArgon2id: NewArgon2idHasher(argonParams),
+		PasswordAlgBcrypt:   NewBcryptHasher(bcryptCost),
+	}
+	if _, ok := hashers[algorithm]; !ok {
+		return nil, fmt.Errorf("unsupported password hash algorithm: %q", algorithm)
+	}
+	// хэши с параметрами вне границ не прошли бы проверку при входе
+	if algorithm == PasswordAlgArgon2id {
+		if err := NewArgon2idHasher(argonParams).params.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &passwordHashers{algorithm: algorithm, hashers: hashers}, nil
+}
+
+func (h *passwordHashers) Hash(password string) (string, error) {
+	return h.hashers[h.algorithm].Hash(password)
+}
+
+func (h *passwordHashers) Verify(password, encoded string) (bool, error) {
+	hasher, ok := h.hashers[passwordHashAlgorithm(encoded)]
+	if !ok {
+		return false, errUnknownPasswordHash
+	}
+	return hasher.Verify(password, encoded)
+}
+
+func (h *passwordHashers) NeedsRehash(encoded string) bool {
+	algorithm := passwordHashAlgorithm(encoded)
+	if algorithm != h.algorithm {
+		return true
+	}
+	return h.hashers[algorithm].NeedsRehash(encoded)
+}
+
+// passwordHashAlgorithm определяет алгоритм по префиксу закодированного хэша
+func passwordHashAlgorithm(encoded string) string {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return PasswordAlgArgon2id
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return PasswordAlgBcrypt
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"service-auth/internal/app/errs"
+)
+
+// PasswordField поле, под которым нарушения политики попадают в ValidationErrorResponse.Fields
+const PasswordField = "Password"
+
+// PasswordPolicyParams правила политики паролей
+type PasswordPolicyParams struct {
+	MinLength        int // в символах
+	MaxLength        int // в символах, 0 - без ограничения
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+}
+
+// PasswordPolicy проверяет пароли при регистрации и смене пароля
+type PasswordPolicy struct {
+	params   PasswordPolicyParams
+	breached *BreachedPasswords
+}
+
+// NewPasswordPolicy создает политику, breached может быть nil (проверка по утечкам отключена)
+func NewPasswordPolicy(params PasswordPolicyParams, breached *BreachedPasswords) *PasswordPolicy {
+	return &PasswordPolicy{params: params, breached: breached}
+}
+
+// Validate проверяет пароль пользователя username/email.
+// Нарушения возвращаются ошибкой errs.ValidationError с сообщением под полем PasswordField
+func (p *PasswordPolicy) Validate(password, username, email string) error {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if length < p.params.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.params.MinLength))
+	}
+	if p.params.MaxLength > 0 && length > p.params.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters", p.params.MaxLength))
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r), unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.params.RequireLowercase && !hasLower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.params.RequireUppercase && !hasUpper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.params.RequireDigit && !hasDigit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.params.RequireSymbol && !hasSymbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if containsIdentity(password, username, email) {
+		violations = append(violations, "must not contain the username or email")
+	}
+
+	if p.breached != nil && p.breached.Contains(password) {
+		violations = append(violations, "has appeared in a data breach, choose another one")
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &errs.ValidationError{
+		Err:    errs.ErrWeakPassword,
+		Fields: map[string]string{PasswordField: strings.Join(violations, "; ")},
+	}
+}
+
+// minIdentityLength короткие логины и части email не проверяются, иначе они запрещают слишком много паролей
+const minIdentityLength = 3
+
+// containsIdentity проверяет, содержит ли пароль логин, email или локальную часть email (без учета регистра)
+func containsIdentity(password, username, email string) bool {
+	password = strings.ToLower(password)
+
+	candidates := []string{username, email}
+	if local, _, ok := strings.Cut(email, "@"); ok {
+		candidates = append(candidates, local)
+	}
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		if utf8.RuneCountInString(candidate) >= minIdentityLength && strings.Contains(password, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// BreachedPasswords локальный список SHA-1 хэшей утекших паролей
+type BreachedPasswords struct {
+	hashes map[[sha1.Size]byte]struct{}
+}
+
+// LoadBreachedPasswords загружает список в формате Pwned Passwords: строки HASH[:COUNT],
+// где HASH - SHA-1 пароля в hex. Пустые строки и строки с # пропускаются
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached passwords file: %w", err)
+	}
+	defer file.Close()
+
+	list := &BreachedPasswords{hashes: make(map[[sha1.Size]byte]struct{})}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		hexHash, _, _ := strings.Cut(entry, ":")
+
+		var hash [sha1.Size]byte
+		if n, err := hex.Decode(hash[:], []byte(hexHash)); err != nil || n != sha1.Size {
+			return nil, fmt.Errorf("invalid SHA-1 hash at line %d", line)
+		}
+		list.hashes[hash] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached passwords file: %w", err)
+	}
+	return list, nil
+}
+
+// Contains сообщает, есть ли пароль в списке утечек
+func (b *BreachedPasswords) Contains(password string) bool {
+	_, ok := b.hashes[sha1.Sum([]byte(password))]
+	return ok
+}
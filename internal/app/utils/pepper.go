@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// Peppers версии серверного перца паролей. Перец хранится вне БД,
+// поэтому хэши из таблицы users бесполезны без него.
+// Версия 0 означает пароль без перца (хэши до его введения).
+type Peppers struct {
+	current int
+	secrets map[int][]byte
+}
+
+// NewPeppers создает набор перцев, current - версия для новых хэшей
+func NewPeppers(current int, secrets map[int][]byte) (*Peppers, error) {
+	if current < 0 {
+		return nil, fmt.Errorf("invalid pepper version: %d", current)
+	}
+	if current != 0 && len(secrets[current]) == 0 {
+		return nil, fmt.Errorf("no secret for current pepper version %d", current)
+	}
+	return &Peppers{current: current, secrets: secrets}, nil
+}
+
+// Current версия перца для новых хэшей
+func (p *Peppers) Current() int {
+	return p.current
+}
+
+// Apply подмешивает перец версии version: HMAC-SHA256(secret, password) в base64
+func (p *Peppers) Apply(password string, version int) (string, error) {
+	if version == 0 {
+		return password, nil
+	}
+	secret, ok := p.secrets[version]
+	if !ok {
+		return "", fmt.Errorf("unknown pepper version: %d", version)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(password))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// PasswordManager хэширует пароли с текущим перцем и проверяет их с перцем, записанным у пользователя
+type PasswordManager struct {
+	hasher  PasswordHasher
+	peppers *Peppers
+}
+
+func NewPasswordManager(hasher PasswordHasher, peppers *Peppers) *PasswordManager {
+	return &PasswordManager{hasher: hasher, peppers: peppers}
+}
+
+// Hash возвращает хэш пароля и версию перца, с которой он получен
+func (m *PasswordManager) Hash(password string) (string, int, error) {
+	version := m.peppers.Current()
+	peppered, err := m.peppers.Apply(password, version)
+	if err != nil {
+		return "", 0, err
+	}
+	hash, err := m.hasher.Hash(peppered)
+	if err != nil {
+		return "", 0, err
+	}
+	return hash, version, nil
+}
+
+// Verify проверяет пароль по хэшу, полученному с перцем pepperVersion
+func (m *PasswordManager) Verify(password, hash string, pepperVersion int) (bool, error) {
+	peppered, err := m.peppers.Apply(password, pepperVersion)
+	if err != nil {
+		return false, err
+	}
+	return m.hasher.Verify(peppered, hash)
+}
+
+// NeedsRehash сообщает, что хэш нужно пересчитать: сменился перец, алгоритм или параметры
+func (m *PasswordManager) NeedsRehash(hash string, pepperVersion int) bool {
+	return pepperVersion != m.peppers.Current() || m.hasher.NeedsRehash(hash)
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// recoveryCodeBytes 80 бит случайности: 16 символов base32, перебор хэша из БД нецелесообразен
+const recoveryCodeBytes = 10
+
+// GenerateRecoveryCodes создает n одноразовых кодов восстановления вида XXXX-XXXX-XXXX-XXXX
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	buf := make([]byte, recoveryCodeBytes)
+	for range n {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("error generating recovery code: %w", err)
+		}
+		raw := totpEncoding.EncodeToString(buf)
+
+		groups := make([]string, 0, len(raw)/4)
+		for i := 0; i < len(raw); i += 4 {
+			groups = append(groups, raw[i:i+4])
+		}
+		codes = append(codes, strings.Join(groups, "-"))
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode приводит введенный код к каноничному виду: без разделителей, в верхнем регистре
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// IsRecoveryCode отличает код восстановления от кода TOTP
+func IsRecoveryCode(code string) bool {
+	return len(NormalizeRecoveryCode(code)) == totpEncoding.EncodedLen(recoveryCodeBytes)
+}
+
+// HashRecoveryCode хэш кода восстановления для хранения в БД
+func HashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(NormalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Разбор и проверка ответов WebAuthn (https://www.w3.org/TR/webauthn-2/) без внешних зависимостей.
+// Поддерживается только аттестация "none": сервис не проверяет производителя аутентификатора.
+
+// Типы clientDataJSON
+const (
+	WebAuthnTypeCreate = "webauthn.create"
+	WebAuthnTypeGet    = "webauthn.get"
+)
+
+// Флаги authenticatorData
+const (
+	AuthFlagUserPresent      byte = 0x01
+	AuthFlagUserVerified     byte = 0x04
+	AuthFlagAttestedCredData byte = 0x40
+	AuthFlagExtensionData    byte = 0x80
+)
+
+// Алгоритмы COSE, которые принимает сервис
+const (
+	COSEAlgES256 int64 = -7
+	COSEAlgEdDSA int64 = -8
+	COSEAlgRS256 int64 = -257
+)
+
+var ErrWebAuthnMalformed = errors.New("malformed webauthn data")
+
+// DecodeBase64URL декодирует base64url с паддингом или без (так кодирует браузер)
+func DecodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// EncodeBase64URL кодирует данные для передачи браузеру
+func EncodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ClientData разобранный clientDataJSON
+type ClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// ParseClientData разбирает clientDataJSON
+func ParseClientData(raw []byte) (ClientData, error) {
+	var clientData ClientData
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return ClientData{}, fmt.Errorf("%w: client data: %v", ErrWebAuthnMalformed, err)
+	}
+	return clientData, nil
+}
+
+// AuthenticatorData разобранные данные аутентификатора
+type AuthenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+	// Заполняются при регистрации (флаг AT)
+	AAGUID              []byte
+	CredentialID        []byte
+	CredentialPublicKey []byte // ключ COSE в CBOR
+}
+
+// HasFlag проверяет флаг authenticatorData
+func (a AuthenticatorData) HasFlag(flag byte) bool {
+	return a.Flags&flag != 0
+}
+
+// ParseAuthenticatorData разбирает authenticatorData: rpIdHash(32) | flags(1) | signCount(4) | attestedCredentialData | extensions
+func ParseAuthenticatorData(raw []byte) (AuthenticatorData, error) {
+	if len(raw) < 37 {
+		return AuthenticatorData{}, fmt.Errorf("%w: authenticator data too short", ErrWebAuthnMalformed)
+	}
+
+	data := AuthenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	rest := raw[37:]
+
+	if data.HasFlag(AuthFlagAttestedCredData) {
+		if len(rest) < 18 {
+			return AuthenticatorData{}, fmt.Errorf("%w: attested credential data too short", ErrWebAuthnMalformed)
+		}
+		data.AAGUID = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < idLength {
+			return AuthenticatorData{}, fmt.Errorf("%w: credential id truncated", ErrWebAuthnMalformed)
+		}
+		data.CredentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		// длина ключа COSE известна только после его декодирования
+		_, afterKey, err := decodeCBOR(rest)
+		if err != nil {
+			return AuthenticatorData{}, fmt.Errorf("%w: credential public key: %v", ErrWebAuthnMalformed, err)
+		}
+		data.CredentialPublicKey = rest[:len(rest)-len(afterKey)]
+		rest = afterKey
+	}
+
+	if data.HasFlag(AuthFlagExtensionData) {
+		_, afterExtensions, err := decodeCBOR(rest)
+		if err != nil {
+			return AuthenticatorData{}, fmt.Errorf("%w: extensions: %v", ErrWebAuthnMalformed, err)
+		}
+		rest = afterExtensions
+	}
+
+	if len(rest) != 0 {
+		return AuthenticatorData{}, fmt.Errorf("%w: trailing authenticator data", ErrWebAuthnMalformed)
+	}
+	return data, nil
+}
+
+// VerifyRPIDHash проверяет, что authenticatorData выдан для rpID
+func (a AuthenticatorData) VerifyRPIDHash(rpID string) bool {
+	expected := sha256.Sum256([]byte(rpID))
+	return subtle.ConstantTimeCompare(a.RPIDHash, expected[:]) == 1
+}
+
+// ParseAttestationObject разбирает attestationObject и возвращает формат аттестации и authenticatorData
+func ParseAttestationObject(raw []byte) (string, []byte, error) {
+	value, rest, err := decodeCBOR(raw)
+	if err != nil || len(rest) != 0 {
+		return "", nil, fmt.Errorf("%w: attestation object", ErrWebAuthnMalformed)
+	}
+	object, ok := value.(map[any]any)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: attestation object is not a map", ErrWebAuthnMalformed)
+	}
+
+	format, _ := object["fmt"].(string)
+	authData, ok := object["authData"].([]byte)
+	if format == "" || !ok {
+		return "", nil, fmt.Errorf("%w: attestation object fields", ErrWebAuthnMalformed)
+	}
+	return format, authData, nil
+}
+
+// Параметры ключей COSE (RFC 9053)
+const (
+	coseKeyType  int64 = 1
+	coseKeyAlg   int64 = 3
+	coseKeyCrv   int64 = -1 // EC2/OKP: кривая; RSA: n
+	coseKeyX     int64 = -2 // EC2/OKP: x; RSA: e
+	coseKeyY     int64 = -3
+	coseKtyOKP   int64 = 1
+	coseKtyEC2   int64 = 2
+	coseKtyRSA   int64 = 3
+	coseCrvP256  int64 = 1
+	coseCrvEd255 int64 = 6
+)
+
+// ParseCOSEKey разбирает публичный ключ COSE и возвращает его с алгоритмом подписи
+func ParseCOSEKey(raw []byte) (crypto.PublicKey, int64, error) {
+	value, rest, err := decodeCBOR(raw)
+	if err != nil || len(rest) != 0 {
+		return nil, 0, fmt.Errorf("%w: cose key", ErrWebAuthnMalformed)
+	}
+	key, ok := value.(map[any]any)
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: cose key is not a map", ErrWebAuthnMalformed)
+	}
+
+	kty, _ := key[coseKeyType].(int64)
+	alg, _ := key[coseKeyAlg].(int64)
+
+	switch {
+	case kty == coseKtyEC2 && alg == COSEAlgES256:
+		crv, _ := key[coseKeyCrv].(int64)
+		x, okX := key[coseKeyX].([]byte)
+		y, okY := key[coseKeyY].([]byte)
+		if crv != coseCrvP256 || !okX || !okY || len(x) != 32 || len(y) != 32 {
+			return nil, 0, fmt.Errorf("%w: invalid EC2 key", ErrWebAuthnMalformed)
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, fmt.Errorf("%w: EC2 point is not on curve", ErrWebAuthnMalformed)
+		}
+		return pub, alg, nil
+	case kty == coseKtyOKP && alg == COSEAlgEdDSA:
+		crv, _ := key[coseKeyCrv].(int64)
+		x, okX := key[coseKeyX].([]byte)
+		if crv != coseCrvEd255 || !okX || len(x) != ed25519.PublicKeySize {
+			return nil, 0, fmt.Errorf("%w: invalid OKP key", ErrWebAuthnMalformed)
+		}
+		return ed25519.PublicKey(x), alg, nil
+	case kty == coseKtyRSA && alg == COSEAlgRS256:
+		n, okN := key[coseKeyCrv].([]byte)
+		e, okE := key[coseKeyX].([]byte)
+		if !okN || !okE || len(e) == 0 || len(e) > 4 || len(n) < 256 {
+			return nil, 0, fmt.Errorf("%w: invalid RSA key", ErrWebAuthnMalformed)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, alg, nil
+	default:
+		return nil, 0, fmt.Errorf("%w: unsupported cose key type %d alg %d", ErrWebAuthnMalformed, kty, alg)
+	}
+}
+
+// VerifyAssertionSignature проверяет подпись assertion: sig над authenticatorData || SHA-256(clientDataJSON)
+func VerifyAssertionSignature(coseKey, authData, clientDataJSON, signature []byte) error {
+	publicKey, alg, err := ParseCOSEKey(coseKey)
+	if err != nil {
+		return err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := make([]byte, 0, len(authData)+len(clientDataHash))
+	signed = append(signed, authData...)
+	signed = append(signed, clientDataHash[:]...)
+
+	switch alg {
+	case COSEAlgES256:
+		digest := sha256.Sum256(signed)
+		if !ecdsa.VerifyASN1(publicKey.(*ecdsa.PublicKey), digest[:], signature) {
+			return errors.New("invalid ES256 signature")
+		}
+	case COSEAlgEdDSA:
+		if !ed25519.Verify(publicKey.(ed25519.PublicKey), signed, signature) {
+			return errors.New("invalid EdDSA signature")
+		}
+	case COSEAlgRS256:
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(publicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("invalid RS256 signature: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported cose algorithm %d", alg)
+	}
+	return nil
+}
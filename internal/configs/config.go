@@ -3,6 +3,9 @@ package configs
 import (
 	"fmt"
 	"log"
+	"net"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +20,7 @@ type ServerConfig struct {
 	ReadTimeout    time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout   time.Duration `mapstructure:"write_timeout"`
 	MaxHeaderBytes int           `mapstructure:"max_header_bytes"`
+	TrustedProxies []string      `mapstructure:"trusted_proxies"` // IP или подсети прокси, которым доверяется X-Forwarded-For (пусто - никому)
 }
 
 // Конфигурация логирования
@@ -34,16 +38,176 @@ type PostgresConfig struct {
 
 // Конфигурация Auth
 type AuthConfig struct {
-	PrivateKey      string        `mapstructure:"private_key"`
-	PublicKey       string        `mapstructure:"public_key"`
-	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`
-	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
+	SigningAlgorithm     string        `mapstructure:"signing_algorithm"` // Алгоритм подписи: RS256, ES256, EdDSA
+	PrivateKey           string        `mapstructure:"private_key"`
+	PublicKey            string        `mapstructure:"public_key"`
+	KeysDir              string        `mapstructure:"keys_dir"`              // Директория связки ключей (включает ротацию)
+	KeysCheckInterval    time.Duration `mapstructure:"keys_check_interval"`   // Период перечитывания связки и удаления выведенных ключей
+	AdminAPIKey          string        `mapstructure:"admin_api_key"`         // Ключ для административных ручек (X-Admin-Key)
+	IntrospectionClients []string      `mapstructure:"introspection_clients"` // Клиенты интроспекции: client_id:client_secret
+	TokenHashSecret      string        `mapstructure:"token_hash_secret"`     // Секрет HMAC, под которым refresh токены хранятся в redis
+	Issuer               string        `mapstructure:"issuer"`                // iss выдаваемых токенов
+	Audience             string        `mapstructure:"audience"`              // aud выдаваемых токенов
+	ClockSkew            time.Duration `mapstructure:"clock_skew"`            // Допуск расхождения часов при проверке exp, nbf и iat
+	AccessTokenTTL       time.Duration `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL      time.Duration `mapstructure:"refresh_token_ttl"`
+}
+
+// IntrospectionCredentials возвращает секреты клиентов интроспекции по client_id
+func (c AuthConfig) IntrospectionCredentials() map[string]string {
+	clients := make(map[string]string, len(c.IntrospectionClients))
+	for _, client := range c.IntrospectionClients {
+		if client == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(client, ":")
+		if !ok || id == "" || secret == "" {
+			log.Printf("Warning: invalid introspection client entry (expected client_id:client_secret)")
+			continue
+		}
+		clients[id] = secret
+	}
+	return clients
+}
+
+// Конфигурация защиты входа от перебора паролей
+type LoginConfig struct {
+	Window           time.Duration `mapstructure:"window"`            // Скользящее окно подсчета неудачных попыток
+	IPMaxFailures    int           `mapstructure:"ip_max_failures"`   // Лимит неудачных попыток с одного IP в окне
+	DelayAfter       int           `mapstructure:"delay_after"`       // После скольких неудач по логину включается задержка
+	BaseDelay        time.Duration `mapstructure:"base_delay"`        // Начальная задержка, удваивается с каждой неудачей
+	MaxDelay         time.Duration `mapstructure:"max_delay"`         // Максимальная задержка между попытками
+	LockoutThreshold int           `mapstructure:"lockout_threshold"` // Неудач подряд до блокировки аккаунта (0 - без блокировки)
+	LockoutDuration  time.Duration `mapstructure:"lockout_duration"`  // Длительность блокировки аккаунта
+}
+
+// Конфигурация паролей: хэширование и политика
+type PasswordConfig struct {
+	Algorithm         string        `mapstructure:"algorithm"`          // argon2id или bcrypt
+	BcryptCost        int           `mapstructure:"bcrypt_cost"`        // Стоимость bcrypt
+	Argon2Memory      uint32        `mapstructure:"argon2_memory"`      // Память argon2id, KiB
+	Argon2Iterations  uint32        `mapstructure:"argon2_iterations"`  // Число проходов argon2id
+	Argon2Parallelism uint8         `mapstructure:"argon2_parallelism"` // Число потоков argon2id
+	Peppers           []string      `mapstructure:"peppers"`            // Серверные перцы: version:secret
+	PepperFile        string        `mapstructure:"pepper_file"`        // Файл с перцами (строки version:secret)
+	PepperVersion     int           `mapstructure:"pepper_version"`     // Версия перца для новых хэшей (0 - без перца)
+	MinLength         int           `mapstructure:"min_length"`         // Минимальная длина пароля в символах
+	MaxLength         int           `mapstructure:"max_length"`         // Максимальная длина пароля в символах
+	RequireLowercase  bool          `mapstructure:"require_lowercase"`  // Требовать строчную букву
+	RequireUppercase  bool          `mapstructure:"require_uppercase"`  // Требовать заглавную букву
+	RequireDigit      bool          `mapstructure:"require_digit"`      // Требовать цифру
+	RequireSymbol     bool          `mapstructure:"require_symbol"`     // Требовать спецсимвол
+	BreachedListFile  string        `mapstructure:"breached_list_file"` // Список SHA-1 утекших паролей (пусто - без проверки)
+	ResetURL          string        `mapstructure:"reset_url"`          // Страница сброса пароля, токен передается в параметре token
+	ResetTokenTTL     time.Duration `mapstructure:"reset_token_ttl"`    // Время жизни ссылки сброса пароля
+	ResetInterval     time.Duration `mapstructure:"reset_interval"`     // Минимальный интервал между письмами сброса
+}
+
+// PepperSecrets возвращает секреты перцев по версиям из конфига и файла секретов
+func (c PasswordConfig) PepperSecrets() (map[int][]byte, error) {
+	entries := append([]string{}, c.Peppers...)
+	if c.PepperFile != "" {
+		data, err := os.ReadFile(c.PepperFile)
+		if err != nil {
+			return nil, fmt.Errorf("read pepper file: %w", err)
+		}
+		entries = append(entries, strings.Split(string(data), "\n")...)
+	}
+
+	secrets := make(map[int][]byte, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		rawVersion, secret, ok := strings.Cut(entry, ":")
+		version, err := strconv.Atoi(rawVersion)
+		if !ok || err != nil || version <= 0 || secret == "" {
+			return nil, fmt.Errorf("invalid pepper entry (expected version:secret)")
+		}
+		secrets[version] = []byte(secret)
+	}
+	return secrets, nil
+}
+
+// Конфигурация двухфакторной аутентификации
+type MFAConfig struct {
+	EncryptionKey string        `mapstructure:"encryption_key"` // Ключ AES-256 (base64) для шифрования секретов TOTP, пусто - MFA недоступна
+	Issuer        string        `mapstructure:"issuer"`         // Имя сервиса в приложении-аутентификаторе
+	ChallengeTTL  time.Duration `mapstructure:"challenge_ttl"`  // Время жизни mfa_token между паролем и кодом
+	MaxAttempts   int           `mapstructure:"max_attempts"`   // Неверных кодов в окне login.window до блокировки проверки
+	Skew          int           `mapstructure:"skew"`           // Допустимое расхождение часов в шагах по 30 секунд
+	RecoveryCodes int           `mapstructure:"recovery_codes"` // Число кодов восстановления в наборе
+	RequiredRoles []string      `mapstructure:"required_roles"` // Роли, которым токены выдаются только с включенной MFA
+}
+
+// Required сообщает, что пользователю с ролью role токены выдаются только после настройки MFA
+func (c MFAConfig) Required(role string) bool {
+	for _, required := range c.RequiredRoles {
+		if required != "" && required == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Конфигурация входа по ключам доступа (WebAuthn / passkeys)
+type WebAuthnConfig struct {
+	RPID             string        `mapstructure:"rp_id"`             // Домен сервиса (Relying Party ID), пусто - WebAuthn недоступен
+	RPName           string        `mapstructure:"rp_name"`           // Имя сервиса, показываемое браузером
+	Origins          []string      `mapstructure:"origins"`           // Допустимые origin страниц, вызывающих WebAuthn
+	Timeout          time.Duration `mapstructure:"timeout"`           // Время на церемонию (жизнь челленджа)
+	UserVerification string        `mapstructure:"user_verification"` // required, preferred или discouraged
+}
+
+// Конфигурация подтверждения email
+type EmailConfig struct {
+	RequireVerified bool          `mapstructure:"require_verified"` // Запрещать вход с неподтвержденным email
+	VerifyURL       string        `mapstructure:"verify_url"`       // Страница подтверждения, токен передается в параметре token
+	ChangeURL       string        `mapstructure:"change_url"`       // Страница подтверждения нового email при смене адреса
+	VerificationTTL time.Duration `mapstructure:"verification_ttl"` // Время жизни ссылки подтверждения
+	ResendInterval  time.Duration `mapstructure:"resend_interval"`  // Минимальный интервал между письмами подтверждения
+}
+
+// Конфигурация удаления учетных записей
+type AccountConfig struct {
+	DeletionGracePeriod time.Duration `mapstructure:"deletion_grace_period"` // Срок, в течение которого удаление можно отменить
+	PurgeInterval       time.Duration `mapstructure:"purge_interval"`        // Период фоновой очистки удаленных учетных записей
+}
+
+// Конфигурация выгрузки персональных данных
+type ExportConfig struct {
+	DownloadURL     string        `mapstructure:"download_url"`     // Адрес скачивания, токен передается в параметре token
+	TTL             time.Duration `mapstructure:"ttl"`              // Время хранения готовой выгрузки и жизни ссылки
+	RequestInterval time.Duration `mapstructure:"request_interval"` // Минимальный интервал между запросами выгрузки пользователем
+	Timeout         time.Duration `mapstructure:"timeout"`          // Таймаут сборки одной выгрузки
+	QueueSize       int           `mapstructure:"queue_size"`       // Размер очереди сборки; при переполнении запрос отклоняется
+	Workers         int           `mapstructure:"workers"`          // Число фоновых сборщиков выгрузок
+}
+
+// Конфигурация отправки писем
+type MailConfig struct {
+	Backend      string        `mapstructure:"backend"`       // log, file или smtp
+	From         string        `mapstructure:"from"`          // Адрес отправителя
+	Locale       string        `mapstructure:"locale"`        // Язык писем (en, ru)
+	Dir          string        `mapstructure:"dir"`           // Директория .eml файлов для backend file
+	SMTPHost     string        `mapstructure:"smtp_host"`     // SMTP сервер
+	SMTPPort     int           `mapstructure:"smtp_port"`     // Порт SMTP сервера
+	SMTPUsername string        `mapstructure:"smtp_username"` // Логин SMTP, пусто - без аутентификации
+	SMTPPassword string        `mapstructure:"smtp_password"` // Пароль SMTP
+	SMTPSecurity string        `mapstructure:"smtp_security"` // starttls, tls или none
+	Timeout      time.Duration `mapstructure:"timeout"`       // Таймаут отправки одного письма
+	QueueSize    int           `mapstructure:"queue_size"`    // Размер очереди писем
+	Workers      int           `mapstructure:"workers"`       // Число обработчиков очереди
+	Retries      int           `mapstructure:"retries"`       // Повторов доставки после неудачи
+	RetryDelay   time.Duration `mapstructure:"retry_delay"`   // Задержка перед первым повтором, удваивается
 }
 
 type RedisConfig struct {
-	Addr     string `mapstructure:"addr"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
+	Addr      string `mapstructure:"addr"`
+	Password  string `mapstructure:"password"`
+	DB        int    `mapstructure:"db"`
+	KeyPrefix string `mapstructure:"key_prefix"` // Пространство имен ключей сервиса
 }
 
 // Полная конфигурация
@@ -53,6 +217,20 @@ type Config struct {
 	Database PostgresConfig `mapstructure:"database"`
 	Auth     AuthConfig     `mapstructure:"auth"`
 	Redis    RedisConfig    `mapstructure:"redis"`
+	Login    LoginConfig    `mapstructure:"login"`
+	Password PasswordConfig `mapstructure:"password"`
+	MFA      MFAConfig      `mapstructure:"mfa"`
+	WebAuthn WebAuthnConfig `mapstructure:"webauthn"`
+	Email    EmailConfig    `mapstructure:"email"`
+	Mail     MailConfig     `mapstructure:"mail"`
+	Account  AccountConfig  `mapstructure:"account"`
+	Export   ExportConfig   `mapstructure:"export"`
+}
+
+// KeyRetention время, в течение которого выведенный ключ нужен для проверки выданных им токенов.
+// Той же связкой подписываются ссылки из писем и ссылки на выгрузку, поэтому учитываются и их сроки
+func (c *Config) KeyRetention() time.Duration {
+	return max(c.Auth.AccessTokenTTL, c.Auth.RefreshTokenTTL, c.MFA.ChallengeTTL, c.Email.VerificationTTL, c.Export.TTL)
 }
 
 // LoadConfig загружает конфигурацию из файлов и переменных окружения
@@ -90,6 +268,149 @@ func LoadConfig(path string) (*Config, error) {
 	if config.Server.WriteTimeout <= 0 {
 		config.Server.WriteTimeout = 10 * time.Second
 	}
+	for _, proxy := range config.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return nil, fmt.Errorf("invalid server.trusted_proxies entry: %q", proxy)
+			}
+		}
+	}
+	if config.Auth.SigningAlgorithm == "" {
+		config.Auth.SigningAlgorithm = "RS256"
+	}
+	if config.Auth.KeysCheckInterval <= 0 {
+		config.Auth.KeysCheckInterval = time.Minute
+	}
+	if config.Auth.TokenHashSecret == "change_me" {
+		return nil, fmt.Errorf("auth.token_hash_secret is set to the placeholder value, generate a random secret")
+	}
+	if config.Auth.ClockSkew < 0 {
+		config.Auth.ClockSkew = 0
+	}
+	if config.Password.Algorithm == "" {
+		config.Password.Algorithm = "argon2id"
+	}
+	if config.Password.BcryptCost <= 0 {
+		config.Password.BcryptCost = 12
+	}
+	if config.Password.Argon2Memory == 0 {
+		config.Password.Argon2Memory = 64 * 1024
+	}
+	if config.Password.Argon2Iterations == 0 {
+		config.Password.Argon2Iterations = 3
+	}
+	if config.Password.Argon2Parallelism == 0 {
+		config.Password.Argon2Parallelism = 2
+	}
+	if config.Password.MinLength <= 0 {
+		config.Password.MinLength = 10
+	}
+	if config.Password.MaxLength <= 0 {
+		config.Password.MaxLength = 128
+	}
+	if config.Password.ResetTokenTTL <= 0 {
+		config.Password.ResetTokenTTL = 30 * time.Minute
+	}
+	if config.Password.ResetInterval <= 0 {
+		config.Password.ResetInterval = time.Minute
+	}
+	if config.MFA.Issuer == "" {
+		config.MFA.Issuer = "service-auth"
+	}
+	if config.MFA.ChallengeTTL <= 0 {
+		config.MFA.ChallengeTTL = 5 * time.Minute
+	}
+	if config.MFA.MaxAttempts <= 0 {
+		config.MFA.MaxAttempts = 5
+	}
+	if config.MFA.RecoveryCodes <= 0 {
+		config.MFA.RecoveryCodes = 10
+	}
+	// без ключа шифрования TOTP не настроить, и роли из required_roles не смогли бы войти
+	if len(config.MFA.RequiredRoles) > 0 && config.MFA.EncryptionKey == "" {
+		return nil, fmt.Errorf("mfa.required_roles needs mfa.encryption_key to be set")
+	}
+	if config.MFA.Skew < 0 {
+		config.MFA.Skew = 0
+	}
+	if config.WebAuthn.RPName == "" {
+		config.WebAuthn.RPName = "service-auth"
+	}
+	if config.WebAuthn.Timeout <= 0 {
+		config.WebAuthn.Timeout = 5 * time.Minute
+	}
+	if config.WebAuthn.UserVerification == "" {
+		config.WebAuthn.UserVerification = "preferred"
+	}
+	if config.Email.VerificationTTL <= 0 {
+		config.Email.VerificationTTL = 24 * time.Hour
+	}
+	if config.Email.ResendInterval <= 0 {
+		config.Email.ResendInterval = time.Minute
+	}
+	if config.Mail.Backend == "" {
+		return nil, fmt.Errorf("mail.backend is required: smtp, file or log (development only)")
+	}
+	if config.Mail.From == "" {
+		config.Mail.From = "service-auth <no-reply@localhost>"
+	}
+	if config.Mail.Locale == "" {
+		config.Mail.Locale = "en"
+	}
+	if config.Mail.Dir == "" {
+		config.Mail.Dir = "./mail"
+	}
+	if config.Mail.SMTPPort <= 0 {
+		config.Mail.SMTPPort = 587
+	}
+	if config.Mail.SMTPSecurity == "" {
+		config.Mail.SMTPSecurity = "starttls"
+	}
+	if config.Mail.Timeout <= 0 {
+		config.Mail.Timeout = 10 * time.Second
+	}
+	if config.Mail.QueueSize <= 0 {
+		config.Mail.QueueSize = 100
+	}
+	if config.Mail.Workers <= 0 {
+		config.Mail.Workers = 2
+	}
+	if config.Mail.Retries < 0 {
+		config.Mail.Retries = 0
+	}
+	if config.Mail.RetryDelay <= 0 {
+		config.Mail.RetryDelay = 2 * time.Second
+	}
+	if config.Account.DeletionGracePeriod <= 0 {
+		config.Account.DeletionGracePeriod = 30 * 24 * time.Hour
+	}
+	if config.Account.PurgeInterval <= 0 {
+		config.Account.PurgeInterval = time.Hour
+	}
+	if config.Export.TTL <= 0 {
+		config.Export.TTL = 24 * time.Hour
+	}
+	if config.Export.RequestInterval <= 0 {
+		config.Export.RequestInterval = time.Hour
+	}
+	if config.Export.Timeout <= 0 {
+		config.Export.Timeout = time.Minute
+	}
+	if config.Export.QueueSize <= 0 {
+		config.Export.QueueSize = 100
+	}
+	if config.Export.Workers <= 0 {
+		config.Export.Workers = 1
+	}
+	if config.Login.Window <= 0 {
+		config.Login.Window = 15 * time.Minute
+	}
+	if config.Login.MaxDelay <= 0 {
+		config.Login.MaxDelay = time.Minute
+	}
+	if config.Login.LockoutThreshold > 0 && config.Login.LockoutDuration <= 0 {
+		config.Login.LockoutDuration = 15 * time.Minute
+	}
 
 	return &config, nil
 }
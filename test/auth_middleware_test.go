@@ -0,0 +1,93 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/delivery/middleware"
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/utils"
+)
+
+// jwtValidator проверяет access токен только по подписи и типу (без redis)
+type jwtValidator struct {
+	jwtManager *utils.JWTManager
+}
+
+func (v jwtValidator) ValidateAccessToken(_ context.Context, token string) (*utils.Claims, error) {
+	claims, err := v.jwtManager.DecodeJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != utils.AccessToken {
+		return nil, errs.ErrAccessTokenInvalid
+	}
+	return claims, nil
+}
+
+func newAuthRouter(t *testing.T) (*gin.Engine, *utils.JWTManager) {
+	gin.SetMode(gin.TestMode)
+
+	jwtManager, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/me", middleware.Authenticate(jwtValidator{jwtManager}), func(c *gin.Context) {
+		claims, ok := middleware.Principal(c)
+		require.True(t, ok)
+		c.String(http.StatusOK, claims.Username)
+	})
+	return router, jwtManager
+}
+
+func TestAuthenticate(t *testing.T) {
+	router, jwtManager := newAuthRouter(t)
+	id := uuid.New()
+
+	accessToken, err := jwtManager.GenerateAccessToken("testuser", "user", id, uuid.NewString(), time.Minute)
+	require.NoError(t, err)
+	refreshToken, err := jwtManager.GenerateRefreshToken("testuser", "user", id, uuid.NewString(), time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		header string
+		cookie string
+		status int
+	}{
+		{name: "bearer header", header: "Bearer " + accessToken, status: http.StatusOK},
+		{name: "cookie", cookie: accessToken, status: http.StatusOK},
+		{name: "no token", status: http.StatusUnauthorized},
+		{name: "refresh token", header: "Bearer " + refreshToken, status: http.StatusUnauthorized},
+		{name: "garbage", header: "Bearer garbage", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: middleware.AccessTokenCookie, Value: tt.cookie})
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "testuser", rec.Body.String())
+			}
+		})
+	}
+}
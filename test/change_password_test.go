@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/utils"
+)
+
+func changePasswordRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/password", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer access-token")
+	return req
+}
+
+func TestChangePassword(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+	userID := uuid.New()
+
+	mockAuthService.EXPECT().ValidateAccessToken(gomock.Any(), "access-token").Return(&utils.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+		TokenType:        utils.AccessToken,
+		SessionID:        "session-1",
+	}, nil)
+	// сессия вызывающего передается в сервис, чтобы она не была отозвана
+	mockAuthService.EXPECT().ChangePassword(gomock.Any(), userID, "session-1",
+		models.ChangePasswordInput{CurrentPassword: "old-Password-1", NewPassword: "new-Password-2"}, gomock.Any()).
+		Return(nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, changePasswordRequest(`{"current_password":"old-Password-1","new_password":"new-Password-2"}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestChangePassword_InvalidCurrent(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+	userID := uuid.New()
+
+	mockAuthService.EXPECT().ValidateAccessToken(gomock.Any(), "access-token").Return(&utils.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+		TokenType:        utils.AccessToken,
+	}, nil)
+	mockAuthService.EXPECT().ChangePassword(gomock.Any(), userID, "", gomock.Any(), gomock.Any()).Return(errs.ErrInvalidPwd)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, changePasswordRequest(`{"current_password":"wrong","new_password":"new-Password-2"}`))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestChangePassword_Unauthorized(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().ValidateAccessToken(gomock.Any(), "access-token").Return(nil, errs.ErrTokenRevoked)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, changePasswordRequest(`{"current_password":"old-Password-1","new_password":"new-Password-2"}`))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestChangePassword_KeepsCurrentSession(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "changeuser")
+
+	current := env.login(t, "changeuser", testPassword)
+	other := env.login(t, "changeuser", testPassword)
+	currentSession := env.sessionID(t, current.AccessToken)
+
+	err := env.services.ChangePassword(ctx, userID, currentSession, models.ChangePasswordInput{
+		CurrentPassword: testPassword,
+		NewPassword:     "newpassword123",
+	}, testClient)
+	require.NoError(t, err)
+
+	// сессия, из которой сменен пароль, продолжает работать
+	_, err = env.services.ValidateAccessToken(ctx, current.AccessToken)
+	assert.NoError(t, err)
+	refreshed, err := env.services.RefreshTokens(ctx, current.RefreshToken, testClient)
+	require.NoError(t, err)
+	assert.Equal(t, currentSession, env.sessionID(t, refreshed.AccessToken))
+
+	// токены другой сессии, выпущенные в ту же секунду до смены пароля, отклоняются
+	_, err = env.services.ValidateAccessToken(ctx, other.AccessToken)
+	assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+	_, err = env.services.RefreshTokens(ctx, other.RefreshToken, testClient)
+	assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+
+	sessions, err := env.services.GetSessions(ctx, userID, currentSession)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, currentSession, sessions[0].ID)
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/configs"
+)
+
+func TestKeyRetentionCoversLinkTokens(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.Auth.AccessTokenTTL = 15 * time.Minute
+	cfg.Auth.RefreshTokenTTL = time.Hour
+	cfg.Email.VerificationTTL = 24 * time.Hour
+	cfg.Export.TTL = 48 * time.Hour
+
+	// ссылки из писем и на выгрузку подписаны той же связкой и должны пережить ротацию
+	assert.Equal(t, 48*time.Hour, cfg.KeyRetention())
+
+	cfg.Export.TTL = time.Hour
+	assert.Equal(t, 24*time.Hour, cfg.KeyRetention())
+}
+
+func TestTokenHashSecretPlaceholderRejected(t *testing.T) {
+	t.Setenv("AUTH_TOKEN_HASH_SECRET", "change_me")
+	_, err := configs.LoadConfig("../internal/configs")
+	assert.Error(t, err)
+
+	t.Setenv("AUTH_TOKEN_HASH_SECRET", "")
+	cfg, err := configs.LoadConfig("../internal/configs")
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Auth.TokenHashSecret)
+}
+
+func TestMailBackendRequired(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	// Способ доставки выбирается явно, log не подставляется по умолчанию
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("server:\n  port: 8080\n"), 0o600))
+	_, err := configs.LoadConfig(dir)
+	assert.ErrorContains(t, err, "mail.backend")
+}
+
+func TestTrustedProxiesValidated(t *testing.T) {
+	t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.0/8,not-an-ip")
+	_, err := configs.LoadConfig("../internal/configs")
+	assert.ErrorContains(t, err, "server.trusted_proxies")
+
+	// по умолчанию X-Forwarded-For не доверяется никому
+	t.Setenv("SERVER_TRUSTED_PROXIES", "")
+	cfg, err := configs.LoadConfig("../internal/configs")
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Server.TrustedProxies)
+
+	t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.0/8,192.0.2.10")
+	cfg, err = configs.LoadConfig("../internal/configs")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.0.2.10"}, cfg.Server.TrustedProxies)
+}
+
+func TestMFARequiredRolesNeedEncryptionKey(t *testing.T) {
+	// по умолчанию MFA не обязательна ни для одной роли
+	cfg, err := configs.LoadConfig("../internal/configs")
+	require.NoError(t, err)
+	assert.Empty(t, cfg.MFA.RequiredRoles)
+
+	t.Setenv("MFA_REQUIRED_ROLES", "admin")
+	t.Setenv("MFA_ENCRYPTION_KEY", "")
+	_, err = configs.LoadConfig("../internal/configs")
+	assert.ErrorContains(t, err, "mfa.encryption_key")
+
+	t.Setenv("MFA_ENCRYPTION_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	cfg, err = configs.LoadConfig("../internal/configs")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, cfg.MFA.RequiredRoles)
+}
@@ -0,0 +1,206 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	handlers "service-auth/internal/app/delivery/http"
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/service"
+	"service-auth/internal/app/service/mocks"
+	"service-auth/internal/app/utils"
+	"service-auth/internal/configs"
+)
+
+func newExportRouter(t *testing.T, userID uuid.UUID) (*gin.Engine, *mocks.MockExportService) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	mockAuthService := mocks.NewMockAuthService(ctrl)
+	mockExportService := mocks.NewMockExportService(ctrl)
+	mockAuthService.EXPECT().ValidateAccessToken(gomock.Any(), "access-token").Return(&utils.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+		TokenType:        utils.AccessToken,
+	}, nil).AnyTimes()
+
+	cfg := &configs.Config{}
+	cfg.Auth.AdminAPIKey = "admin-key"
+	services := service.Service{AuthService: mockAuthService, ExportService: mockExportService}
+	router := handlers.NewHandler(services, cfg).InitRoutes()
+	return router, mockExportService
+}
+
+func TestDataExportToken(t *testing.T) {
+	jwtManager, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+
+	id := uuid.New()
+	token, err := jwtManager.GenerateDataExportToken(id, "export-1", time.Hour)
+	require.NoError(t, err)
+
+	claims, err := jwtManager.DecodeJWT(token)
+	require.NoError(t, err)
+	assert.Equal(t, utils.DataExportToken, claims.TokenType)
+	assert.Equal(t, "export-1", claims.ExportID)
+	assert.Equal(t, id.String(), claims.Subject)
+	// ссылка не раскрывает логин и роль
+	assert.Empty(t, claims.Username)
+}
+
+func TestRequestExport(t *testing.T) {
+	userID := uuid.New()
+	router, mockExportService := newExportRouter(t, userID)
+
+	mockExportService.EXPECT().RequestExport(gomock.Any(), userID).
+		Return(models.DataExport{ID: "export-1", UserID: userID, Status: models.ExportPending}, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer access-token")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var export models.DataExport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Equal(t, models.ExportPending, export.Status)
+	assert.Empty(t, export.DownloadURL)
+}
+
+func TestRequestExport_TooOften(t *testing.T) {
+	userID := uuid.New()
+	router, mockExportService := newExportRouter(t, userID)
+
+	mockExportService.EXPECT().RequestExport(gomock.Any(), userID).
+		Return(models.DataExport{}, errs.WithRetryAfter(errs.ErrTooManyAttempts, time.Hour))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer access-token")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3600", w.Header().Get("Retry-After"))
+}
+
+func TestRequestExport_QueueFull(t *testing.T) {
+	userID := uuid.New()
+	router, mockExportService := newExportRouter(t, userID)
+
+	mockExportService.EXPECT().RequestExport(gomock.Any(), userID).Return(models.DataExport{}, errs.ErrExportQueueFull)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer access-token")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestGetExport_OtherUser(t *testing.T) {
+	userID := uuid.New()
+	router, mockExportService := newExportRouter(t, userID)
+
+	// выгрузка чужого пользователя не отличается от несуществующей
+	mockExportService.EXPECT().GetExport(gomock.Any(), userID, "export-1").Return(models.DataExport{}, errs.ErrExportNotFound)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/export/export-1", nil)
+	req.Header.Set("Authorization", "Bearer access-token")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminRequestExport(t *testing.T) {
+	userID := uuid.New()
+	router, mockExportService := newExportRouter(t, uuid.New())
+
+	mockExportService.EXPECT().RequestExportByAdmin(gomock.Any(), userID).
+		Return(models.DataExport{ID: "export-1", UserID: userID, Status: models.ExportPending}, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/"+userID.String()+"/export", nil)
+	req.Header.Set("X-Admin-Key", "admin-key")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestAdminRequestExport_Forbidden(t *testing.T) {
+	router, _ := newExportRouter(t, uuid.New())
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/"+uuid.NewString()+"/export", nil)
+	req.Header.Set("X-Admin-Key", "wrong")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestDownloadExport(t *testing.T) {
+	router, mockExportService := newExportRouter(t, uuid.New())
+
+	mockExportService.EXPECT().DownloadExport(gomock.Any(), "download-token").Return([]byte(`{"user":{}}`), nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/exports/download?token=download-token", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"user":{}}`, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestDownloadExport_InvalidLink(t *testing.T) {
+	router, mockExportService := newExportRouter(t, uuid.New())
+
+	mockExportService.EXPECT().DownloadExport(gomock.Any(), "expired").Return(nil, errs.ErrExportTokenInvalid)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/exports/download?token=expired", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDataExportArchive_Consents(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Export.QueueSize = 1
+	cfg.Export.Timeout = time.Minute
+	cfg.Export.DownloadURL = "http://localhost/export"
+	env := newServiceEnvWithConfig(t, cfg)
+	userID := env.register(t, "exportuser")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go env.services.RunExports(ctx, 1)
+
+	export, err := env.services.RequestExportByAdmin(ctx, userID)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		export, err = env.services.GetExport(ctx, userID, export.ID)
+		return err == nil && export.Status == models.ExportReady
+	}, 5*time.Second, 10*time.Millisecond)
+
+	link, err := url.Parse(export.DownloadURL)
+	require.NoError(t, err)
+	data, err := env.services.DownloadExport(ctx, link.Query().Get("token"))
+	require.NoError(t, err)
+
+	// раздел согласий есть в схеме архива, даже пустой
+	var archive map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &archive))
+	assert.JSONEq(t, `[]`, string(archive["consents"]))
+}
@@ -0,0 +1,186 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/utils"
+)
+
+func deleteAccountRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer access-token")
+	return req
+}
+
+func TestDeleteAccount(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+	userID := uuid.New()
+	deletedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	mockAuthService.EXPECT().ValidateAccessToken(gomock.Any(), "access-token").Return(&utils.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+		TokenType:        utils.AccessToken,
+	}, nil)
+	mockAuthService.EXPECT().DeleteAccount(gomock.Any(), userID, models.DeleteAccountInput{Password: "Password-1"}, gomock.Any()).
+		Return(models.AccountDeletion{DeletedAt: deletedAt, PurgeAt: deletedAt.Add(30 * 24 * time.Hour)}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, deleteAccountRequest(`{"password":"Password-1"}`))
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var deletion models.AccountDeletion
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deletion))
+	assert.True(t, deletion.PurgeAt.Equal(deletedAt.Add(30*24*time.Hour)))
+}
+
+func TestDeleteAccount_InvalidPassword(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+	userID := uuid.New()
+
+	mockAuthService.EXPECT().ValidateAccessToken(gomock.Any(), "access-token").Return(&utils.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+		TokenType:        utils.AccessToken,
+	}, nil)
+	mockAuthService.EXPECT().DeleteAccount(gomock.Any(), userID, gomock.Any(), gomock.Any()).
+		Return(models.AccountDeletion{}, errs.ErrInvalidPwd)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, deleteAccountRequest(`{"password":"wrong"}`))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestDeleteAccount_PasswordRequired(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().ValidateAccessToken(gomock.Any(), "access-token").Return(&utils.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+		TokenType:        utils.AccessToken,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, deleteAccountRequest(`{}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestLogin_AccountDeleted(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), "testuser", "password123", gomock.Any()).
+		Return(models.Tokens{}, errs.ErrAccountDeleted)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"username":"testuser","password":"password123"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "scheduled for deletion")
+}
+
+func TestRestoreAccount(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().RestoreAccount(gomock.Any(), "testuser", "password123", gomock.Any()).Return(nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/restore-account", strings.NewReader(`{"username":"testuser","password":"password123"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRestoreAccount_GracePeriodExpired(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().RestoreAccount(gomock.Any(), "testuser", "password123", gomock.Any()).Return(errs.ErrUserNotFound)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/restore-account", strings.NewReader(`{"username":"testuser","password":"password123"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeleteAccount_BlocksLoginUntilRestored(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "deleteuser")
+	tokens := env.login(t, "deleteuser", testPassword)
+
+	deletion, err := env.services.DeleteAccount(ctx, userID, models.DeleteAccountInput{Password: testPassword}, testClient)
+	require.NoError(t, err)
+	assert.Equal(t, deletion.DeletedAt.Add(env.cfg.Account.DeletionGracePeriod), deletion.PurgeAt)
+
+	_, err = env.services.GenerateTokens(ctx, "deleteuser", testPassword, testClient)
+	assert.ErrorIs(t, err, errs.ErrAccountDeleted)
+	_, err = env.services.ValidateAccessToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+	_, err = env.services.RefreshTokens(ctx, tokens.RefreshToken, testClient)
+	assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+
+	assert.ErrorIs(t, env.services.RestoreAccount(ctx, "deleteuser", "wrongpassword1", testClient), errs.ErrInvalidPwd)
+	require.NoError(t, env.services.RestoreAccount(ctx, "deleteuser", testPassword, testClient))
+	env.login(t, "deleteuser", testPassword)
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	expiredID := env.register(t, "expireduser")
+	pendingID := env.register(t, "pendinguser")
+
+	exports := make(map[uuid.UUID]string)
+	for _, id := range []uuid.UUID{expiredID, pendingID} {
+		export := models.DataExport{
+			ID:        uuid.NewString(),
+			UserID:    id,
+			Status:    models.ExportReady,
+			ExpiresAt: time.Now().Add(env.cfg.Export.TTL),
+		}
+		require.NoError(t, env.repo.CompleteDataExport(ctx, export, []byte("{}")))
+		exports[id] = export.ID
+
+		_, err := env.services.DeleteAccount(ctx, id, models.DeleteAccountInput{Password: testPassword}, testClient)
+		require.NoError(t, err)
+	}
+	// срок отмены удаления expireduser истек
+	env.pg.update(expiredID, func(user *fakeUser) {
+		deletedAt := time.Now().Add(-env.cfg.Account.DeletionGracePeriod - time.Minute)
+		user.DeletedAt = &deletedAt
+	})
+
+	assert.ErrorIs(t, env.services.RestoreAccount(ctx, "expireduser", testPassword, testClient), errs.ErrUserNotFound)
+
+	require.NoError(t, env.services.PurgeDeletedAccounts(ctx))
+	_, ok := env.pg.user(expiredID)
+	assert.False(t, ok)
+	_, ok = env.pg.user(pendingID)
+	assert.True(t, ok)
+
+	// выгрузки удаляются вместе с учетной записью
+	_, err := env.repo.GetDataExport(ctx, exports[expiredID])
+	assert.ErrorIs(t, err, errs.ErrExportNotFound)
+	_, err = env.repo.GetDataExportArchive(ctx, exports[expiredID])
+	assert.ErrorIs(t, err, errs.ErrExportNotFound)
+	assert.Empty(t, env.redis.keysWithPrefix(testRedisPrefix+"user_data_exports:"+expiredID.String()))
+	_, err = env.repo.GetDataExportArchive(ctx, exports[pendingID])
+	assert.NoError(t, err)
+
+	require.NoError(t, env.services.RestoreAccount(ctx, "pendinguser", testPassword, testClient))
+	env.login(t, "pendinguser", testPassword)
+}
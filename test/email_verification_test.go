@@ -0,0 +1,86 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/utils"
+)
+
+func TestEmailToken(t *testing.T) {
+	jwtManager, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+
+	id := uuid.New()
+	token, err := jwtManager.GenerateEmailToken("testuser", "user", id, "test@example.com", time.Hour)
+	require.NoError(t, err)
+
+	claims, err := jwtManager.DecodeJWT(token)
+	require.NoError(t, err)
+	assert.Equal(t, utils.EmailToken, claims.TokenType)
+	assert.Equal(t, "test@example.com", claims.Email)
+	assert.Equal(t, id.String(), claims.Subject)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().VerifyEmail(gomock.Any(), "email-token").Return(nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email", strings.NewReader(`{"token":"email-token"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestVerifyEmail_InvalidToken(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().VerifyEmail(gomock.Any(), "expired").Return(errs.ErrEmailTokenInvalid)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email", strings.NewReader(`{"token":"expired"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestResendVerification(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().ResendVerification(gomock.Any(), "test@example.com").Return(nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/verify-email/resend",
+		strings.NewReader(`{"email":"test@example.com"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestLogin_EmailNotVerified(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), "testuser", "password123", gomock.Any()).
+		Return(models.Tokens{}, errs.ErrEmailNotVerified)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		strings.NewReader(`{"username":"testuser","password":"password123"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "email is not verified")
+	assert.Empty(t, w.Result().Cookies())
+}
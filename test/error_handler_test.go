@@ -0,0 +1,69 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/delivery/middleware"
+	"service-auth/internal/app/errs"
+)
+
+func TestErrorHandler_RetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name       string
+		err        error
+		status     int
+		retryAfter string
+	}{
+		{"account locked", errs.WithRetryAfter(errs.ErrAccountLocked, 90*time.Second), http.StatusLocked, "90"},
+		{"too many attempts", errs.WithRetryAfter(errs.ErrTooManyAttempts, 1500*time.Millisecond), http.StatusTooManyRequests, "2"},
+		{"without retry", errs.ErrInvalidPwd, http.StatusForbidden, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.POST("/login", func(c *gin.Context) {
+				_ = c.Error(tc.err)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.retryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestErrorHandler_ValidationFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.POST("/register", func(c *gin.Context) {
+		_ = c.Error(&errs.ValidationError{
+			Err:    errs.ErrWeakPassword,
+			Fields: map[string]string{"Password": "must contain a digit"},
+		})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/register", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp middleware.ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Validation error", resp.Error.Message)
+	assert.Equal(t, "must contain a digit", resp.Error.Fields["Password"])
+}
@@ -0,0 +1,432 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+// fakePostgres PostgresRepository в памяти для тестов сервисов.
+// Повторяет условия запросов PostgresRepo, которые влияют на поведение сервисов
+type fakePostgres struct {
+	mu            sync.Mutex
+	users         map[uuid.UUID]*fakeUser
+	audit         map[uuid.UUID][]models.AuditEvent
+	recoveryCodes map[uuid.UUID]map[string]bool // хэш кода -> использован
+	credentials   []models.WebAuthnCredential
+}
+
+type fakeUser struct {
+	models.GetUserResponse
+	totp models.TOTP
+}
+
+func newFakePostgres() *fakePostgres {
+	return &fakePostgres{
+		users:         make(map[uuid.UUID]*fakeUser),
+		audit:         make(map[uuid.UUID][]models.AuditEvent),
+		recoveryCodes: make(map[uuid.UUID]map[string]bool),
+	}
+}
+
+// update меняет пользователя под блокировкой, как одна UPDATE запись
+func (r *fakePostgres) update(id uuid.UUID, fn func(user *fakeUser)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		fn(user)
+	}
+}
+
+// user возвращает копию пользователя (для проверок в тестах)
+func (r *fakePostgres) user(id uuid.UUID) (models.GetUserResponse, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return models.GetUserResponse{}, false
+	}
+	return user.GetUserResponse, true
+}
+
+// events названия событий журнала пользователя
+func (r *fakePostgres) events(id uuid.UUID) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var names []string
+	for _, event := range r.audit[id] {
+		names = append(names, event.Event)
+	}
+	return names
+}
+
+func (r *fakePostgres) emailTaken(email string, except uuid.UUID) bool {
+	for id, user := range r.users {
+		if id == except {
+			continue
+		}
+		if user.Email == email || (user.PendingEmail != nil && *user.PendingEmail == email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakePostgres) find(match func(user *fakeUser) bool) (models.GetUserResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if match(user) {
+			return user.GetUserResponse, nil
+		}
+	}
+	return models.GetUserResponse{}, errs.ErrUserNotFound
+}
+
+func (r *fakePostgres) CreateUser(_ context.Context, input models.UserInput) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Username == input.Username {
+			return uuid.UUID{}, errs.ErrUserAlreadyExists
+		}
+	}
+	if r.emailTaken(input.Email, uuid.Nil) {
+		return uuid.UUID{}, errs.ErrEmailAlreadyUsed
+	}
+
+	now := time.Now()
+	user := &fakeUser{GetUserResponse: models.GetUserResponse{
+		ID:            uuid.New(),
+		Username:      input.Username,
+		Password:      input.Password,
+		Email:         input.Email,
+		Role:          "user",
+		CreateAt:      now,
+		UpdateAt:      now,
+		PepperVersion: input.PepperVersion,
+	}}
+	r.users[user.ID] = user
+	return user.ID, nil
+}
+
+func (r *fakePostgres) GetUser(_ context.Context, username string) (models.GetUserResponse, error) {
+	return r.find(func(user *fakeUser) bool { return user.Username == username })
+}
+
+func (r *fakePostgres) GetUserByID(_ context.Context, id uuid.UUID) (models.GetUserResponse, error) {
+	return r.find(func(user *fakeUser) bool { return user.ID == id })
+}
+
+func (r *fakePostgres) RegisterFailedLogin(_ context.Context, id uuid.UUID, threshold int, lockout time.Duration) (*time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errs.ErrUserNotFound
+	}
+	user.FailedLoginAttempts++
+	if user.FailedLoginAttempts >= threshold {
+		user.FailedLoginAttempts = 0
+		lockedUntil := time.Now().Add(lockout)
+		user.LockedUntil = &lockedUntil
+	}
+	return user.LockedUntil, nil
+}
+
+func (r *fakePostgres) ResetFailedLogins(_ context.Context, id uuid.UUID) error {
+	r.update(id, func(user *fakeUser) {
+		user.FailedLoginAttempts = 0
+		user.LockedUntil = nil
+	})
+	return nil
+}
+
+func (r *fakePostgres) UpdatePasswordHash(_ context.Context, id uuid.UUID, oldHash, newHash string, pepperVersion int) error {
+	r.update(id, func(user *fakeUser) {
+		if user.Password == oldHash {
+			user.Password = newHash
+			user.PepperVersion = pepperVersion
+		}
+	})
+	return nil
+}
+
+func (r *fakePostgres) GetTOTP(_ context.Context, id uuid.UUID) (models.TOTP, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return models.TOTP{}, errs.ErrUserNotFound
+	}
+	return user.totp, nil
+}
+
+func (r *fakePostgres) SetPendingTOTP(_ context.Context, id uuid.UUID, secret []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.totp.Enabled() {
+		return errs.ErrMFAAlreadyEnabled
+	}
+	user.totp = models.TOTP{Secret: secret}
+	return nil
+}
+
+func (r *fakePostgres) EnableTOTP(_ context.Context, id uuid.UUID, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.totp.Secret == nil || user.totp.Enabled() {
+		return errs.ErrMFAAlreadyEnabled
+	}
+	now := time.Now()
+	user.totp.EnabledAt = &now
+	user.totp.LastStep = step
+	user.MFAEnabled = true
+	return nil
+}
+
+func (r *fakePostgres) UseTOTPStep(_ context.Context, id uuid.UUID, step int64) (bool, error) {
+	var used bool
+	r.update(id, func(user *fakeUser) {
+		if user.totp.LastStep < step {
+			user.totp.LastStep = step
+			used = true
+		}
+	})
+	return used, nil
+}
+
+func (r *fakePostgres) ReplaceRecoveryCodes(_ context.Context, userID uuid.UUID, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	codes := make(map[string]bool, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes[hash] = false
+	}
+	r.recoveryCodes[userID] = codes
+	return nil
+}
+
+func (r *fakePostgres) UseRecoveryCode(_ context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	used, ok := r.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (r *fakePostgres) CountRecoveryCodes(_ context.Context, userID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, used := range r.recoveryCodes[userID] {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakePostgres) CreateWebAuthnCredential(_ context.Context, cred models.WebAuthnCredential) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.credentials {
+		if bytes.Equal(existing.CredentialID, cred.CredentialID) {
+			return uuid.UUID{}, errs.ErrWebAuthnCredentialExists
+		}
+	}
+	cred.ID = uuid.New()
+	cred.CreatedAt = time.Now()
+	r.credentials = append(r.credentials, cred)
+	return cred.ID, nil
+}
+
+func (r *fakePostgres) GetWebAuthnCredential(_ context.Context, credentialID []byte) (models.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cred := range r.credentials {
+		if bytes.Equal(cred.CredentialID, credentialID) {
+			return cred, nil
+		}
+	}
+	return models.WebAuthnCredential{}, errs.ErrWebAuthnCredentialNotFound
+}
+
+func (r *fakePostgres) GetWebAuthnCredentials(_ context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var creds []models.WebAuthnCredential
+	for _, cred := range r.credentials {
+		if cred.UserID == userID {
+			creds = append(creds, cred)
+		}
+	}
+	sort.Slice(creds, func(i, j int) bool { return creds[i].CreatedAt.Before(creds[j].CreatedAt) })
+	return creds, nil
+}
+
+func (r *fakePostgres) UpdateWebAuthnSignCount(_ context.Context, id uuid.UUID, oldCount, newCount uint32) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.credentials {
+		if r.credentials[i].ID == id && r.credentials[i].SignCount == oldCount {
+			now := time.Now()
+			r.credentials[i].SignCount = newCount
+			r.credentials[i].LastUsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakePostgres) GetUserByEmail(_ context.Context, email string) (models.GetUserResponse, error) {
+	return r.find(func(user *fakeUser) bool { return user.Email == email })
+}
+
+func (r *fakePostgres) VerifyEmail(_ context.Context, id uuid.UUID, email string) (bool, error) {
+	var verified bool
+	r.update(id, func(user *fakeUser) {
+		if user.Email == email && !user.EmailVerified {
+			user.EmailVerified = true
+			verified = true
+		}
+	})
+	return verified, nil
+}
+
+func (r *fakePostgres) SetPasswordHash(_ context.Context, id uuid.UUID, hash string, pepperVersion int) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return time.Time{}, errs.ErrUserNotFound
+	}
+	now := time.Now()
+	user.Password = hash
+	user.PepperVersion = pepperVersion
+	user.PasswordChangedAt = &now
+	return now, nil
+}
+
+func (r *fakePostgres) ChangePassword(_ context.Context, id uuid.UUID, oldHash, newHash string, pepperVersion int) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.Password != oldHash {
+		return time.Time{}, errs.ErrInvalidPwd
+	}
+	now := time.Now()
+	user.Password = newHash
+	user.PepperVersion = pepperVersion
+	user.PasswordChangedAt = &now
+	return now, nil
+}
+
+func (r *fakePostgres) UpdateUsername(_ context.Context, id uuid.UUID, username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return errs.ErrUserNotFound
+	}
+	for otherID, other := range r.users {
+		if otherID != id && other.Username == username {
+			return errs.ErrUserAlreadyExists
+		}
+	}
+	user.Username = username
+	return nil
+}
+
+func (r *fakePostgres) SetPendingEmail(_ context.Context, id uuid.UUID, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return errs.ErrUserNotFound
+	}
+	if r.emailTaken(email, id) {
+		return errs.ErrEmailAlreadyUsed
+	}
+	user.PendingEmail = &email
+	return nil
+}
+
+func (r *fakePostgres) ConfirmEmailChange(_ context.Context, id uuid.UUID, email string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.PendingEmail == nil || *user.PendingEmail != email {
+		return false, nil
+	}
+	if r.emailTaken(email, id) {
+		return false, errs.ErrEmailAlreadyUsed
+	}
+	user.Email = email
+	user.PendingEmail = nil
+	user.EmailVerified = true
+	return true, nil
+}
+
+func (r *fakePostgres) MarkUserDeleted(_ context.Context, id uuid.UUID) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt != nil {
+		return time.Time{}, errs.ErrUserNotFound
+	}
+	now := time.Now()
+	user.DeletedAt = &now
+	return now, nil
+}
+
+func (r *fakePostgres) RestoreUser(_ context.Context, id uuid.UUID, deletedAfter time.Time) (bool, error) {
+	var restored bool
+	r.update(id, func(user *fakeUser) {
+		if user.DeletedAt != nil && user.DeletedAt.After(deletedAfter) {
+			user.DeletedAt = nil
+			restored = true
+		}
+	})
+	return restored, nil
+}
+
+func (r *fakePostgres) PurgeDeletedUsers(_ context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uuid.UUID
+	for id, user := range r.users {
+		if user.DeletedAt != nil && !user.DeletedAt.After(deletedBefore) {
+			delete(r.users, id)
+			delete(r.recoveryCodes, id)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakePostgres) SaveAuditEvent(_ context.Context, userID uuid.UUID, event models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.CreatedAt = time.Now()
+	r.audit[userID] = append(r.audit[userID], event)
+	return nil
+}
+
+func (r *fakePostgres) GetAuditEvents(_ context.Context, userID uuid.UUID) ([]models.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.AuditEvent(nil), r.audit[userID]...), nil
+}
@@ -0,0 +1,686 @@
+package test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis минимальный redis (RESP2) в памяти для тестов сервисов поверх настоящего RedisRepo.
+// Поддерживает только команды, которые использует репозиторий, включая MULTI/EXEC и WATCH
+type fakeRedis struct {
+	mu       sync.Mutex
+	keys     map[string]*fakeRedisValue
+	versions map[string]uint64 // версия ключа для WATCH, растет при каждом изменении
+	revision uint64
+	listener net.Listener
+}
+
+type fakeRedisValue struct {
+	str      string
+	hash     map[string]string
+	set      map[string]struct{}
+	zset     map[string]float64
+	expireAt time.Time // нулевое - без срока
+}
+
+// fakeRedisConn состояние соединения: открытая транзакция и наблюдаемые ключи
+type fakeRedisConn struct {
+	multi   bool
+	queued  [][]string
+	watched map[string]uint64
+}
+
+var errFakeRedisSyntax = errors.New("ERR syntax error")
+
+func newFakeRedis(t *testing.T) (*fakeRedis, *redis.Client) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fakeRedis{
+		keys:     make(map[string]*fakeRedisValue),
+		versions: make(map[string]uint64),
+		listener: listener,
+	}
+	go server.serve()
+
+	client := redis.NewClient(&redis.Options{
+		Addr:             listener.Addr().String(),
+		Protocol:         2,
+		DisableIndentity: true,
+	})
+	t.Cleanup(func() {
+		client.Close()
+		listener.Close()
+	})
+	return server, client
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	state := &fakeRedisConn{}
+
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		writeRESP(writer, s.dispatch(state, args))
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// readRESPCommand читает команду клиента: массив bulk строк
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimRight(header, "\r\n")[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// respStatus простая строка ответа (+OK)
+type respStatus string
+
+// writeRESP кодирует ответ: nil - пустая bulk строка, []any(nil) - пустой массив (отмененный EXEC)
+func writeRESP(w *bufio.Writer, reply any) {
+	switch v := reply.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case respStatus:
+		fmt.Fprintf(w, "+%s\r\n", v)
+	case error:
+		fmt.Fprintf(w, "-%s\r\n", v.Error())
+	case int:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []any:
+		if v == nil {
+			w.WriteString("*-1\r\n")
+			return
+		}
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeRESP(w, item)
+		}
+	default:
+		panic(fmt.Sprintf("fake redis: unsupported reply %T", reply))
+	}
+}
+
+func (s *fakeRedis) dispatch(conn *fakeRedisConn, args []string) any {
+	if len(args) == 0 {
+		return errFakeRedisSyntax
+	}
+	name := strings.ToUpper(args[0])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "MULTI":
+		conn.multi = true
+		conn.queued = nil
+		return respStatus("OK")
+	case "DISCARD":
+		conn.multi = false
+		conn.queued = nil
+		conn.watched = nil
+		return respStatus("OK")
+	case "EXEC":
+		queued := conn.queued
+		watched := conn.watched
+		conn.multi = false
+		conn.queued = nil
+		conn.watched = nil
+		for key, version := range watched {
+			if s.versions[key] != version {
+				return []any(nil)
+			}
+		}
+		replies := make([]any, 0, len(queued))
+		for _, cmd := range queued {
+			replies = append(replies, s.exec(cmd))
+		}
+		return replies
+	case "WATCH":
+		if conn.watched == nil {
+			conn.watched = make(map[string]uint64)
+		}
+		for _, key := range args[1:] {
+			s.expire(key)
+			conn.watched[key] = s.versions[key]
+		}
+		return respStatus("OK")
+	case "UNWATCH":
+		conn.watched = nil
+		return respStatus("OK")
+	}
+
+	if conn.multi {
+		conn.queued = append(conn.queued, args)
+		return respStatus("QUEUED")
+	}
+	return s.exec(args)
+}
+
+// exec выполняет одну команду; вызывается под s.mu
+func (s *fakeRedis) exec(args []string) any {
+	name := strings.ToUpper(args[0])
+	args = args[1:]
+	for _, key := range commandKeys(name, args) {
+		s.expire(key)
+	}
+
+	switch name {
+	case "PING":
+		return respStatus("PONG")
+	case "GET":
+		if v, ok := s.keys[args[0]]; ok {
+			return v.str
+		}
+		return nil
+	case "GETDEL":
+		v, ok := s.keys[args[0]]
+		if !ok {
+			return nil
+		}
+		s.delete(args[0])
+		return v.str
+	case "SET":
+		return s.set(args)
+	case "SETNX":
+		if _, ok := s.keys[args[0]]; ok {
+			return 0
+		}
+		s.keys[args[0]] = &fakeRedisValue{str: args[1]}
+		s.touch(args[0])
+		return 1
+	case "DEL":
+		deleted := 0
+		for _, key := range args {
+			if _, ok := s.keys[key]; ok {
+				s.delete(key)
+				deleted++
+			}
+		}
+		return deleted
+	case "EXISTS":
+		count := 0
+		for _, key := range args {
+			if _, ok := s.keys[key]; ok {
+				count++
+			}
+		}
+		return count
+	case "EXPIRE", "PEXPIRE", "EXPIREAT", "PEXPIREAT":
+		return s.setExpire(name, args)
+	case "TTL", "PTTL":
+		v, ok := s.keys[args[0]]
+		switch {
+		case !ok:
+			return -2
+		case v.expireAt.IsZero():
+			return -1
+		case name == "TTL":
+			return int(math.Ceil(time.Until(v.expireAt).Seconds()))
+		default:
+			return int(time.Until(v.expireAt).Milliseconds())
+		}
+	case "HSET":
+		v := s.value(args[0])
+		if v.hash == nil {
+			v.hash = make(map[string]string)
+		}
+		added := 0
+		for i := 1; i+1 < len(args); i += 2 {
+			if _, ok := v.hash[args[i]]; !ok {
+				added++
+			}
+			v.hash[args[i]] = args[i+1]
+		}
+		s.touch(args[0])
+		return added
+	case "HGETALL":
+		reply := []any{}
+		if v, ok := s.keys[args[0]]; ok {
+			for _, field := range sortedKeys(v.hash) {
+				reply = append(reply, field, v.hash[field])
+			}
+		}
+		return reply
+	case "HMGET":
+		reply := make([]any, 0, len(args)-1)
+		v := s.keys[args[0]]
+		for _, field := range args[1:] {
+			if value, ok := v.hashField(field); ok {
+				reply = append(reply, value)
+			} else {
+				reply = append(reply, nil)
+			}
+		}
+		return reply
+	case "SADD":
+		v := s.value(args[0])
+		if v.set == nil {
+			v.set = make(map[string]struct{})
+		}
+		added := 0
+		for _, member := range args[1:] {
+			if _, ok := v.set[member]; !ok {
+				v.set[member] = struct{}{}
+				added++
+			}
+		}
+		s.touch(args[0])
+		return added
+	case "SREM":
+		v, ok := s.keys[args[0]]
+		if !ok {
+			return 0
+		}
+		removed := 0
+		for _, member := range args[1:] {
+			if _, ok := v.set[member]; ok {
+				delete(v.set, member)
+				removed++
+			}
+		}
+		s.touch(args[0])
+		s.dropEmpty(args[0])
+		return removed
+	case "SMEMBERS":
+		reply := []any{}
+		if v, ok := s.keys[args[0]]; ok {
+			for _, member := range sortedKeys(v.set) {
+				reply = append(reply, member)
+			}
+		}
+		return reply
+	case "SISMEMBER":
+		if v, ok := s.keys[args[0]]; ok {
+			if _, ok := v.set[args[1]]; ok {
+				return 1
+			}
+		}
+		return 0
+	case "ZADD":
+		v := s.value(args[0])
+		if v.zset == nil {
+			v.zset = make(map[string]float64)
+		}
+		added := 0
+		for i := 1; i+1 < len(args); i += 2 {
+			score, err := strconv.ParseFloat(args[i], 64)
+			if err != nil {
+				return errFakeRedisSyntax
+			}
+			if _, ok := v.zset[args[i+1]]; !ok {
+				added++
+			}
+			v.zset[args[i+1]] = score
+		}
+		s.touch(args[0])
+		return added
+	case "ZCOUNT":
+		return len(s.zrangeByScore(args[0], args[1], args[2]))
+	case "ZRANGEBYSCORE":
+		return s.zrangeReply(args[0], args[1], args[2], args[3:])
+	case "ZREMRANGEBYSCORE":
+		members := s.zrangeByScore(args[0], args[1], args[2])
+		for _, m := range members {
+			delete(s.keys[args[0]].zset, m.member)
+		}
+		if len(members) > 0 {
+			s.touch(args[0])
+			s.dropEmpty(args[0])
+		}
+		return len(members)
+	case "ZREVRANGE":
+		return s.zrevrange(args)
+	}
+	return fmt.Errorf("ERR unknown command '%s'", strings.ToLower(name))
+}
+
+// commandKeys ключи команды, срок которых нужно проверить перед выполнением
+func commandKeys(name string, args []string) []string {
+	switch name {
+	case "PING":
+		return nil
+	case "DEL", "EXISTS":
+		return args
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return args[:1]
+}
+
+func (s *fakeRedis) set(args []string) any {
+	key, value := args[0], args[1]
+	var ttl time.Duration
+	var nx bool
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return errFakeRedisSyntax
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return errFakeRedisSyntax
+			}
+			ttl = time.Duration(n) * time.Millisecond
+			if strings.EqualFold(args[i], "EX") {
+				ttl = time.Duration(n) * time.Second
+			}
+			i++
+		case "NX":
+			nx = true
+		default:
+			return errFakeRedisSyntax
+		}
+	}
+	if _, ok := s.keys[key]; ok && nx {
+		return nil
+	}
+
+	v := &fakeRedisValue{str: value}
+	if ttl > 0 {
+		v.expireAt = time.Now().Add(ttl)
+	}
+	s.keys[key] = v
+	s.touch(key)
+	return respStatus("OK")
+}
+
+func (s *fakeRedis) setExpire(name string, args []string) any {
+	v, ok := s.keys[args[0]]
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errFakeRedisSyntax
+	}
+	switch name {
+	case "EXPIRE":
+		v.expireAt = time.Now().Add(time.Duration(n) * time.Second)
+	case "PEXPIRE":
+		v.expireAt = time.Now().Add(time.Duration(n) * time.Millisecond)
+	case "EXPIREAT":
+		v.expireAt = time.Unix(n, 0)
+	case "PEXPIREAT":
+		v.expireAt = time.UnixMilli(n)
+	}
+	s.touch(args[0])
+	s.expire(args[0])
+	return 1
+}
+
+type fakeZMember struct {
+	member string
+	score  float64
+}
+
+// zrangeByScore элементы sorted set в диапазоне [min, max] по возрастанию score
+func (s *fakeRedis) zrangeByScore(key, minScore, maxScore string) []fakeZMember {
+	v, ok := s.keys[key]
+	if !ok {
+		return nil
+	}
+	lo, loExclusive := parseScoreBound(minScore)
+	hi, hiExclusive := parseScoreBound(maxScore)
+
+	var members []fakeZMember
+	for member, score := range v.zset {
+		if score < lo || (loExclusive && score == lo) || score > hi || (hiExclusive && score == hi) {
+			continue
+		}
+		members = append(members, fakeZMember{member: member, score: score})
+	}
+	sortZMembers(members)
+	return members
+}
+
+func (s *fakeRedis) zrangeReply(key, minScore, maxScore string, options []string) any {
+	members := s.zrangeByScore(key, minScore, maxScore)
+	withScores := false
+	for i := 0; i < len(options); i++ {
+		switch strings.ToUpper(options[i]) {
+		case "WITHSCORES":
+			withScores = true
+		case "LIMIT":
+			if i+2 >= len(options) {
+				return errFakeRedisSyntax
+			}
+			offset, _ := strconv.Atoi(options[i+1])
+			count, _ := strconv.Atoi(options[i+2])
+			members = members[min(offset, len(members)):]
+			if count >= 0 && count < len(members) {
+				members = members[:count]
+			}
+			i += 2
+		}
+	}
+	return zmembersReply(members, withScores)
+}
+
+func (s *fakeRedis) zrevrange(args []string) any {
+	var members []fakeZMember
+	if v, ok := s.keys[args[0]]; ok {
+		for member, score := range v.zset {
+			members = append(members, fakeZMember{member: member, score: score})
+		}
+	}
+	sortZMembers(members)
+	for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+		members[i], members[j] = members[j], members[i]
+	}
+
+	start, _ := strconv.Atoi(args[1])
+	stop, _ := strconv.Atoi(args[2])
+	if stop < 0 {
+		stop += len(members)
+	}
+	if start >= len(members) || start > stop {
+		members = nil
+	} else {
+		members = members[start:min(stop+1, len(members))]
+	}
+	withScores := len(args) > 3 && strings.EqualFold(args[3], "WITHSCORES")
+	return zmembersReply(members, withScores)
+}
+
+func zmembersReply(members []fakeZMember, withScores bool) []any {
+	reply := []any{}
+	for _, m := range members {
+		reply = append(reply, m.member)
+		if withScores {
+			reply = append(reply, strconv.FormatFloat(m.score, 'f', -1, 64))
+		}
+	}
+	return reply
+}
+
+func sortZMembers(members []fakeZMember) {
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].score != members[j].score {
+			return members[i].score < members[j].score
+		}
+		return members[i].member < members[j].member
+	})
+}
+
+// parseScoreBound разбирает границу диапазона score: -inf, +inf, (x - не включая x
+func parseScoreBound(bound string) (float64, bool) {
+	exclusive := strings.HasPrefix(bound, "(")
+	bound = strings.TrimPrefix(bound, "(")
+	switch bound {
+	case "-inf":
+		return math.Inf(-1), exclusive
+	case "+inf", "inf":
+		return math.Inf(1), exclusive
+	}
+	score, _ := strconv.ParseFloat(bound, 64)
+	return score, exclusive
+}
+
+// value возвращает значение ключа, создавая его при отсутствии
+func (s *fakeRedis) value(key string) *fakeRedisValue {
+	v, ok := s.keys[key]
+	if !ok {
+		v = &fakeRedisValue{}
+		s.keys[key] = v
+	}
+	return v
+}
+
+func (v *fakeRedisValue) hashField(field string) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+	value, ok := v.hash[field]
+	return value, ok
+}
+
+// touch отмечает изменение ключа для WATCH
+func (s *fakeRedis) touch(key string) {
+	s.revision++
+	s.versions[key] = s.revision
+}
+
+func (s *fakeRedis) delete(key string) {
+	delete(s.keys, key)
+	s.touch(key)
+}
+
+// expire удаляет ключ с истекшим сроком
+func (s *fakeRedis) expire(key string) {
+	if v, ok := s.keys[key]; ok && !v.expireAt.IsZero() && !time.Now().Before(v.expireAt) {
+		s.delete(key)
+	}
+}
+
+// dropEmpty удаляет опустевшие множество и sorted set, как это делает redis
+func (s *fakeRedis) dropEmpty(key string) {
+	if v, ok := s.keys[key]; ok && v.str == "" && len(v.hash) == 0 && len(v.set) == 0 && len(v.zset) == 0 {
+		delete(s.keys, key)
+	}
+}
+
+// keysWithPrefix ключи с префиксом prefix (для проверок в тестах)
+func (s *fakeRedis) keysWithPrefix(prefix string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key := range s.keys {
+		s.expire(key)
+		if _, ok := s.keys[key]; ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ttl оставшийся срок ключа; 0 - ключа нет или он без срока
+func (s *fakeRedis) ttl(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(key)
+	if v, ok := s.keys[key]; ok && !v.expireAt.IsZero() {
+		return time.Until(v.expireAt)
+	}
+	return 0
+}
+
+// stores сообщает, встречается ли substr в каком-либо ключе или значении (для проверок в тестах)
+func (s *fakeRedis) stores(substr string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, v := range s.keys {
+		if strings.Contains(key, substr) || strings.Contains(v.str, substr) {
+			return true
+		}
+		for field, value := range v.hash {
+			if strings.Contains(field, substr) || strings.Contains(value, substr) {
+				return true
+			}
+		}
+		for member := range v.set {
+			if strings.Contains(member, substr) {
+				return true
+			}
+		}
+		for member := range v.zset {
+			if strings.Contains(member, substr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
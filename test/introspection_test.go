@@ -0,0 +1,67 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospect_ActiveTokens(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "introuser")
+	tokens := env.login(t, "introuser", testPassword)
+
+	access, err := env.services.Introspect(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, access.Active)
+	assert.Equal(t, userID.String(), access.Sub)
+	assert.Equal(t, "introuser", access.Username)
+	assert.Equal(t, "user", access.Role)
+	assert.Equal(t, "access_token", access.TokenType)
+	assert.NotEmpty(t, access.Jti)
+	assert.Greater(t, access.Exp, access.Iat)
+
+	refresh, err := env.services.Introspect(ctx, tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.True(t, refresh.Active)
+	assert.Equal(t, "refresh_token", refresh.TokenType)
+}
+
+func TestIntrospect_InactiveTokens(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "introuser")
+	tokens := env.login(t, "introuser", testPassword)
+
+	rotated, err := env.services.RefreshTokens(ctx, tokens.RefreshToken, testClient)
+	require.NoError(t, err)
+
+	mfaToken, err := env.jwt.GenerateMFAToken("introuser", "user", userID, time.Minute)
+	require.NoError(t, err)
+
+	// недействительный токен - active=false без ошибки и без подробностей
+	for name, token := range map[string]string{
+		"malformed":          "not-a-jwt",
+		"rotated refresh":    tokens.RefreshToken,
+		"unsupported type":   mfaToken,
+		"tampered signature": tokens.AccessToken[:len(tokens.AccessToken)-2] + "xx",
+	} {
+		response, err := env.services.Introspect(ctx, token)
+		require.NoError(t, err, name)
+		assert.False(t, response.Active, name)
+		assert.Empty(t, response.Sub, name)
+	}
+
+	// интроспекция старого токена не считается повторным использованием
+	_, err = env.services.RefreshTokens(ctx, rotated.RefreshToken, testClient)
+	assert.NoError(t, err)
+
+	require.NoError(t, env.services.RevokeAccessToken(ctx, tokens.AccessToken))
+	response, err := env.services.Introspect(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.False(t, response.Active)
+}
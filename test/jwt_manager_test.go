@@ -0,0 +1,236 @@
+package test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/utils"
+)
+
+const (
+	privateKeyPath = "../internal/certs/jwt-private.pem"
+	publicKeyPath  = "../internal/certs/jwt-public.pem"
+)
+
+func TestJWKS(t *testing.T) {
+	jwtManager, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+
+	jwks := jwtManager.JWKS()
+	require.Len(t, jwks.Keys, 1)
+
+	key := jwks.Keys[0]
+	assert.Equal(t, "RSA", key.Kty)
+	assert.Equal(t, "sig", key.Use)
+	assert.Equal(t, "RS256", key.Alg)
+	assert.Equal(t, jwtManager.KeyID(), key.Kid)
+	assert.NotEmpty(t, key.N)
+	assert.Equal(t, "AQAB", key.E)
+}
+
+func TestTokenHasKid(t *testing.T) {
+	jwtManager, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+
+	token, err := jwtManager.GenerateAccessToken("testuser", "user", uuid.New(), uuid.NewString(), time.Minute)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, jwtManager.KeyID(), parsed.Header["kid"])
+	assert.NotEmpty(t, parsed.Claims.(jwt.MapClaims)["jti"])
+
+	_, err = jwtManager.DecodeJWT(token)
+	assert.NoError(t, err)
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	jwtManager, err := utils.NewJWTManagerFromDir(utils.AlgRS256, dir, time.Hour)
+	require.NoError(t, err)
+	oldKid := jwtManager.KeyID()
+
+	oldToken, err := jwtManager.GenerateRefreshToken("testuser", "user", uuid.New(), uuid.NewString(), time.Minute)
+	require.NoError(t, err)
+
+	newKid, err := jwtManager.RotateKey("")
+	require.NoError(t, err)
+	assert.NotEqual(t, oldKid, newKid)
+	assert.Equal(t, newKid, jwtManager.KeyID())
+	assert.Len(t, jwtManager.JWKS().Keys, 2)
+
+	// Токен, подписанный выведенным ключом, продолжает проверяться
+	_, err = jwtManager.DecodeJWT(oldToken)
+	assert.NoError(t, err)
+
+	// Связка восстанавливается из директории
+	reloaded, err := utils.NewJWTManagerFromDir(utils.AlgRS256, dir, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, newKid, reloaded.KeyID())
+	_, err = reloaded.DecodeJWT(oldToken)
+	assert.NoError(t, err)
+}
+
+func TestRetiredKeyRemoved(t *testing.T) {
+	jwtManager, err := utils.NewJWTManagerFromDir(utils.AlgRS256, t.TempDir(), 0)
+	require.NoError(t, err)
+
+	oldToken, err := jwtManager.GenerateAccessToken("testuser", "user", uuid.New(), uuid.NewString(), time.Minute)
+	require.NoError(t, err)
+
+	_, err = jwtManager.RotateKey("")
+	require.NoError(t, err)
+	require.NoError(t, jwtManager.Reload())
+
+	assert.Len(t, jwtManager.JWKS().Keys, 1)
+	_, err = jwtManager.DecodeJWT(oldToken)
+	assert.Error(t, err)
+}
+
+func TestSigningAlgorithms(t *testing.T) {
+	tests := []struct {
+		alg string
+		kty string
+		crv string
+	}{
+		{alg: utils.AlgRS256, kty: "RSA"},
+		{alg: utils.AlgES256, kty: "EC", crv: "P-256"},
+		{alg: utils.AlgEdDSA, kty: "OKP", crv: "Ed25519"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			jwtManager, err := utils.NewJWTManagerFromDir(tt.alg, t.TempDir(), time.Hour)
+			require.NoError(t, err)
+
+			jwks := jwtManager.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.alg, jwks.Keys[0].Alg)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.crv, jwks.Keys[0].Crv)
+
+			token, err := jwtManager.GenerateAccessToken("testuser", "user", uuid.New(), uuid.NewString(), time.Minute)
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, parsed.Method.Alg())
+
+			_, err = jwtManager.DecodeJWT(token)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAlgorithmBoundToKey(t *testing.T) {
+	jwtManager, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+
+	token, err := jwtManager.GenerateAccessToken("testuser", "user", uuid.New(), uuid.NewString(), time.Minute)
+	require.NoError(t, err)
+
+	// Тот же ключ, но другой алгоритм RSA в заголовке - токен отклоняется
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodPS256, parsed.Claims)
+	forged.Header["kid"] = jwtManager.KeyID()
+	privateKeyData, err := os.ReadFile(privateKeyPath)
+	require.NoError(t, err)
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyData)
+	require.NoError(t, err)
+	forgedToken, err := forged.SignedString(privateKey)
+	require.NoError(t, err)
+
+	_, err = jwtManager.DecodeJWT(forgedToken)
+	assert.Error(t, err)
+
+	// Ключ не соответствует алгоритму из конфигурации
+	_, err = utils.NewJWTManager(utils.AlgES256, privateKeyPath, publicKeyPath)
+	assert.Error(t, err)
+}
+
+func TestTypedClaims(t *testing.T) {
+	jwtManager, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+	jwtManager.WithIssuer("service-auth", "api")
+
+	id := uuid.New()
+	familyID := uuid.NewString()
+	token, err := jwtManager.GenerateRefreshToken("testuser", "admin", id, familyID, time.Minute)
+	require.NoError(t, err)
+
+	claims, err := jwtManager.DecodeJWT(token)
+	require.NoError(t, err)
+	assert.Equal(t, "testuser", claims.Username)
+	assert.Equal(t, "admin", claims.Role)
+	assert.Equal(t, utils.RefreshToken, claims.TokenType)
+	assert.Equal(t, familyID, claims.FamilyID)
+	assert.Equal(t, "service-auth", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"api"}, claims.Audience)
+	assert.NotEmpty(t, claims.ID)
+	assert.NotNil(t, claims.NotBefore)
+
+	userID, err := claims.UserID()
+	require.NoError(t, err)
+	assert.Equal(t, id, userID)
+}
+
+func TestIssuerAndAudienceValidated(t *testing.T) {
+	issuer, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+	issuer.WithIssuer("other-issuer", "api")
+
+	token, err := issuer.GenerateAccessToken("testuser", "user", uuid.New(), uuid.NewString(), time.Minute)
+	require.NoError(t, err)
+
+	verifier, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+
+	verifier.WithIssuer("service-auth", "api")
+	_, err = verifier.DecodeJWT(token)
+	assert.Error(t, err)
+
+	issuer.WithIssuer("service-auth", "other-audience")
+	token, err = issuer.GenerateAccessToken("testuser", "user", uuid.New(), uuid.NewString(), time.Minute)
+	require.NoError(t, err)
+	_, err = verifier.DecodeJWT(token)
+	assert.Error(t, err)
+}
+
+func TestClockSkewLeeway(t *testing.T) {
+	jwtManager, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+
+	// Токен выпущен узлом, часы которого спешат на 10 секунд
+	issuedAt := time.Now().Add(10 * time.Second)
+	skewed := jwt.NewWithClaims(jwt.SigningMethodRS256, utils.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(time.Minute)),
+		},
+		TokenType: utils.AccessToken,
+	})
+	skewed.Header["kid"] = jwtManager.KeyID()
+	privateKeyData, err := os.ReadFile(privateKeyPath)
+	require.NoError(t, err)
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyData)
+	require.NoError(t, err)
+	token, err := skewed.SignedString(privateKey)
+	require.NoError(t, err)
+
+	_, err = jwtManager.DecodeJWT(token)
+	assert.Error(t, err)
+
+	jwtManager.WithLeeway(30 * time.Second)
+	_, err = jwtManager.DecodeJWT(token)
+	assert.NoError(t, err)
+}
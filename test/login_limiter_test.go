@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	handlers "service-auth/internal/app/delivery/http"
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+// loginFromProxy вход с неверным паролем с адреса соединения remoteAddr и заголовком X-Forwarded-For
+func loginFromProxy(router *gin.Engine, remoteAddr, forwardedFor string) int {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		strings.NewReader(`{"username":"proxyuser","password":"wrongpassword1"}`))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestLoginLimiter_IgnoresSpoofedForwardedFor(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Login.IPMaxFailures = 3
+	env := newServiceEnvWithConfig(t, cfg)
+	env.register(t, "proxyuser")
+	router := handlers.NewHandler(env.services, cfg).InitRoutes()
+
+	// без доверенных прокси каждый запрос подставляет новый X-Forwarded-For, но считается по адресу соединения
+	for i := 0; i < cfg.Login.IPMaxFailures; i++ {
+		code := loginFromProxy(router, "203.0.113.7:4000", fmt.Sprintf("198.51.100.%d", i))
+		assert.Equal(t, http.StatusForbidden, code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, loginFromProxy(router, "203.0.113.7:4000", "198.51.100.200"))
+}
+
+func TestLoginLimiter_TrustedProxyForwardedFor(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Login.IPMaxFailures = 3
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8"}
+	env := newServiceEnvWithConfig(t, cfg)
+	env.register(t, "proxyuser")
+	router := handlers.NewHandler(env.services, cfg).InitRoutes()
+
+	// от доверенного прокси лимит считается по адресу клиента из X-Forwarded-For
+	for i := 0; i < cfg.Login.IPMaxFailures; i++ {
+		assert.Equal(t, http.StatusForbidden, loginFromProxy(router, "10.0.0.2:4000", "198.51.100.1"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, loginFromProxy(router, "10.0.0.2:4000", "198.51.100.1"))
+	assert.Equal(t, http.StatusForbidden, loginFromProxy(router, "10.0.0.2:4000", "198.51.100.2"))
+}
+
+func TestLoginLimiter_IPWindow(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Login.IPMaxFailures = 2
+	cfg.Login.Window = time.Second
+	env := newServiceEnvWithConfig(t, cfg)
+	ctx := context.Background()
+	env.register(t, "limituser")
+
+	for i := 0; i < cfg.Login.IPMaxFailures; i++ {
+		_, err := env.services.GenerateTokens(ctx, "limituser", "wrongpassword1", testClient)
+		require.ErrorIs(t, err, errs.ErrInvalidPwd)
+	}
+
+	// лимит IP не пускает даже с верным паролем, пока попытки не выйдут из окна
+	_, err := env.services.GenerateTokens(ctx, "limituser", testPassword, testClient)
+	var retryErr *errs.RetryAfterError
+	require.ErrorAs(t, err, &retryErr)
+	assert.ErrorIs(t, err, errs.ErrTooManyAttempts)
+	assert.True(t, retryErr.RetryAfter > 0 && retryErr.RetryAfter <= cfg.Login.Window, "retry after %s", retryErr.RetryAfter)
+
+	// другой IP лимитом не затронут
+	other := models.ClientInfo{IP: "192.0.2.2"}
+	_, err = env.services.GenerateTokens(ctx, "limituser", testPassword, other)
+	assert.NoError(t, err)
+
+	time.Sleep(cfg.Login.Window + 50*time.Millisecond)
+	env.login(t, "limituser", testPassword)
+}
+
+func TestLoginLimiter_ProgressiveDelay(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Login.DelayAfter = 2
+	cfg.Login.BaseDelay = time.Hour
+	cfg.Login.MaxDelay = 2 * time.Hour
+	env := newServiceEnvWithConfig(t, cfg)
+	ctx := context.Background()
+	env.register(t, "delayuser")
+
+	for i := 0; i < cfg.Login.DelayAfter; i++ {
+		client := models.ClientInfo{IP: fmt.Sprintf("192.0.2.%d", 10+i)}
+		_, err := env.services.GenerateTokens(ctx, "delayuser", "wrongpassword1", client)
+		require.ErrorIs(t, err, errs.ErrInvalidPwd)
+	}
+
+	// задержка действует по логину, смена IP ее не обходит
+	_, err := env.services.GenerateTokens(ctx, "delayuser", testPassword, models.ClientInfo{IP: "192.0.2.99"})
+	var retryErr *errs.RetryAfterError
+	require.ErrorAs(t, err, &retryErr)
+	assert.ErrorIs(t, err, errs.ErrTooManyAttempts)
+	assert.InDelta(t, cfg.Login.BaseDelay.Seconds(), retryErr.RetryAfter.Seconds(), 5)
+}
+
+func TestLoginLimiter_AccountLockout(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Login.LockoutThreshold = 3
+	env := newServiceEnvWithConfig(t, cfg)
+	ctx := context.Background()
+	userID := env.register(t, "lockuser")
+
+	for i := 0; i < cfg.Login.LockoutThreshold-1; i++ {
+		_, err := env.services.GenerateTokens(ctx, "lockuser", "wrongpassword1", testClient)
+		require.ErrorIs(t, err, errs.ErrInvalidPwd)
+	}
+	_, err := env.services.GenerateTokens(ctx, "lockuser", "wrongpassword1", testClient)
+	assert.ErrorIs(t, err, errs.ErrAccountLocked)
+	assert.Contains(t, env.pg.events(userID), "account_locked")
+
+	// пока блокировка действует, верный пароль не принимается
+	_, err = env.services.GenerateTokens(ctx, "lockuser", testPassword, testClient)
+	assert.ErrorIs(t, err, errs.ErrAccountLocked)
+
+	// блокировка истекла: вход разрешен и сбрасывает счетчик
+	env.pg.update(userID, func(user *fakeUser) {
+		expired := time.Now().Add(-time.Second)
+		user.LockedUntil = &expired
+	})
+	env.login(t, "lockuser", testPassword)
+	user, _ := env.pg.user(userID)
+	assert.Nil(t, user.LockedUntil)
+	assert.Zero(t, user.FailedLoginAttempts)
+}
@@ -0,0 +1,311 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/mailer"
+)
+
+// fakeSMTPServer минимальный SMTP сервер: принимает одно письмо на соединение
+type fakeSMTPServer struct {
+	listener   net.Listener
+	rejectRcpt bool
+
+	mu       sync.Mutex
+	from     string
+	rcpt     string
+	messages []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(cmd)
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = cmd[len("MAIL FROM:"):]
+			s.mu.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			if s.rejectRcpt {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpt = cmd[len("RCPT TO:"):]
+			s.mu.Unlock()
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK queued")
+		case upper == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func newTestSMTPMailer(t *testing.T, server *fakeSMTPServer) *mailer.SMTPMailer {
+	m, err := mailer.NewSMTPMailer(mailer.SMTPParams{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		From:     "service-auth <no-reply@example.com>",
+		Security: mailer.SMTPSecurityNone,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(t, err)
+	return m
+}
+
+func TestSMTPMailer(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	m := newTestSMTPMailer(t, server)
+
+	err := m.Send(context.Background(), mailer.Message{
+		To:      "user@example.com",
+		Subject: "Подтвердите email",
+		Text:    "Текст письма",
+		HTML:    "<p>HTML письма</p>",
+	})
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "<no-reply@example.com>", server.from)
+	assert.Equal(t, "<user@example.com>", server.rcpt)
+	require.Len(t, server.messages, 1)
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.messages[0]))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Подтвердите email", subject)
+	assert.Equal(t, "<user@example.com>", msg.Header.Get("To"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8: Текст письма",
+		"text/html; charset=utf-8: <p>HTML письма</p>",
+	}, bodies)
+}
+
+func TestSMTPMailer_Rejected(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.rejectRcpt = true
+	m := newTestSMTPMailer(t, server)
+
+	err := m.Send(context.Background(), mailer.Message{To: "user@example.com", Subject: "s", Text: "t"})
+	assert.Error(t, err)
+}
+
+func TestSMTPMailer_InvalidRecipient(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	m := newTestSMTPMailer(t, server)
+
+	err := m.Send(context.Background(), mailer.Message{To: "user@example.com\r\nBcc: other@example.com", Subject: "s", Text: "t"})
+	assert.Error(t, err)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m, err := mailer.NewFileMailer(dir, "no-reply@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), mailer.Message{To: "user@example.com", Subject: "Hello", Text: "body"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Subject: Hello")
+	assert.Contains(t, string(data), "Content-Type: text/plain; charset=utf-8")
+}
+
+func TestMailTemplates(t *testing.T) {
+	templates, err := mailer.NewTemplates("en")
+	require.NoError(t, err)
+
+	data := struct {
+		Username  string
+		Link      string
+		ExpiresAt time.Time
+	}{"<b>user</b>", "https://example.com/verify?token=abc&x=1", time.Date(2030, 1, 2, 3, 4, 0, 0, time.UTC)}
+
+	en, err := templates.Render(mailer.TemplateVerifyEmail, "en", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Confirm your email", en.Subject)
+	assert.Contains(t, en.Text, "https://example.com/verify?token=abc&x=1")
+	assert.Contains(t, en.Text, "2030-01-02 03:04 UTC")
+	// в HTML данные экранируются
+	assert.Contains(t, en.HTML, "&lt;b&gt;user&lt;/b&gt;")
+	assert.Contains(t, en.HTML, `href="https://example.com/verify?token=abc&amp;x=1"`)
+
+	ru, err := templates.Render(mailer.TemplatePasswordReset, "ru", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Сброс пароля", ru.Subject)
+
+	// неизвестный язык - шаблон языка по умолчанию
+	fallback, err := templates.Render(mailer.TemplatePasswordReset, "de", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Reset your password", fallback.Subject)
+
+	_, err = templates.Render("unknown", "en", data)
+	assert.Error(t, err)
+
+	_, err = mailer.NewTemplates("de")
+	assert.Error(t, err)
+}
+
+// flakyMailer отказывает failures раз, затем доставляет
+type flakyMailer struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	delivered chan mailer.Message
+}
+
+func (m *flakyMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts++
+	if m.attempts <= m.failures {
+		return errors.New("temporary failure")
+	}
+	m.delivered <- msg
+	return nil
+}
+
+func TestMailQueue_Retries(t *testing.T) {
+	backend := &flakyMailer{failures: 2, delivered: make(chan mailer.Message, 1)}
+	queue := mailer.NewQueue(backend, 10, 3, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queue.Run(ctx, 1)
+
+	require.NoError(t, queue.Send(context.Background(), mailer.Message{To: "user@example.com", Subject: "s"}))
+
+	select {
+	case msg := <-backend.delivered:
+		assert.Equal(t, "user@example.com", msg.To)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not delivered")
+	}
+	backend.mu.Lock()
+	assert.Equal(t, 3, backend.attempts)
+	backend.mu.Unlock()
+}
+
+func TestMailQueue_Full(t *testing.T) {
+	queue := mailer.NewQueue(mailer.NewLogMailer(), 1, 0, time.Millisecond)
+
+	require.NoError(t, queue.Send(context.Background(), mailer.Message{To: "user@example.com"}))
+	assert.ErrorIs(t, queue.Send(context.Background(), mailer.Message{To: "user@example.com"}), mailer.ErrQueueFull)
+}
+
+func TestLogMailer_DoesNotLogText(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer logger.StandardLogger().ReplaceHooks(make(logger.LevelHooks))
+
+	err := mailer.NewLogMailer().Send(context.Background(), mailer.Message{
+		To:      "user@example.com",
+		Subject: "Reset your password",
+		Text:    "https://example.com/reset-password?token=secret-token",
+		HTML:    `<a href="https://example.com/reset-password?token=secret-token">reset</a>`,
+	})
+	require.NoError(t, err)
+
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, "user@example.com", entry.Data["to"])
+	assert.Equal(t, "Reset your password", entry.Data["subject"])
+	line, err := entry.String()
+	require.NoError(t, err)
+	assert.NotContains(t, line, "secret-token")
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	handlers "service-auth/internal/app/delivery/http"
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/service"
+	"service-auth/internal/app/service/mocks"
+	"service-auth/internal/configs"
+)
+
+func TestLogin_MFAChallenge(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), "testuser", "password123", gomock.Any()).
+		Return(models.Tokens{MFARequired: true, MFAToken: "mfa-token"}, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		strings.NewReader(`{"username":"testuser","password":"password123"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// до второго фактора куки с токенами не выставляются
+	assert.Empty(t, w.Result().Cookies())
+
+	var tokens models.Tokens
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.True(t, tokens.MFARequired)
+	assert.Equal(t, "mfa-token", tokens.MFAToken)
+	assert.Empty(t, tokens.AccessToken)
+}
+
+func TestLogin_MFAEnrollmentRequired(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), "admin", "password123", gomock.Any()).
+		Return(models.Tokens{MFAEnrollmentRequired: true, MFAToken: "enroll-token"}, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		strings.NewReader(`{"username":"admin","password":"password123"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// без настроенной MFA роль не получает токены
+	assert.Empty(t, w.Result().Cookies())
+
+	var tokens models.Tokens
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.True(t, tokens.MFAEnrollmentRequired)
+	assert.Equal(t, "enroll-token", tokens.MFAToken)
+	assert.Empty(t, tokens.AccessToken)
+}
+
+func TestRefresh_MFARequired(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().RefreshTokens(gomock.Any(), "refresh-token", gomock.Any()).
+		Return(models.Tokens{}, errs.ErrMFARequired)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh",
+		strings.NewReader(`{"refresh_token":"refresh-token"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestVerifyMFA(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().VerifyMFA(gomock.Any(), "mfa-token", "123456", gomock.Any()).
+		Return(models.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/mfa/verify",
+		strings.NewReader(`{"mfa_token":"mfa-token","code":"123456"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Result().Cookies(), 2)
+}
+
+func TestVerifyMFA_CookieMaxAge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockAuthService := mocks.NewMockAuthService(gomock.NewController(t))
+	cfg := &configs.Config{}
+	cfg.Auth.AccessTokenTTL = 15 * time.Minute
+	cfg.Auth.RefreshTokenTTL = 7 * 24 * time.Hour
+	router := handlers.NewHandler(service.Service{AuthService: mockAuthService}, cfg).InitRoutes()
+
+	mockAuthService.EXPECT().VerifyMFA(gomock.Any(), "mfa-token", "123456", gomock.Any()).
+		Return(models.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/mfa/verify",
+		strings.NewReader(`{"mfa_token":"mfa-token","code":"123456"}`))
+	router.ServeHTTP(w, req)
+
+	// Max-Age в секундах, а не в наносекундах time.Duration
+	maxAge := make(map[string]int)
+	for _, cookie := range w.Result().Cookies() {
+		maxAge[cookie.Name] = cookie.MaxAge
+	}
+	assert.Equal(t, map[string]int{"access_token": 900, "refresh_token": 604800}, maxAge)
+}
+
+func TestVerifyMFA_CodeReused(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().VerifyMFA(gomock.Any(), "mfa-token", "123456", gomock.Any()).
+		Return(models.Tokens{}, errs.ErrMFACodeAlreadyUsed)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/mfa/verify",
+		strings.NewReader(`{"mfa_token":"mfa-token","code":"123456"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/utils"
+)
+
+// Облегченные параметры, чтобы тесты не тратили 64 MiB на каждый хэш
+var testArgon2Params = utils.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestPasswordHasher_Argon2id(t *testing.T) {
+	hasher, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+
+	hash, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	ok, err := hasher.Verify("password123", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify("password124", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, hasher.NeedsRehash(hash))
+}
+
+func TestPasswordHasher_LongPassword(t *testing.T) {
+	hasher, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+
+	// bcrypt обрезал бы пароль до 72 байт, и оба пароля совпали бы
+	prefix := strings.Repeat("a", 72)
+	hash, err := hasher.Hash(prefix + "1")
+	require.NoError(t, err)
+
+	ok, err := hasher.Verify(prefix+"2", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestPasswordHasher_RehashFromBcrypt(t *testing.T) {
+	bcryptHasher, err := utils.NewPasswordHasher(utils.PasswordAlgBcrypt, testArgon2Params, 4)
+	require.NoError(t, err)
+	legacyHash, err := bcryptHasher.Hash("password123")
+	require.NoError(t, err)
+
+	hasher, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+
+	// старый bcrypt хэш проверяется и помечается для перехэширования
+	ok, err := hasher.Verify("password123", legacyHash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, hasher.NeedsRehash(legacyHash))
+}
+
+func TestPasswordHasher_RehashWeakerParams(t *testing.T) {
+	weak, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+	hash, err := weak.Hash("password123")
+	require.NoError(t, err)
+
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+	hasher, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, stronger, 4)
+	require.NoError(t, err)
+	assert.True(t, hasher.NeedsRehash(hash))
+
+	bcryptWeak, err := utils.NewPasswordHasher(utils.PasswordAlgBcrypt, testArgon2Params, 4)
+	require.NoError(t, err)
+	bcryptHash, err := bcryptWeak.Hash("password123")
+	require.NoError(t, err)
+
+	bcryptStrong, err := utils.NewPasswordHasher(utils.PasswordAlgBcrypt, testArgon2Params, 5)
+	require.NoError(t, err)
+	assert.True(t, bcryptStrong.NeedsRehash(bcryptHash))
+	assert.False(t, bcryptWeak.NeedsRehash(bcryptHash))
+}
+
+func TestPasswordHasher_UnknownAlgorithm(t *testing.T) {
+	_, err := utils.NewPasswordHasher("md5", testArgon2Params, 4)
+	assert.Error(t, err)
+
+	hasher, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+	_, err = hasher.Verify("password123", "plain")
+	assert.Error(t, err)
+}
+
+func TestPasswordHasher_Argon2idParamsOutOfRange(t *testing.T) {
+	hasher, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+	hash, err := hasher.Hash("password123")
+	require.NoError(t, err)
+
+	// параметры из испорченной записи в БД: argon2 паникует на t=0 и p=0, огромная память - DoS
+	for _, params := range []string{"m=1024,t=0,p=1", "m=1024,t=1,p=0", "m=4194304,t=1,p=1", "m=1024,t=1000,p=1"} {
+		tampered := strings.Replace(hash, "m=1024,t=1,p=1", params, 1)
+		require.NotEqual(t, hash, tampered)
+
+		ok, err := hasher.Verify("password123", tampered)
+		assert.Error(t, err, params)
+		assert.False(t, ok, params)
+		assert.True(t, hasher.NeedsRehash(tampered), params)
+	}
+
+	_, err = utils.NewPasswordHasher(utils.PasswordAlgArgon2id, utils.Argon2Params{Memory: 1024, Iterations: 0, Parallelism: 1}, 4)
+	assert.Error(t, err)
+}
+
+func TestPasswordManager_Pepper(t *testing.T) {
+	hasher, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+	peppers, err := utils.NewPeppers(1, map[int][]byte{1: []byte("pepper-v1")})
+	require.NoError(t, err)
+	passwords := utils.NewPasswordManager(hasher, peppers)
+
+	hash, version, err := passwords.Hash("password123")
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	ok, err := passwords.Verify("password123", hash, version)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, passwords.NeedsRehash(hash, version))
+
+	// без перца хэш из БД не подходит к паролю
+	ok, err = hasher.Verify("password123", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestPasswordManager_PepperRotation(t *testing.T) {
+	hasher, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+	secrets := map[int][]byte{1: []byte("pepper-v1"), 2: []byte("pepper-v2")}
+
+	oldPeppers, err := utils.NewPeppers(1, secrets)
+	require.NoError(t, err)
+	hash, version, err := utils.NewPasswordManager(hasher, oldPeppers).Hash("password123")
+	require.NoError(t, err)
+
+	peppers, err := utils.NewPeppers(2, secrets)
+	require.NoError(t, err)
+	passwords := utils.NewPasswordManager(hasher, peppers)
+
+	// хэш со старым перцем проверяется и помечается для перехэширования
+	ok, err := passwords.Verify("password123", hash, version)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, passwords.NeedsRehash(hash, version))
+
+	// хэши до введения перца (версия 0) тоже перехэшируются
+	legacy, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	ok, err = passwords.Verify("password123", legacy, 0)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, passwords.NeedsRehash(legacy, 0))
+
+	_, err = passwords.Verify("password123", hash, 3)
+	assert.Error(t, err)
+}
+
+func TestNewPeppers_MissingCurrent(t *testing.T) {
+	_, err := utils.NewPeppers(2, map[int][]byte{1: []byte("pepper-v1")})
+	assert.Error(t, err)
+
+	_, err = utils.NewPeppers(0, nil)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/utils"
+)
+
+var testPolicyParams = utils.PasswordPolicyParams{
+	MinLength:        10,
+	MaxLength:        64,
+	RequireLowercase: true,
+	RequireDigit:     true,
+}
+
+// policyViolations возвращает сообщение политики для поля пароля
+func policyViolations(t *testing.T, err error) string {
+	t.Helper()
+	var validationErr *errs.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.ErrorIs(t, err, errs.ErrWeakPassword)
+	return validationErr.Fields[utils.PasswordField]
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := utils.NewPasswordPolicy(testPolicyParams, nil)
+
+	// длинная фраза проходит, хотя старое ограничение max=16 ее бы отклонило
+	assert.NoError(t, policy.Validate("correct horse battery staple 42", "testuser", "testuser@gmail.com"))
+
+	msg := policyViolations(t, policy.Validate("short1", "testuser", "testuser@gmail.com"))
+	assert.Contains(t, msg, "at least 10 characters")
+
+	msg = policyViolations(t, policy.Validate(strings.Repeat("a1", 40), "testuser", "testuser@gmail.com"))
+	assert.Contains(t, msg, "at most 64 characters")
+
+	msg = policyViolations(t, policy.Validate("nodigitsinhere", "testuser", "testuser@gmail.com"))
+	assert.Contains(t, msg, "must contain a digit")
+
+	msg = policyViolations(t, policy.Validate("ALLUPPER12345", "testuser", "testuser@gmail.com"))
+	assert.Contains(t, msg, "must contain a lowercase letter")
+}
+
+func TestPasswordPolicy_Identity(t *testing.T) {
+	policy := utils.NewPasswordPolicy(testPolicyParams, nil)
+
+	msg := policyViolations(t, policy.Validate("my-TestUser-2024", "testuser", "someone@gmail.com"))
+	assert.Contains(t, msg, "must not contain the username or email")
+
+	msg = policyViolations(t, policy.Validate("someone-2024-pass", "testuser", "someone@gmail.com"))
+	assert.Contains(t, msg, "must not contain the username or email")
+}
+
+func TestPasswordPolicy_Breached(t *testing.T) {
+	sum := sha1.Sum([]byte("password123"))
+	content := "# test list\n" + strings.ToUpper(hex.EncodeToString(sum[:])) + ":2254650\n"
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	breached, err := utils.LoadBreachedPasswords(path)
+	require.NoError(t, err)
+	assert.True(t, breached.Contains("password123"))
+	assert.False(t, breached.Contains("password124"))
+
+	policy := utils.NewPasswordPolicy(utils.PasswordPolicyParams{MinLength: 8}, breached)
+	msg := policyViolations(t, policy.Validate("password123", "testuser", "testuser@gmail.com"))
+	assert.Contains(t, msg, "data breach")
+}
+
+func TestLoadBreachedPasswords_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("not-a-hash\n"), 0o600))
+
+	_, err := utils.LoadBreachedPasswords(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/utils"
+)
+
+func TestForgotPassword(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().ForgotPassword(gomock.Any(), "test@example.com").Return(nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/forgot",
+		strings.NewReader(`{"email":"test@example.com"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestForgotPassword_InvalidEmail(t *testing.T) {
+	router, _ := newMockAuthRouter(t)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/forgot", strings.NewReader(`{"email":"not-an-email"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestResetPassword(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().ResetPassword(gomock.Any(), "reset-token", "new-Password-42").Return(nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/reset",
+		strings.NewReader(`{"token":"reset-token","password":"new-Password-42"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestResetPassword_InvalidToken(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	mockAuthService.EXPECT().ResetPassword(gomock.Any(), "used-token", "new-Password-42").Return(errs.ErrResetTokenInvalid)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/reset",
+		strings.NewReader(`{"token":"used-token","password":"new-Password-42"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid or expired password reset token")
+}
+
+func TestResetPassword_WeakPassword(t *testing.T) {
+	router, mockAuthService := newMockAuthRouter(t)
+
+	policyErr := utils.NewPasswordPolicy(utils.PasswordPolicyParams{MinLength: 10, MaxLength: 128}, nil).
+		Validate("short", "testuser", "test@example.com")
+	mockAuthService.EXPECT().ResetPassword(gomock.Any(), "reset-token", "short").Return(policyErr)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/reset",
+		strings.NewReader(`{"token":"reset-token","password":"short"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"Password"`)
+}
+
+func TestResetPassword_TokenSingleUse(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	env.register(t, "resetuser")
+
+	require.NoError(t, env.services.ForgotPassword(ctx, "resetuser@example.com"))
+	token := env.mail.lastToken(t, "resetuser@example.com")
+
+	// пароль, не прошедший политику, не гасит ссылку
+	var validationErr *errs.ValidationError
+	assert.ErrorAs(t, env.services.ResetPassword(ctx, token, "short"), &validationErr)
+
+	require.NoError(t, env.services.ResetPassword(ctx, token, "newpassword123"))
+	assert.ErrorIs(t, env.services.ResetPassword(ctx, token, "otherpassword123"), errs.ErrResetTokenInvalid)
+
+	_, err := env.services.GenerateTokens(ctx, "resetuser", testPassword, testClient)
+	assert.ErrorIs(t, err, errs.ErrInvalidPwd)
+	env.login(t, "resetuser", "newpassword123")
+}
+
+func TestResetPassword_RevokesAllSessions(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "resetuser")
+
+	first := env.login(t, "resetuser", testPassword)
+	second := env.login(t, "resetuser", testPassword)
+
+	require.NoError(t, env.services.ForgotPassword(ctx, "resetuser@example.com"))
+	require.NoError(t, env.services.ResetPassword(ctx, env.mail.lastToken(t, "resetuser@example.com"), "newpassword123"))
+
+	for _, tokens := range []models.Tokens{first, second} {
+		_, err := env.services.ValidateAccessToken(ctx, tokens.AccessToken)
+		assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+		_, err = env.services.RefreshTokens(ctx, tokens.RefreshToken, testClient)
+		assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+	}
+	sessions, err := env.services.GetSessions(ctx, userID, "")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
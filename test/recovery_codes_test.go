@@ -0,0 +1,44 @@
+package test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/utils"
+)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := utils.GenerateRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	format := regexp.MustCompile(`^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$`)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		assert.Regexp(t, format, code)
+		assert.True(t, utils.IsRecoveryCode(code))
+		assert.False(t, seen[code])
+		seen[code] = true
+	}
+}
+
+func TestRecoveryCode_Normalize(t *testing.T) {
+	codes, err := utils.GenerateRecoveryCodes(1)
+	require.NoError(t, err)
+	code := codes[0]
+
+	// регистр и разделители при вводе не важны
+	typed := strings.ToLower(strings.ReplaceAll(code, "-", " "))
+	assert.True(t, utils.IsRecoveryCode(typed))
+	assert.Equal(t, utils.HashRecoveryCode(code), utils.HashRecoveryCode(typed))
+
+	// в хэше нет самого кода
+	assert.NotContains(t, utils.HashRecoveryCode(code), utils.NormalizeRecoveryCode(code))
+
+	// код TOTP не принимается за код восстановления
+	assert.False(t, utils.IsRecoveryCode("123456"))
+}
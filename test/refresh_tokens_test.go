@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+func TestRefreshTokens_RotationKeepsFamily(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	env.register(t, "refreshuser")
+	tokens := env.login(t, "refreshuser", testPassword)
+
+	rotated, err := env.services.RefreshTokens(ctx, tokens.RefreshToken, testClient)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+	assert.Equal(t, env.sessionID(t, tokens.RefreshToken), env.sessionID(t, rotated.RefreshToken))
+
+	_, err = env.services.RefreshTokens(ctx, rotated.RefreshToken, testClient)
+	assert.NoError(t, err)
+}
+
+func TestRefreshTokens_ReuseRevokesFamily(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "refreshuser")
+	tokens := env.login(t, "refreshuser", testPassword)
+	other := env.login(t, "refreshuser", testPassword)
+
+	rotated, err := env.services.RefreshTokens(ctx, tokens.RefreshToken, testClient)
+	require.NoError(t, err)
+
+	// повторное предъявление уже ротированного токена отзывает все семейство
+	_, err = env.services.RefreshTokens(ctx, tokens.RefreshToken, testClient)
+	assert.ErrorIs(t, err, errs.ErrTokenReused)
+	assert.Contains(t, env.pg.events(userID), "refresh_token_reuse")
+
+	_, err = env.services.RefreshTokens(ctx, rotated.RefreshToken, testClient)
+	assert.ErrorIs(t, err, errs.ErrTokenNotFound)
+
+	// другие сессии пользователя не затронуты
+	_, err = env.services.RefreshTokens(ctx, other.RefreshToken, testClient)
+	assert.NoError(t, err)
+	sessions, err := env.services.GetSessions(ctx, userID, "")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, env.sessionID(t, other.RefreshToken), sessions[0].ID)
+}
+
+func TestRefreshTokens_ConcurrentRotation(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	env.register(t, "refreshuser")
+	tokens := env.login(t, "refreshuser", testPassword)
+
+	const attempts = 2
+	results := make([]error, attempts)
+	rotated := make([]models.Tokens, attempts)
+	var wg sync.WaitGroup
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rotated[i], results[i] = env.services.RefreshTokens(ctx, tokens.RefreshToken, testClient)
+		}()
+	}
+	wg.Wait()
+
+	// токен ротируется один раз, остальные попытки считаются повторным использованием
+	succeeded := 0
+	for _, err := range results {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, errs.ErrTokenReused)
+	}
+	assert.Equal(t, 1, succeeded)
+
+	for i, err := range results {
+		if err == nil {
+			_, err = env.services.RefreshTokens(ctx, rotated[i].RefreshToken, testClient)
+			assert.Error(t, err, "family must be revoked after reuse")
+		}
+	}
+}
+
+func TestRefreshTokens_StoredUnderKeyedDigest(t *testing.T) {
+	env := newServiceEnv(t)
+	env.register(t, "digestuser")
+	tokens := env.login(t, "digestuser", testPassword)
+
+	mac := hmac.New(sha256.New, []byte(env.cfg.Auth.TokenHashSecret))
+	mac.Write([]byte(tokens.RefreshToken))
+	digest := hex.EncodeToString(mac.Sum(nil))
+	plain := sha256.Sum256([]byte(tokens.RefreshToken))
+
+	// в Redis нет ни самого токена, ни его отпечатка без секрета
+	assert.NotEmpty(t, env.redis.keysWithPrefix(testRedisPrefix+"refresh:"+digest))
+	assert.False(t, env.redis.stores(tokens.RefreshToken))
+	assert.False(t, env.redis.stores(hex.EncodeToString(plain[:])))
+
+	// все ключи сервиса в его пространстве имен
+	all := env.redis.keysWithPrefix("")
+	require.NotEmpty(t, all)
+	for _, key := range all {
+		assert.True(t, strings.HasPrefix(key, testRedisPrefix), key)
+	}
+}
+
+func TestRefreshTokens_PlainDigestWithoutSecret(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Auth.TokenHashSecret = ""
+	env := newServiceEnvWithConfig(t, cfg)
+	env.register(t, "digestuser")
+	tokens := env.login(t, "digestuser", testPassword)
+
+	// без секрета токен хранится под SHA-256, обновление работает как обычно
+	plain := sha256.Sum256([]byte(tokens.RefreshToken))
+	assert.NotEmpty(t, env.redis.keysWithPrefix(testRedisPrefix+"refresh:"+hex.EncodeToString(plain[:])))
+	assert.False(t, env.redis.stores(tokens.RefreshToken))
+	_, err := env.services.RefreshTokens(context.Background(), tokens.RefreshToken, testClient)
+	assert.NoError(t, err)
+}
@@ -97,13 +97,13 @@ func TestGenerateTokens(t *testing.T) {
 	username := "testuser"
 	password := "password123"
 
-	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), username, password).
+	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), username, password, gomock.Any()).
 		Return(models.Tokens{
 			AccessToken:  "accessToken",
 			RefreshToken: "refreshToken",
 		}, nil)
 
-	tokens, err := svc.GenerateTokens(context.Background(), username, password)
+	tokens, err := svc.GenerateTokens(context.Background(), username, password, models.ClientInfo{})
 	assert.NoError(t, err)
 	assert.Equal(t, "accessToken", tokens.AccessToken)
 	assert.Equal(t, "refreshToken", tokens.RefreshToken)
@@ -117,8 +117,8 @@ func TestUserNotFound(t *testing.T) {
 	username := "testuser"
 	password := "password123"
 
-	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), username, password).Return(models.Tokens{}, errs.ErrUserNotFound)
-	_, err := svc.GenerateTokens(context.Background(), username, password)
+	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), username, password, gomock.Any()).Return(models.Tokens{}, errs.ErrUserNotFound)
+	_, err := svc.GenerateTokens(context.Background(), username, password, models.ClientInfo{})
 	assert.Error(t, err)
 	assert.Equal(t, errs.ErrUserNotFound, err)
 }
@@ -132,8 +132,8 @@ func TestInvalidPassword(t *testing.T) {
 	username := "testuser"
 	password := "password123"
 
-	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), username, password).Return(models.Tokens{}, errs.ErrInvalidPwd)
-	_, err := svc.GenerateTokens(context.Background(), username, password)
+	mockAuthService.EXPECT().GenerateTokens(gomock.Any(), username, password, gomock.Any()).Return(models.Tokens{}, errs.ErrInvalidPwd)
+	_, err := svc.GenerateTokens(context.Background(), username, password, models.ClientInfo{})
 
 	assert.Error(t, err)
 	assert.Equal(t, errs.ErrInvalidPwd, err)
@@ -149,13 +149,13 @@ func TestRefreshTokens(t *testing.T) {
 
 	oldToken := "oldRefreshToken"
 
-	mockAuthService.EXPECT().RefreshTokens(gomock.Any(), oldToken).
+	mockAuthService.EXPECT().RefreshTokens(gomock.Any(), oldToken, gomock.Any()).
 		Return(models.Tokens{
 			AccessToken:  "newAccessToken",
 			RefreshToken: "newRefreshToken",
 		}, nil)
 
-	tokens, err := svc.RefreshTokens(context.Background(), oldToken)
+	tokens, err := svc.RefreshTokens(context.Background(), oldToken, models.ClientInfo{})
 	assert.NoError(t, err)
 	assert.Equal(t, "newAccessToken", tokens.AccessToken)
 	assert.Equal(t, "newRefreshToken", tokens.RefreshToken)
@@ -168,8 +168,8 @@ func TestRefreshTokens_InvalidToken(t *testing.T) {
 	svc := service.Service{AuthService: mockAuthService}
 
 	oldToken := "oldRefreshToken"
-	mockAuthService.EXPECT().RefreshTokens(gomock.Any(), oldToken).Return(models.Tokens{}, errs.ErrTokenInvalid)
-	_, err := svc.RefreshTokens(context.Background(), oldToken)
+	mockAuthService.EXPECT().RefreshTokens(gomock.Any(), oldToken, gomock.Any()).Return(models.Tokens{}, errs.ErrTokenInvalid)
+	_, err := svc.RefreshTokens(context.Background(), oldToken, models.ClientInfo{})
 	assert.Error(t, err)
 	assert.Equal(t, errs.ErrTokenInvalid, err)
 }
@@ -180,8 +180,8 @@ func TestInvalidTokenType(t *testing.T) {
 	mockAuthService := mocks.NewMockAuthService(ctrl)
 	svc := service.Service{AuthService: mockAuthService}
 	oldToken := "oldRefreshToken"
-	mockAuthService.EXPECT().RefreshTokens(gomock.Any(), oldToken).Return(models.Tokens{}, errs.ErrInvalidTokenType)
-	_, err := svc.RefreshTokens(context.Background(), oldToken)
+	mockAuthService.EXPECT().RefreshTokens(gomock.Any(), oldToken, gomock.Any()).Return(models.Tokens{}, errs.ErrInvalidTokenType)
+	_, err := svc.RefreshTokens(context.Background(), oldToken, models.ClientInfo{})
 	assert.Error(t, err)
 	assert.Equal(t, errs.ErrInvalidTokenType, err)
 }
@@ -192,8 +192,8 @@ func TestTokenExpired(t *testing.T) {
 	mockAuthService := mocks.NewMockAuthService(ctrl)
 	svc := service.Service{AuthService: mockAuthService}
 	oldToken := "oldRefreshToken"
-	mockAuthService.EXPECT().RefreshTokens(gomock.Any(), oldToken).Return(models.Tokens{}, errs.ErrTokenExpired)
-	_, err := svc.RefreshTokens(context.Background(), oldToken)
+	mockAuthService.EXPECT().RefreshTokens(gomock.Any(), oldToken, gomock.Any()).Return(models.Tokens{}, errs.ErrTokenExpired)
+	_, err := svc.RefreshTokens(context.Background(), oldToken, models.ClientInfo{})
 	assert.Error(t, err)
 	assert.Equal(t, errs.ErrTokenExpired, err)
 }
@@ -0,0 +1,23 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+
+	handlers "service-auth/internal/app/delivery/http"
+	"service-auth/internal/app/service"
+	"service-auth/internal/app/service/mocks"
+	"service-auth/internal/configs"
+)
+
+// newMockAuthRouter маршруты поверх мока AuthService: проверяет разбор запросов и коды ответов
+func newMockAuthRouter(t *testing.T) (*gin.Engine, *mocks.MockAuthService) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	mockAuthService := mocks.NewMockAuthService(ctrl)
+	router := handlers.NewHandler(service.Service{AuthService: mockAuthService}, &configs.Config{}).InitRoutes()
+	return router, mockAuthService
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/mailer"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/repository"
+	"service-auth/internal/app/service"
+	"service-auth/internal/app/utils"
+	"service-auth/internal/configs"
+)
+
+const (
+	testRedisPrefix = "auth:"
+	testPassword    = "password123"
+)
+
+var testClient = models.ClientInfo{IP: "192.0.2.1", UserAgent: "test"}
+
+// captureMailer запоминает отправленные письма
+type captureMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *captureMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+var mailTokenRe = regexp.MustCompile(`token=([A-Za-z0-9_.-]+)`)
+
+// lastToken токен из ссылки последнего письма адресату to
+func (m *captureMailer) lastToken(t *testing.T, to string) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To != to {
+			continue
+		}
+		if match := mailTokenRe.FindStringSubmatch(m.messages[i].Text); match != nil {
+			return match[1]
+		}
+	}
+	t.Fatalf("no mail with token sent to %s", to)
+	return ""
+}
+
+// serviceEnv сервисы поверх настоящего RedisRepo (fakeRedis) и PostgresRepository в памяти
+type serviceEnv struct {
+	services service.Service
+	repo     *repository.Repository
+	pg       *fakePostgres
+	redis    *fakeRedis
+	mail     *captureMailer
+	jwt      *utils.JWTManager
+	cfg      *configs.Config
+}
+
+func newTestConfig() *configs.Config {
+	cfg := &configs.Config{}
+	cfg.Auth.AccessTokenTTL = time.Minute
+	cfg.Auth.RefreshTokenTTL = time.Hour
+	cfg.Auth.TokenHashSecret = "test-token-hash-secret"
+	cfg.Password.MinLength = 10
+	cfg.Password.ResetURL = "http://localhost/reset-password"
+	cfg.Password.ResetTokenTTL = 30 * time.Minute
+	cfg.Password.ResetInterval = time.Minute
+	cfg.Email.VerifyURL = "http://localhost/verify-email"
+	cfg.Email.ChangeURL = "http://localhost/confirm-email"
+	cfg.Email.VerificationTTL = time.Hour
+	cfg.Email.ResendInterval = time.Minute
+	cfg.MFA.ChallengeTTL = 5 * time.Minute
+	cfg.Mail.Locale = "en"
+	cfg.Account.DeletionGracePeriod = 720 * time.Hour
+	cfg.Export.TTL = time.Hour
+	cfg.Login.Window = 15 * time.Minute
+	cfg.Login.IPMaxFailures = 50
+	cfg.Login.LockoutThreshold = 10
+	cfg.Login.LockoutDuration = 15 * time.Minute
+	return cfg
+}
+
+func newServiceEnv(t *testing.T) *serviceEnv {
+	return newServiceEnvWithConfig(t, newTestConfig())
+}
+
+func newServiceEnvWithConfig(t *testing.T, cfg *configs.Config) *serviceEnv {
+	t.Helper()
+
+	jwtManager, err := utils.NewJWTManager(utils.AlgRS256, privateKeyPath, publicKeyPath)
+	require.NoError(t, err)
+	hasher, err := utils.NewPasswordHasher(utils.PasswordAlgArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+	peppers, err := utils.NewPeppers(0, nil)
+	require.NoError(t, err)
+	templates, err := mailer.NewTemplates(cfg.Mail.Locale)
+	require.NoError(t, err)
+
+	redisServer, redisClient := newFakeRedis(t)
+	pg := newFakePostgres()
+	repo := &repository.Repository{
+		PostgresRepository: pg,
+		RedisRepository:    repository.NewRedisRepo(redisClient, testRedisPrefix, []byte(cfg.Auth.TokenHashSecret)),
+	}
+	mail := &captureMailer{}
+	policy := utils.NewPasswordPolicy(utils.PasswordPolicyParams{MinLength: cfg.Password.MinLength}, nil)
+
+	return &serviceEnv{
+		services: service.NewService(repo, jwtManager, utils.NewPasswordManager(hasher, peppers), policy, nil,
+			mail, templates, cfg),
+		repo:  repo,
+		pg:    pg,
+		redis: redisServer,
+		mail:  mail,
+		jwt:   jwtManager,
+		cfg:   cfg,
+	}
+}
+
+// register создает пользователя с паролем testPassword
+func (e *serviceEnv) register(t *testing.T, username string) uuid.UUID {
+	t.Helper()
+	id, err := e.services.CreateUser(context.Background(), models.UserInput{
+		Username: username,
+		Password: testPassword,
+		Email:    username + "@example.com",
+	})
+	require.NoError(t, err)
+	return id
+}
+
+// login входит паролем и возвращает выданные токены
+func (e *serviceEnv) login(t *testing.T, username, password string) models.Tokens {
+	t.Helper()
+	tokens, err := e.services.GenerateTokens(context.Background(), username, password, testClient)
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.AccessToken)
+	return tokens
+}
+
+// sessionID сессия, к которой относится токен
+func (e *serviceEnv) sessionID(t *testing.T, token string) string {
+	t.Helper()
+	claims, err := e.jwt.DecodeJWT(token)
+	require.NoError(t, err)
+	if claims.SessionID != "" {
+		return claims.SessionID
+	}
+	return claims.FamilyID
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+func TestGetSessions_MarksCurrent(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "sessionuser")
+	laptop := env.login(t, "sessionuser", testPassword)
+	phone, err := env.services.GenerateTokens(ctx, "sessionuser", testPassword,
+		models.ClientInfo{IP: "192.0.2.50", UserAgent: "phone"})
+	require.NoError(t, err)
+
+	sessions, err := env.services.GetSessions(ctx, userID, env.sessionID(t, phone.AccessToken))
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	byID := map[string]models.Session{}
+	for _, session := range sessions {
+		byID[session.ID] = session
+	}
+	assert.False(t, byID[env.sessionID(t, laptop.AccessToken)].Current)
+	current := byID[env.sessionID(t, phone.AccessToken)]
+	assert.True(t, current.Current)
+	assert.Equal(t, "phone", current.UserAgent)
+	assert.Equal(t, "192.0.2.50", current.IP)
+}
+
+func TestRevokeSession_EndsOnlyThatSession(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "sessionuser")
+	current := env.login(t, "sessionuser", testPassword)
+	other := env.login(t, "sessionuser", testPassword)
+
+	require.NoError(t, env.services.RevokeSession(ctx, userID, env.sessionID(t, other.AccessToken)))
+
+	// access токены завершенной сессии отклоняются сразу, не дожидаясь истечения
+	_, err := env.services.ValidateAccessToken(ctx, other.AccessToken)
+	assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+	_, err = env.services.RefreshTokens(ctx, other.RefreshToken, testClient)
+	assert.ErrorIs(t, err, errs.ErrTokenNotFound)
+
+	_, err = env.services.ValidateAccessToken(ctx, current.AccessToken)
+	assert.NoError(t, err)
+	_, err = env.services.RefreshTokens(ctx, current.RefreshToken, testClient)
+	assert.NoError(t, err)
+
+	sessions, err := env.services.GetSessions(ctx, userID, "")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, env.sessionID(t, current.AccessToken), sessions[0].ID)
+}
+
+func TestRevokeSession_NotOwned(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "sessionuser")
+	env.register(t, "otheruser")
+	other := env.login(t, "otheruser", testPassword)
+
+	// чужая и несуществующая сессии неотличимы
+	err := env.services.RevokeSession(ctx, userID, env.sessionID(t, other.AccessToken))
+	assert.ErrorIs(t, err, errs.ErrSessionNotFound)
+	err = env.services.RevokeSession(ctx, userID, "00000000-0000-0000-0000-000000000000")
+	assert.ErrorIs(t, err, errs.ErrSessionNotFound)
+
+	_, err = env.services.ValidateAccessToken(ctx, other.AccessToken)
+	assert.NoError(t, err)
+	_, err = env.services.RefreshTokens(ctx, other.RefreshToken, testClient)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+)
+
+func TestTokenCutoff_Boundary(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "cutoffuser")
+	tokens := env.login(t, "cutoffuser", testPassword)
+
+	claims, err := env.jwt.DecodeJWT(tokens.AccessToken)
+	require.NoError(t, err)
+	// iat остается в целых секундах, миллисекунды несет iat_ms
+	assert.Equal(t, claims.IssuedAt.Truncate(time.Second), claims.IssuedAt.Time)
+	issuedAt := claims.IssuedAtPrecise()
+	assert.Equal(t, claims.IssuedAt.Unix(), issuedAt.Unix())
+
+	setCutoff := func(at time.Time) {
+		t.Helper()
+		require.NoError(t, env.repo.SetTokenCutoff(ctx, userID, models.TokenCutoff{At: at}, time.Hour))
+	}
+
+	// отсечка на миллисекунду раньше iat не затрагивает токен
+	setCutoff(issuedAt.Add(-time.Millisecond))
+	_, err = env.services.ValidateAccessToken(ctx, tokens.AccessToken)
+	assert.NoError(t, err)
+
+	// отсечка в ту же миллисекунду и позже в той же секунде отклоняет токен
+	for _, at := range []time.Time{issuedAt, issuedAt.Add(time.Millisecond), issuedAt.Truncate(time.Second).Add(999 * time.Millisecond)} {
+		setCutoff(at)
+		_, err = env.services.ValidateAccessToken(ctx, tokens.AccessToken)
+		assert.ErrorIs(t, err, errs.ErrTokenRevoked, "cutoff %s", at)
+	}
+}
+
+func TestTokenCutoff_SameSecondAsLogin(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "cutoffuser")
+
+	tokens := env.login(t, "cutoffuser", testPassword)
+	require.NoError(t, env.services.RevokeAllForUser(ctx, userID))
+
+	// вход и выход со всех устройств почти всегда приходятся на одну секунду
+	_, err := env.services.ValidateAccessToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+
+	// токены, выпущенные после отсечки, принимаются
+	time.Sleep(5 * time.Millisecond)
+	fresh := env.login(t, "cutoffuser", testPassword)
+	_, err = env.services.ValidateAccessToken(ctx, fresh.AccessToken)
+	assert.NoError(t, err)
+	_, err = env.services.RefreshTokens(ctx, fresh.RefreshToken, testClient)
+	assert.NoError(t, err)
+}
+
+func TestRevokeToken_DeniesJTI(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	env.register(t, "denyuser")
+	tokens := env.login(t, "denyuser", testPassword)
+
+	require.NoError(t, env.services.RevokeToken(ctx, tokens.RefreshToken))
+
+	claims, err := env.jwt.DecodeJWT(tokens.RefreshToken)
+	require.NoError(t, err)
+	// jti остается в denylist до истечения срока токена, не дольше
+	ttl := env.redis.ttl(testRedisPrefix + "deny:" + claims.ID)
+	assert.True(t, ttl > 0 && ttl <= env.cfg.Auth.RefreshTokenTTL, "deny ttl %s", ttl)
+
+	_, err = env.services.RefreshTokens(ctx, tokens.RefreshToken, testClient)
+	assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+	introspection, err := env.services.Introspect(ctx, tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.False(t, introspection.Active)
+}
+
+func TestRevokeAccessToken_DeniesOnlyThatToken(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	env.register(t, "denyuser")
+	tokens := env.login(t, "denyuser", testPassword)
+	other := env.login(t, "denyuser", testPassword)
+
+	require.NoError(t, env.services.RevokeAccessToken(ctx, tokens.AccessToken))
+
+	_, err := env.services.ValidateAccessToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+	_, err = env.services.ValidateAccessToken(ctx, other.AccessToken)
+	assert.NoError(t, err)
+
+	// refresh токен через ручку отзыва access токена не принимается
+	assert.ErrorIs(t, env.services.RevokeAccessToken(ctx, tokens.RefreshToken), errs.ErrAccessTokenInvalid)
+}
+
+func TestRevokeAllForUser(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	userID := env.register(t, "logoutuser")
+	env.register(t, "bystander")
+	sessions := []models.Tokens{
+		env.login(t, "logoutuser", testPassword),
+		env.login(t, "logoutuser", testPassword),
+	}
+	bystander := env.login(t, "bystander", testPassword)
+
+	require.NoError(t, env.services.RevokeAllForUser(ctx, userID))
+
+	for _, tokens := range sessions {
+		_, err := env.services.ValidateAccessToken(ctx, tokens.AccessToken)
+		assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+		_, err = env.services.RefreshTokens(ctx, tokens.RefreshToken, testClient)
+		assert.ErrorIs(t, err, errs.ErrTokenRevoked)
+	}
+	active, err := env.services.GetSessions(ctx, userID, "")
+	require.NoError(t, err)
+	assert.Empty(t, active)
+	assert.Empty(t, env.redis.keysWithPrefix(testRedisPrefix+"user_tokens:"+userID.String()))
+
+	// сессии других пользователей не затронуты, новый вход работает
+	_, err = env.services.ValidateAccessToken(ctx, bystander.AccessToken)
+	assert.NoError(t, err)
+	_, err = env.services.RefreshTokens(ctx, bystander.RefreshToken, testClient)
+	assert.NoError(t, err)
+
+	fresh := env.login(t, "logoutuser", testPassword)
+	_, err = env.services.ValidateAccessToken(ctx, fresh.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestRevokeHandler_AccessTokenSources(t *testing.T) {
+	const target = "/api/v1/auth/revoke-token?refresh_token=refresh-token"
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		setup  func(req *http.Request)
+		revoke bool // ожидается отзыв access токена
+	}{
+		{name: "body", target: target, body: `{"access_token":"access-token"}`, revoke: true},
+		{name: "authorization header", target: target, revoke: true, setup: func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer access-token")
+		}},
+		{name: "cookie", target: target, revoke: true, setup: func(req *http.Request) {
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: "access-token"})
+		}},
+		// токен в URL оседает в логах, поэтому из query он не берется
+		{name: "query is ignored", target: target + "&access_token=access-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockAuthService := newMockAuthRouter(t)
+			mockAuthService.EXPECT().RevokeToken(gomock.Any(), "refresh-token").Return(nil)
+			if tt.revoke {
+				mockAuthService.EXPECT().RevokeAccessToken(gomock.Any(), "access-token").Return(nil)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, tt.target, strings.NewReader(tt.body))
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/utils"
+)
+
+// Векторы RFC 6238 (SHA1), последние 6 цифр 8-значных кодов
+func TestTOTPCode_RFC6238(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, code := range cases {
+		assert.Equal(t, code, utils.TOTPCode(secret, utils.TOTPStep(time.Unix(unix, 0))), "time %d", unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	current := utils.TOTPStep(now)
+
+	step, ok := utils.ValidateTOTP(secret, utils.TOTPCode(secret, current), now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, current, step)
+
+	// код предыдущего шага принимается в пределах skew
+	step, ok = utils.ValidateTOTP(secret, utils.TOTPCode(secret, current-1), now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, current-1, step)
+
+	_, ok = utils.ValidateTOTP(secret, utils.TOTPCode(secret, current-2), now, 1)
+	assert.False(t, ok)
+
+	_, ok = utils.ValidateTOTP(secret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	uri, err := url.Parse(utils.TOTPURI("service-auth", "testuser", secret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/service-auth:testuser", uri.Path)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", uri.Query().Get("secret"))
+	assert.Equal(t, "service-auth", uri.Query().Get("issuer"))
+}
+
+func TestSecretCipher(t *testing.T) {
+	cipher, err := utils.NewSecretCipher(base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+
+	owner := uuid.New()
+	encrypted, err := cipher.Encrypt([]byte("secret"), owner[:])
+	require.NoError(t, err)
+
+	plaintext, err := cipher.Decrypt(encrypted, owner[:])
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+
+	// шифртекст привязан к владельцу
+	other := uuid.New()
+	_, err = cipher.Decrypt(encrypted, other[:])
+	assert.Error(t, err)
+
+	_, err = utils.NewSecretCipher(base64.StdEncoding.EncodeToString(make([]byte, 16)))
+	assert.Error(t, err)
+}
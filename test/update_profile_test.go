@@ -0,0 +1,156 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	handlers "service-auth/internal/app/delivery/http"
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/service"
+	"service-auth/internal/app/service/mocks"
+	"service-auth/internal/app/utils"
+	"service-auth/internal/configs"
+)
+
+func newUserRouter(t *testing.T, userID uuid.UUID) (*gin.Engine, *mocks.MockUserService) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	mockAuthService := mocks.NewMockAuthService(ctrl)
+	mockUserService := mocks.NewMockUserService(ctrl)
+	mockAuthService.EXPECT().ValidateAccessToken(gomock.Any(), "access-token").Return(&utils.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+		TokenType:        utils.AccessToken,
+	}, nil).AnyTimes()
+
+	services := service.Service{AuthService: mockAuthService, UserService: mockUserService}
+	router := handlers.NewHandler(services, &configs.Config{}).InitRoutes()
+	return router, mockUserService
+}
+
+func updateProfileRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/me", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer access-token")
+	return req
+}
+
+func TestUpdateProfile(t *testing.T) {
+	userID := uuid.New()
+	router, mockUserService := newUserRouter(t, userID)
+
+	username := "newuser"
+	email := "new@example.com"
+	// email меняется только после подтверждения, в ответе он ожидающий
+	input := models.UpdateProfileInput{Username: &username, Email: &email, CurrentPassword: "password123"}
+	mockUserService.EXPECT().UpdateProfile(gomock.Any(), userID, input, gomock.Any()).
+		Return(models.UserProfile{ID: userID, Username: username, Email: "old@example.com", PendingEmail: &email}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, updateProfileRequest(`{"username":"newuser","email":"new@example.com","current_password":"password123"}`))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var profile models.UserProfile
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+	assert.Equal(t, "old@example.com", profile.Email)
+	require.NotNil(t, profile.PendingEmail)
+	assert.Equal(t, email, *profile.PendingEmail)
+}
+
+func TestUpdateProfile_EmailAlreadyUsed(t *testing.T) {
+	userID := uuid.New()
+	router, mockUserService := newUserRouter(t, userID)
+
+	mockUserService.EXPECT().UpdateProfile(gomock.Any(), userID, gomock.Any(), gomock.Any()).
+		Return(models.UserProfile{}, errs.ErrEmailAlreadyUsed)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, updateProfileRequest(`{"email":"taken@example.com","current_password":"password123"}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "email")
+}
+
+func TestUpdateProfile_EmailChangeWithoutPassword(t *testing.T) {
+	userID := uuid.New()
+	router, mockUserService := newUserRouter(t, userID)
+
+	mockUserService.EXPECT().UpdateProfile(gomock.Any(), userID, gomock.Any(), gomock.Any()).
+		Return(models.UserProfile{}, errs.ErrInvalidPwd)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, updateProfileRequest(`{"email":"new@example.com"}`))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestUpdateProfile_InvalidInput(t *testing.T) {
+	userID := uuid.New()
+	router, _ := newUserRouter(t, userID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, updateProfileRequest(`{"email":"not-an-email"}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	router, mockUserService := newUserRouter(t, uuid.New())
+
+	mockUserService.EXPECT().ConfirmEmailChange(gomock.Any(), "change-token").Return(nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/email-change/confirm", strings.NewReader(`{"token":"change-token"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestConfirmEmailChange_InvalidToken(t *testing.T) {
+	router, mockUserService := newUserRouter(t, uuid.New())
+
+	mockUserService.EXPECT().ConfirmEmailChange(gomock.Any(), "used-token").Return(errs.ErrEmailTokenInvalid)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/email-change/confirm", strings.NewReader(`{"token":"used-token"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateProfile_EmailChangeCooldown(t *testing.T) {
+	env := newServiceEnv(t)
+	ctx := context.Background()
+	changeEmail := func(userID uuid.UUID, email string) error {
+		_, err := env.services.UpdateProfile(ctx, userID, models.UpdateProfileInput{
+			Email:           &email,
+			CurrentPassword: testPassword,
+		}, testClient)
+		return err
+	}
+
+	userID := env.register(t, "emailuser")
+	require.NoError(t, changeEmail(userID, "first@example.com"))
+
+	// запрос в пределах интервала отклоняется и не меняет ожидающий адрес
+	assert.ErrorIs(t, changeEmail(userID, "second@example.com"), errs.ErrTooManyAttempts)
+	user, _ := env.pg.user(userID)
+	require.NotNil(t, user.PendingEmail)
+	assert.Equal(t, "first@example.com", *user.PendingEmail)
+
+	// занятый адрес тоже расходует интервал
+	otherID := env.register(t, "otheruser")
+	assert.ErrorIs(t, changeEmail(otherID, "emailuser@example.com"), errs.ErrEmailAlreadyUsed)
+	assert.ErrorIs(t, changeEmail(otherID, "third@example.com"), errs.ErrTooManyAttempts)
+}
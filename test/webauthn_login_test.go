@@ -0,0 +1,161 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/errs"
+	"service-auth/internal/app/models"
+	"service-auth/internal/app/utils"
+)
+
+// passkey ключ доступа пользователя, зарегистрированный напрямую в fakePostgres
+type passkey struct {
+	id        []byte
+	key       *ecdsa.PrivateKey
+	signCount uint32
+}
+
+func newPasskeyEnv(t *testing.T) *serviceEnv {
+	cfg := newTestConfig()
+	cfg.WebAuthn.RPID = "example.com"
+	cfg.WebAuthn.Origins = []string{"https://example.com"}
+	cfg.WebAuthn.Timeout = time.Minute
+	cfg.WebAuthn.UserVerification = "preferred"
+	return newServiceEnvWithConfig(t, cfg)
+}
+
+func (e *serviceEnv) addPasskey(t *testing.T, userID uuid.UUID) *passkey {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	pk := &passkey{id: []byte("credential-" + userID.String()), key: privateKey}
+
+	_, err = e.pg.CreateWebAuthnCredential(context.Background(), models.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: pk.id,
+		PublicKey:    es256COSEKey(t, &privateKey.PublicKey),
+	})
+	require.NoError(t, err)
+	return pk
+}
+
+// passkeyLogin проходит BeginLogin/FinishLogin ключом с флагами flags
+func (e *serviceEnv) passkeyLogin(t *testing.T, username string, pk *passkey, flags byte) (models.Tokens, error) {
+	t.Helper()
+	ctx := context.Background()
+	options, err := e.services.BeginLogin(ctx, username)
+	require.NoError(t, err)
+
+	clientDataJSON, err := json.Marshal(utils.ClientData{
+		Type: utils.WebAuthnTypeGet, Challenge: options.Challenge, Origin: "https://example.com",
+	})
+	require.NoError(t, err)
+	pk.signCount++
+	authData := authenticatorData(e.cfg.WebAuthn.RPID, flags, pk.signCount, nil, nil)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, pk.key, digest[:])
+	require.NoError(t, err)
+
+	return e.services.FinishLogin(ctx, models.WebAuthnLoginInput{
+		ID:    utils.EncodeBase64URL(pk.id),
+		RawID: utils.EncodeBase64URL(pk.id),
+		Type:  "public-key",
+		Response: models.WebAuthnAssertionResponse{
+			ClientDataJSON:    utils.EncodeBase64URL(clientDataJSON),
+			AuthenticatorData: utils.EncodeBase64URL(authData),
+			Signature:         utils.EncodeBase64URL(signature),
+		},
+	}, testClient)
+}
+
+func TestPasskeyLogin_IssuesTokens(t *testing.T) {
+	env := newPasskeyEnv(t)
+	userID := env.register(t, "alice")
+	pk := env.addPasskey(t, userID)
+
+	tokens, err := env.passkeyLogin(t, "alice", pk, utils.AuthFlagUserPresent)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.False(t, tokens.MFARequired)
+}
+
+func TestPasskeyLogin_MFAUserNeedsUserVerification(t *testing.T) {
+	env := newPasskeyEnv(t)
+	userID := env.register(t, "alice")
+	env.pg.update(userID, func(user *fakeUser) { user.MFAEnabled = true })
+	pk := env.addPasskey(t, userID)
+
+	// без UV ключ заменяет только пароль: нужен второй фактор, как после пароля
+	tokens, err := env.passkeyLogin(t, "alice", pk, utils.AuthFlagUserPresent)
+	require.NoError(t, err)
+	assert.True(t, tokens.MFARequired)
+	assert.NotEmpty(t, tokens.MFAToken)
+	assert.Empty(t, tokens.AccessToken)
+	assert.Empty(t, tokens.RefreshToken)
+
+	// ключ с проверкой пользователя сам по себе двухфакторный
+	tokens, err = env.passkeyLogin(t, "alice", pk, utils.AuthFlagUserPresent|utils.AuthFlagUserVerified)
+	require.NoError(t, err)
+	assert.False(t, tokens.MFARequired)
+	assert.NotEmpty(t, tokens.AccessToken)
+}
+
+func TestPasskeyLogin_RequiredRoleWithoutMFA(t *testing.T) {
+	env := newPasskeyEnv(t)
+	env.cfg.MFA.RequiredRoles = []string{"admin"}
+	userID := env.register(t, "root")
+	env.pg.update(userID, func(user *fakeUser) { user.Role = "admin" })
+	pk := env.addPasskey(t, userID)
+
+	// без ключа шифрования MFA не настроить, токены роль не получает даже с UV
+	_, err := env.passkeyLogin(t, "root", pk, utils.AuthFlagUserPresent|utils.AuthFlagUserVerified)
+	assert.ErrorIs(t, err, errs.ErrMFANotConfigured)
+}
+
+func TestPasskeyLogin_FailuresCountTowardsIPLimit(t *testing.T) {
+	env := newPasskeyEnv(t)
+	env.cfg.Login.IPMaxFailures = 3
+	userID := env.register(t, "alice")
+	pk := env.addPasskey(t, userID)
+	ctx := context.Background()
+
+	for i := 0; i < env.cfg.Login.IPMaxFailures; i++ {
+		// ответ с rpIdHash чужого домена
+		options, err := env.services.BeginLogin(ctx, "alice")
+		require.NoError(t, err)
+		clientDataJSON, err := json.Marshal(utils.ClientData{
+			Type: utils.WebAuthnTypeGet, Challenge: options.Challenge, Origin: "https://example.com",
+		})
+		require.NoError(t, err)
+		_, err = env.services.FinishLogin(ctx, models.WebAuthnLoginInput{
+			ID:    utils.EncodeBase64URL(pk.id),
+			RawID: utils.EncodeBase64URL(pk.id),
+			Type:  "public-key",
+			Response: models.WebAuthnAssertionResponse{
+				ClientDataJSON:    utils.EncodeBase64URL(clientDataJSON),
+				AuthenticatorData: utils.EncodeBase64URL(authenticatorData("evil.com", utils.AuthFlagUserPresent, 1, nil, nil)),
+				Signature:         utils.EncodeBase64URL([]byte("signature")),
+			},
+		}, testClient)
+		require.ErrorIs(t, err, errs.ErrWebAuthnVerification)
+	}
+
+	// лимит с IP закрывает и вход ключом, и вход паролем
+	_, err := env.passkeyLogin(t, "alice", pk, utils.AuthFlagUserPresent)
+	assert.ErrorIs(t, err, errs.ErrTooManyAttempts)
+	_, err = env.services.GenerateTokens(ctx, "alice", testPassword, testClient)
+	assert.ErrorIs(t, err, errs.ErrTooManyAttempts)
+}
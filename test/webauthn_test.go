@@ -0,0 +1,160 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"service-auth/internal/app/utils"
+)
+
+// cborItem кодирует элемент CBOR: заголовок major type и аргумент
+func cborItem(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg < 1<<8:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg < 1<<16:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	}
+}
+
+// cborEncode минимальный кодировщик CBOR для построения ответов аутентификатора в тестах
+func cborEncode(t *testing.T, value any) []byte {
+	t.Helper()
+	switch v := value.(type) {
+	case int:
+		if v >= 0 {
+			return cborItem(0, uint64(v))
+		}
+		return cborItem(1, uint64(-1-v))
+	case []byte:
+		return append(cborItem(2, uint64(len(v))), v...)
+	case string:
+		return append(cborItem(3, uint64(len(v))), v...)
+	case map[any]any:
+		out := cborItem(5, uint64(len(v)))
+		for key, item := range v {
+			out = append(out, cborEncode(t, key)...)
+			out = append(out, cborEncode(t, item)...)
+		}
+		return out
+	default:
+		t.Fatalf("unsupported cbor value %T", value)
+		return nil
+	}
+}
+
+// authenticatorData собирает authenticatorData, coseKey != nil добавляет attested credential data
+func authenticatorData(rpID string, flags byte, signCount uint32, credentialID, coseKey []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	if coseKey != nil {
+		data = append(data, make([]byte, 16)...) // aaguid
+		data = binary.BigEndian.AppendUint16(data, uint16(len(credentialID)))
+		data = append(data, credentialID...)
+		data = append(data, coseKey...)
+	}
+	return data
+}
+
+func es256COSEKey(t *testing.T, pub *ecdsa.PublicKey) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	pub.X.FillBytes(x)
+	pub.Y.FillBytes(y)
+	return cborEncode(t, map[any]any{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+}
+
+func TestWebAuthn_RegistrationAndAssertionES256(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	coseKey := es256COSEKey(t, &privateKey.PublicKey)
+	credentialID := []byte("credential-1")
+
+	// регистрация: attestationObject с форматом none
+	regAuthData := authenticatorData("example.com",
+		utils.AuthFlagUserPresent|utils.AuthFlagUserVerified|utils.AuthFlagAttestedCredData, 0, credentialID, coseKey)
+	attestation := cborEncode(t, map[any]any{"fmt": "none", "attStmt": map[any]any{}, "authData": regAuthData})
+
+	format, rawAuthData, err := utils.ParseAttestationObject(attestation)
+	require.NoError(t, err)
+	assert.Equal(t, "none", format)
+
+	parsed, err := utils.ParseAuthenticatorData(rawAuthData)
+	require.NoError(t, err)
+	assert.True(t, parsed.VerifyRPIDHash("example.com"))
+	assert.False(t, parsed.VerifyRPIDHash("evil.com"))
+	assert.True(t, parsed.HasFlag(utils.AuthFlagUserVerified))
+	assert.Equal(t, credentialID, parsed.CredentialID)
+	assert.Equal(t, coseKey, parsed.CredentialPublicKey)
+
+	_, alg, err := utils.ParseCOSEKey(parsed.CredentialPublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, utils.COSEAlgES256, alg)
+
+	// вход: подпись над authenticatorData || SHA-256(clientDataJSON)
+	clientDataJSON, err := json.Marshal(utils.ClientData{
+		Type: utils.WebAuthnTypeGet, Challenge: "challenge", Origin: "https://example.com",
+	})
+	require.NoError(t, err)
+	assertionAuthData := authenticatorData("example.com", utils.AuthFlagUserPresent, 1, nil, nil)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, assertionAuthData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	require.NoError(t, err)
+
+	assert.NoError(t, utils.VerifyAssertionSignature(coseKey, assertionAuthData, clientDataJSON, signature))
+
+	// подмена clientDataJSON ломает подпись
+	tampered := append([]byte{}, clientDataJSON...)
+	tampered[len(tampered)-2] ^= 1
+	assert.Error(t, utils.VerifyAssertionSignature(coseKey, assertionAuthData, tampered, signature))
+}
+
+func TestWebAuthn_AssertionEdDSA(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	coseKey := cborEncode(t, map[any]any{1: 1, 3: -8, -1: 6, -2: []byte(publicKey)})
+
+	clientDataJSON := []byte(`{"type":"webauthn.get","challenge":"c","origin":"https://example.com"}`)
+	authData := authenticatorData("example.com", utils.AuthFlagUserPresent, 0, nil, nil)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signature := ed25519.Sign(privateKey, append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	assert.NoError(t, utils.VerifyAssertionSignature(coseKey, authData, clientDataJSON, signature))
+}
+
+func TestWebAuthn_Malformed(t *testing.T) {
+	_, err := utils.ParseAuthenticatorData(make([]byte, 10))
+	assert.ErrorIs(t, err, utils.ErrWebAuthnMalformed)
+
+	// attested credential data обрезана
+	data := authenticatorData("example.com", utils.AuthFlagAttestedCredData, 0, []byte("id"), []byte{0xa5})
+	_, err = utils.ParseAuthenticatorData(data)
+	assert.ErrorIs(t, err, utils.ErrWebAuthnMalformed)
+
+	_, _, err = utils.ParseAttestationObject([]byte{0x9f})
+	assert.ErrorIs(t, err, utils.ErrWebAuthnMalformed)
+
+	// неподдерживаемый алгоритм ключа
+	_, _, err = utils.ParseCOSEKey(cborEncode(t, map[any]any{1: 2, 3: -35}))
+	assert.ErrorIs(t, err, utils.ErrWebAuthnMalformed)
+
+	// точка вне кривой
+	_, _, err = utils.ParseCOSEKey(cborEncode(t, map[any]any{1: 2, 3: -7, -1: 1, -2: make([]byte, 32), -3: make([]byte, 32)}))
+	assert.ErrorIs(t, err, utils.ErrWebAuthnMalformed)
+}